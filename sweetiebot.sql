@@ -17,14 +17,14 @@ USE `sweetiebot`;
 
 -- Dumping structure for procedure sweetiebot.AddChat
 DELIMITER //
-CREATE DEFINER=`root`@`localhost` PROCEDURE `AddChat`(IN `_id` BIGINT, IN `_author` BIGINT, IN `_message` VARCHAR(2000), IN `_channel` BIGINT, IN `_everyone` BIT, IN `_guild` BIGINT)
+CREATE DEFINER=`root`@`localhost` PROCEDURE `AddChat`(IN `_id` BIGINT, IN `_author` BIGINT, IN `_message` VARCHAR(2000), IN `_channel` BIGINT, IN `_everyone` BIT, IN `_guild` BIGINT, IN `_attachments` TINYINT UNSIGNED)
     DETERMINISTIC
 BEGIN
 
 CALL SawUser(_author);
 
-INSERT INTO chatlog (ID, Author, Message, Timestamp, Channel, Everyone, Guild)
-VALUES (_id, _author, _message, UTC_TIMESTAMP(), _channel, _everyone, _guild)
+INSERT INTO chatlog (ID, Author, Message, Timestamp, Channel, Everyone, Guild, Attachments)
+VALUES (_id, _author, _message, UTC_TIMESTAMP(), _channel, _everyone, _guild, _attachments)
 ON DUPLICATE KEY UPDATE /* This prevents a race condition from causing a serious error */
 Message = _message COLLATE 'utf8mb4_general_ci', Timestamp = UTC_TIMESTAMP(), Everyone=_everyone;
 
@@ -97,6 +97,24 @@ CREATE TABLE IF NOT EXISTS `aliases` (
 -- Data exporting was unselected.
 
 
+-- Dumping structure for table sweetiebot.appeals
+CREATE TABLE IF NOT EXISTS `appeals` (
+  `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `Guild` bigint(20) unsigned NOT NULL,
+  `User` bigint(20) unsigned NOT NULL,
+  `Type` tinyint(3) unsigned NOT NULL,
+  `Reason` varchar(2000) NOT NULL DEFAULT '',
+  `Appeal` varchar(2000) DEFAULT NULL,
+  `Status` tinyint(3) unsigned NOT NULL DEFAULT '0',
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`ID`),
+  KEY `INDEX_USER_STATUS` (`User`,`Status`),
+  KEY `INDEX_GUILD` (`Guild`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.chatlog
 CREATE TABLE IF NOT EXISTS `chatlog` (
   `ID` bigint(20) unsigned NOT NULL,
@@ -106,32 +124,18 @@ CREATE TABLE IF NOT EXISTS `chatlog` (
   `Channel` bigint(20) unsigned NOT NULL,
   `Everyone` bit(1) NOT NULL,
   `Guild` bigint(20) unsigned NOT NULL,
+  `Attachments` tinyint(3) unsigned NOT NULL DEFAULT '0',
   PRIMARY KEY (`ID`),
   KEY `INDEX_TIMESTAMP` (`Timestamp`),
   KEY `INDEX_CHANNEL` (`Channel`),
   KEY `CHATLOG_USERS` (`Author`),
+  FULLTEXT KEY `INDEX_MESSAGE` (`Message`),
   CONSTRAINT `CHATLOG_USERS` FOREIGN KEY (`Author`) REFERENCES `users` (`ID`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='A log of all the messages from all the chatrooms.';
 
 -- Data exporting was unselected.
 
 
--- Dumping structure for event sweetiebot.CleanChatlog
-DELIMITER //
-CREATE DEFINER=`root`@`localhost` EVENT `CleanChatlog` ON SCHEDULE EVERY 1 DAY STARTS '2016-01-29 17:04:34' ON COMPLETION NOT PRESERVE ENABLE DO BEGIN
-DELETE FROM chatlog WHERE Timestamp < DATE_SUB(UTC_TIMESTAMP(), INTERVAL 7 DAY);
-END//
-DELIMITER ;
-
-
--- Dumping structure for event sweetiebot.CleanDebugLog
-DELIMITER //
-CREATE DEFINER=`root`@`localhost` EVENT `CleanDebugLog` ON SCHEDULE EVERY 1 DAY STARTS '2016-01-29 17:30:36' ON COMPLETION NOT PRESERVE ENABLE DO BEGIN
-DELETE FROM debuglog WHERE Timestamp < DATE_SUB(UTC_TIMESTAMP(), INTERVAL 8 DAY);
-END//
-DELIMITER ;
-
-
 -- Dumping structure for table sweetiebot.debuglog
 CREATE TABLE IF NOT EXISTS `debuglog` (
   `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
@@ -149,6 +153,32 @@ CREATE TABLE IF NOT EXISTS `debuglog` (
 -- Data exporting was unselected.
 
 
+-- Dumping structure for table sweetiebot.deletelog
+CREATE TABLE IF NOT EXISTS `deletelog` (
+  `ID` bigint(20) unsigned NOT NULL,
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`ID`),
+  KEY `INDEX_TIMESTAMP` (`Timestamp`),
+  CONSTRAINT `DELETELOG_CHATLOG` FOREIGN KEY (`ID`) REFERENCES `chatlog` (`ID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Records when a message in the chatlog was deleted.';
+
+-- Data exporting was unselected.
+
+
+-- Dumping structure for table sweetiebot.departures
+CREATE TABLE IF NOT EXISTS `departures` (
+  `ID` bigint(20) unsigned NOT NULL,
+  `Guild` bigint(20) unsigned NOT NULL,
+  `FirstSeen` datetime NOT NULL,
+  `FirstMessage` datetime DEFAULT NULL,
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`ID`,`Guild`,`Timestamp`),
+  KEY `INDEX_GUILD_TIMESTAMP` (`Guild`,`Timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Records members that left a server, along with when they joined.';
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.editlog
 CREATE TABLE IF NOT EXISTS `editlog` (
   `ID` bigint(20) unsigned NOT NULL,
@@ -158,7 +188,7 @@ CREATE TABLE IF NOT EXISTS `editlog` (
   `Channel` bigint(20) unsigned NOT NULL,
   `Everyone` bit(1) NOT NULL,
   `Guild` bigint(20) unsigned NOT NULL,
-  PRIMARY KEY (`ID`),
+  PRIMARY KEY (`ID`,`Timestamp`),
   KEY `INDEX_TIMESTAMP` (`Timestamp`),
   KEY `INDEX_CHANNEL` (`Channel`),
   KEY `CHATLOG_USERS` (`Author`),
@@ -427,6 +457,22 @@ CREATE TABLE IF NOT EXISTS `members` (
 -- Data exporting was unselected.
 
 
+-- Dumping structure for table sweetiebot.namelog
+CREATE TABLE IF NOT EXISTS `namelog` (
+  `ID` bigint(20) unsigned NOT NULL AUTO_INCREMENT,
+  `User` bigint(20) unsigned NOT NULL,
+  `Guild` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Type` tinyint(3) unsigned NOT NULL,
+  `Old` varchar(512) NOT NULL DEFAULT '',
+  `New` varchar(512) NOT NULL DEFAULT '',
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`ID`),
+  KEY `INDEX_USER_TIMESTAMP` (`User`,`Timestamp`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='A timeline of username (0), nickname (1) and avatar (2) changes. Guild is 0 for changes that apply everywhere.';
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for table sweetiebot.polloptions
 CREATE TABLE IF NOT EXISTS `polloptions` (
   `Poll` bigint(20) unsigned NOT NULL,
@@ -447,8 +493,16 @@ CREATE TABLE IF NOT EXISTS `polls` (
   `Guild` bigint(20) unsigned NOT NULL,
   `Name` varchar(50) NOT NULL,
   `Description` varchar(2048) NOT NULL,
+  `Channel` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Message` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Choices` tinyint(3) unsigned NOT NULL DEFAULT '1',
+  `Ranked` tinyint(3) unsigned NOT NULL DEFAULT '0',
+  `Role` bigint(20) unsigned NOT NULL DEFAULT '0',
+  `Anonymous` tinyint(3) unsigned NOT NULL DEFAULT '0',
+  `Closed` tinyint(3) unsigned NOT NULL DEFAULT '0',
   PRIMARY KEY (`ID`),
-  UNIQUE KEY `Index 2` (`Name`,`Guild`)
+  UNIQUE KEY `Index 2` (`Name`,`Guild`),
+  KEY `INDEX_MESSAGE` (`Message`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
 -- Data exporting was unselected.
@@ -506,6 +560,19 @@ END//
 DELIMITER ;
 
 
+-- Dumping structure for table sweetiebot.rsvps
+CREATE TABLE IF NOT EXISTS `rsvps` (
+  `Event` bigint(20) unsigned NOT NULL,
+  `User` bigint(20) unsigned NOT NULL,
+  `Timestamp` datetime NOT NULL,
+  PRIMARY KEY (`Event`,`User`),
+  KEY `INDEX_USER` (`User`),
+  CONSTRAINT `FK_rsvps_schedule` FOREIGN KEY (`Event`) REFERENCES `schedule` (`ID`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='Members that will attend a community event.';
+
+-- Data exporting was unselected.
+
+
 -- Dumping structure for procedure sweetiebot.SawUser
 DELIMITER //
 CREATE DEFINER=`root`@`localhost` PROCEDURE `SawUser`(IN `_id` BIGINT)
@@ -522,8 +589,13 @@ CREATE TABLE IF NOT EXISTS `schedule` (
   `Date` datetime NOT NULL,
   `RepeatInterval` tinyint(3) unsigned DEFAULT NULL,
   `Repeat` int(11) DEFAULT NULL,
+  `Cron` varchar(128) DEFAULT NULL,
   `Type` tinyint(3) unsigned NOT NULL,
   `Data` text NOT NULL,
+  `Status` tinyint(3) unsigned NOT NULL DEFAULT '0',
+  `Attempts` tinyint(3) unsigned NOT NULL DEFAULT '0',
+  `Retry` datetime DEFAULT NULL,
+  `LastError` varchar(255) DEFAULT NULL,
   PRIMARY KEY (`ID`),
   KEY `INDEX_GUILD_DATE_TYPE` (`Date`,`Guild`,`Type`),
   KEY `INDEX_GUILD` (`Guild`)
@@ -582,7 +654,8 @@ CREATE TABLE IF NOT EXISTS `votes` (
   `Poll` bigint(20) unsigned NOT NULL,
   `User` bigint(20) unsigned NOT NULL,
   `Option` bigint(20) unsigned NOT NULL,
-  PRIMARY KEY (`Poll`,`User`),
+  `Rank` tinyint(3) unsigned NOT NULL DEFAULT '1',
+  PRIMARY KEY (`Poll`,`User`,`Option`),
   KEY `FK_votes_users` (`User`),
   KEY `FK_votes_options` (`Poll`,`Option`),
   CONSTRAINT `FK_votes_options` FOREIGN KEY (`Poll`, `Option`) REFERENCES `polloptions` (`Poll`, `Index`),
@@ -597,6 +670,7 @@ SET @OLDTMP_SQL_MODE=@@SQL_MODE, SQL_MODE='NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTIT
 DELIMITER //
 CREATE TRIGGER `chatlog_before_delete` BEFORE DELETE ON `chatlog` FOR EACH ROW BEGIN
 DELETE FROM editlog WHERE ID = OLD.ID;
+DELETE FROM deletelog WHERE ID = OLD.ID;
 END//
 DELIMITER ;
 SET SQL_MODE=@OLDTMP_SQL_MODE;
@@ -607,9 +681,38 @@ SET @OLDTMP_SQL_MODE=@@SQL_MODE, SQL_MODE='NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTIT
 DELIMITER //
 CREATE TRIGGER `chatlog_before_update` BEFORE UPDATE ON `chatlog` FOR EACH ROW BEGIN
 
+IF NEW.Message <> OLD.Message THEN
 INSERT INTO editlog (ID, Author, Message, Timestamp, Channel, Everyone, Guild)
 VALUES (OLD.ID, OLD.Author, OLD.Message, OLD.Timestamp, OLD.Channel, OLD.Everyone, OLD.Guild)
-ON DUPLICATE KEY UPDATE ID = OLD.ID;
+ON DUPLICATE KEY UPDATE Message = OLD.Message;
+END IF;
+
+END//
+DELIMITER ;
+SET SQL_MODE=@OLDTMP_SQL_MODE;
+
+
+-- Dumping structure for trigger sweetiebot.members_before_delete
+SET @OLDTMP_SQL_MODE=@@SQL_MODE, SQL_MODE='NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTITUTION';
+DELIMITER //
+CREATE TRIGGER `members_before_delete` BEFORE DELETE ON `members` FOR EACH ROW BEGIN
+INSERT IGNORE INTO departures (ID, Guild, FirstSeen, FirstMessage, Timestamp)
+VALUES (OLD.ID, OLD.Guild, OLD.FirstSeen, OLD.FirstMessage, UTC_TIMESTAMP());
+END//
+DELIMITER ;
+SET SQL_MODE=@OLDTMP_SQL_MODE;
+
+
+-- Dumping structure for trigger sweetiebot.members_before_update
+SET @OLDTMP_SQL_MODE=@@SQL_MODE, SQL_MODE='NO_AUTO_CREATE_USER,NO_ENGINE_SUBSTITUTION';
+DELIMITER //
+CREATE TRIGGER `members_before_update` BEFORE UPDATE ON `members` FOR EACH ROW BEGIN
+
+IF NEW.Nickname != OLD.Nickname THEN
+SET NEW.LastNickChange = UTC_TIMESTAMP();
+INSERT INTO namelog (User, Guild, Type, Old, New, Timestamp)
+VALUES (OLD.ID, OLD.Guild, 1, OLD.Nickname, NEW.Nickname, UTC_TIMESTAMP());
+END IF;
 
 END//
 DELIMITER ;
@@ -663,6 +766,13 @@ SET @diff = UNIX_TIMESTAMP(NEW.LastNameChange) - UNIX_TIMESTAMP(OLD.LastNameChan
 INSERT INTO aliases (User, Alias, Duration)
 VALUES (OLD.ID, OLD.Username, @diff)
 ON DUPLICATE KEY UPDATE Duration = Duration + @diff;
+INSERT INTO namelog (User, Guild, Type, Old, New, Timestamp)
+VALUES (OLD.ID, 0, 0, OLD.Username, NEW.Username, UTC_TIMESTAMP());
+END IF;
+
+IF NEW.Avatar != OLD.Avatar THEN
+INSERT INTO namelog (User, Guild, Type, Old, New, Timestamp)
+VALUES (OLD.ID, 0, 2, OLD.Avatar, NEW.Avatar, UTC_TIMESTAMP());
 END IF;
 
 END//
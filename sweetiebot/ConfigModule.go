@@ -33,6 +33,11 @@ func (w *ConfigModule) Commands() []Command {
 // Description of the module
 func (w *ConfigModule) Description() string { return "Manages Sweetie Bot's configuration file." }
 
+// configHidden returns true for config options that only the bot itself may see or change, like secrets
+func configHidden(f reflect.StructField) bool {
+	return f.Tag.Get("config") == "hidden"
+}
+
 func fixRequest(arg string, t reflect.Value) (string, error) {
 	args := strings.SplitN(strings.ToLower(arg), ".", 3)
 	list := []string{}
@@ -49,7 +54,7 @@ func fixRequest(arg string, t reflect.Value) (string, error) {
 		case reflect.Struct:
 			f := t.Field(i)
 			for j := 0; j < f.NumField(); j++ {
-				if strings.ToLower(f.Type().Field(j).Name) == args[0] {
+				if strings.ToLower(f.Type().Field(j).Name) == args[0] && !configHidden(f.Type().Field(j)) {
 					list = append(list, t.Type().Field(i).Name)
 				}
 			}
@@ -85,6 +90,11 @@ func (c *setConfigCommand) Process(args []string, msg *discordgo.Message, indice
 	if err != nil {
 		return err.Error(), false, nil
 	}
+	if strings.ToLower(args[0]) == "spam.lockdownlevels" {
+		if err := checkLockdownActions(args[2:]); err != nil {
+			return "```Error: " + err.Error() + "```", false, nil
+		}
+	}
 	n, ok := info.SetConfig(args[0], args[1], args[2:]...)
 	info.SaveConfig()
 	if ok {
@@ -233,6 +243,9 @@ func (c *getConfigCommand) Process(args []string, msg *discordgo.Message, indice
 				f := t.Field(i)
 				s := make([]string, 0, f.NumField())
 				for j := 0; j < f.NumField(); j++ {
+					if configHidden(f.Type().Field(j)) {
+						continue
+					}
 					str := f.Type().Field(j).Name
 					switch f.Field(j).Interface().(type) {
 					case []uint64, map[string]bool:
@@ -277,13 +290,16 @@ func (c *getConfigCommand) Process(args []string, msg *discordgo.Message, indice
 				f := t.Field(i)
 				if len(arg) > 1 {
 					for j := 0; j < f.NumField(); j++ {
-						if strings.ToLower(f.Type().Field(j).Name) == arg[1] {
+						if strings.ToLower(f.Type().Field(j).Name) == arg[1] && !configHidden(f.Type().Field(j)) {
 							return c.GetSubStruct(arg, f, j, info)
 						}
 					}
 				} else {
 					fields := make([]*discordgo.MessageEmbedField, 0, f.NumField())
 					for j := 0; j < f.NumField(); j++ {
+						if configHidden(f.Type().Field(j)) {
+							continue
+						}
 						desc, ok := ConfigHelp[strings.ToLower(t.Type().Field(i).Name+"."+f.Type().Field(j).Name)]
 						if !ok {
 							desc = "\u200b"
@@ -437,7 +453,7 @@ func (c *setupCommand) Process(args []string, msg *discordgo.Message, indices []
 	info.config.Basic.Aliases["calc"] = "roll"
 	info.config.Basic.Aliases["calculate"] = "roll"
 
-	sensitive := []string{"add", "addrole", "addwit", "ban", "disable", "dumptables", "echo", "enable", "getconfig", "deleterole", "removerole", "remove", "removewit", "setconfig", "setstatus", "update", "announce", "collections", "addevent", "addbirthday", "autosilence", "silence", "unsilence", "wipe", "new", "addquote", "removequote", "removealias", "delete", "createpoll", "deletepoll", "addoption", "echoembed", "getpressure", "getaudit", "getraid", "banraid", "bannewcomers"}
+	sensitive := []string{"add", "addrole", "addwit", "ban", "disable", "dumptables", "echo", "enable", "getconfig", "deleterole", "removerole", "remove", "removewit", "setconfig", "setstatus", "update", "announce", "collections", "addevent", "addbirthday", "autosilence", "silence", "unsilence", "wipe", "new", "addquote", "removequote", "removealias", "delete", "createpoll", "deletepoll", "addoption", "echoembed", "getpressure", "getaudit", "getraid", "banraid", "bannewcomers", "appeal", "simulatespam", "raidreport", "lockdown", "unlock", "history", "deleted", "purgelog", "transcript", "joinstats", "failedevents", "postpoll", "pollsettings", "voters"}
 	modint := SBitoa(info.config.Basic.AlertRole)
 
 	for _, v := range sensitive {
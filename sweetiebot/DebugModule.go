@@ -31,6 +31,7 @@ func (w *DebugModule) Commands() []Command {
 		&announceCommand{},
 		&removeAliasCommand{},
 		&getAuditCommand{},
+		&forgetUserCommand{},
 	}
 }
 
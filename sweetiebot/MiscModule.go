@@ -22,6 +22,11 @@ func (w *MiscModule) Commands() []Command {
 	return []Command{
 		&LastSeenCommand{},
 		&searchCommand{emotes: w.emotes, statements: make(map[string][]*sql.Stmt)},
+		&historyCommand{},
+		&deletedCommand{},
+		&purgeLogCommand{},
+		&transcriptCommand{},
+		&statsCommand{},
 		&rollCommand{},
 		&SnowflakeTimeCommand{},
 	}
@@ -41,6 +41,18 @@ type ModuleOnMessageDelete interface {
 	OnMessageDelete(*GuildInfo, *discordgo.Message)
 }
 
+// ModuleOnMessageReactionAdd hook interface
+type ModuleOnMessageReactionAdd interface {
+	Module
+	OnMessageReactionAdd(*GuildInfo, *discordgo.MessageReactionAdd)
+}
+
+// ModuleOnMessageReactionRemove hook interface
+type ModuleOnMessageReactionRemove interface {
+	Module
+	OnMessageReactionRemove(*GuildInfo, *discordgo.MessageReactionRemove)
+}
+
 // ModuleOnPresenceUpdate hook interface
 type ModuleOnPresenceUpdate interface {
 	Module
@@ -114,6 +126,22 @@ type ModuleOnTick interface {
 	OnTick(*GuildInfo)
 }
 
+// ModuleScheduleEvents hook interface, for modules that define their own kinds of scheduled events
+type ModuleScheduleEvents interface {
+	Module
+	ScheduleEvents() []ScheduleEventKind
+}
+
+// ScheduleEventKind processes one type of scheduled event. Type() is the value stored in the schedule table and must be unique across all modules, Name() is used to refer to the type in commands, and Private() hides these events from anyone but the moderators. Skippable() allows events to be skipped if they are processed too late, which must be false for events that undo moderation actions. Process() returns an error if the event should be retried. Describe() returns the tag and text used to list an event.
+type ScheduleEventKind interface {
+	Type() uint8
+	Name() string
+	Private() bool
+	Skippable() bool
+	Process(*GuildInfo, *ScheduleEvent, string) error
+	Describe(*GuildInfo, *ScheduleEvent, *discordgo.Message) (string, string)
+}
+
 // CommandUsageParam describes a single parameter to a command
 type CommandUsageParam struct {
 	Name     string
@@ -249,6 +277,8 @@ type moduleHooks struct {
 	OnMessageCreate     []ModuleOnMessageCreate
 	OnMessageUpdate     []ModuleOnMessageUpdate
 	OnMessageDelete     []ModuleOnMessageDelete
+	OnReactionAdd       []ModuleOnMessageReactionAdd
+	OnReactionRemove    []ModuleOnMessageReactionRemove
 	OnPresenceUpdate    []ModuleOnPresenceUpdate
 	OnGuildUpdate       []ModuleOnGuildUpdate
 	OnGuildMemberAdd    []ModuleOnGuildMemberAdd
@@ -260,6 +290,7 @@ type moduleHooks struct {
 	OnCommand           []ModuleOnCommand
 	OnIdle              []ModuleOnIdle
 	OnTick              []ModuleOnTick
+	ScheduleEvents      map[uint8]ScheduleEventKind
 }
 
 func (info *GuildInfo) RegisterModule(m Module) {
@@ -275,6 +306,12 @@ func (info *GuildInfo) RegisterModule(m Module) {
 	if h, ok := m.(ModuleOnMessageDelete); ok {
 		info.hooks.OnMessageDelete = append(info.hooks.OnMessageDelete, h)
 	}
+	if h, ok := m.(ModuleOnMessageReactionAdd); ok {
+		info.hooks.OnReactionAdd = append(info.hooks.OnReactionAdd, h)
+	}
+	if h, ok := m.(ModuleOnMessageReactionRemove); ok {
+		info.hooks.OnReactionRemove = append(info.hooks.OnReactionRemove, h)
+	}
 	if h, ok := m.(ModuleOnPresenceUpdate); ok {
 		info.hooks.OnPresenceUpdate = append(info.hooks.OnPresenceUpdate, h)
 	}
@@ -309,4 +346,12 @@ func (info *GuildInfo) RegisterModule(m Module) {
 	if h, ok := m.(ModuleOnTick); ok {
 		info.hooks.OnTick = append(info.hooks.OnTick, h)
 	}
+	if h, ok := m.(ModuleScheduleEvents); ok {
+		if info.hooks.ScheduleEvents == nil {
+			info.hooks.ScheduleEvents = make(map[uint8]ScheduleEventKind)
+		}
+		for _, k := range h.ScheduleEvents() {
+			info.hooks.ScheduleEvents[k.Type()] = k
+		}
+	}
 }
@@ -4,10 +4,14 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/blackhole12/discordgo"
 )
 
+// pollEmoji are the reactions used to vote for the first ten options of a poll posted with !postpoll. Keycaps are written in their fully qualified form with the variation selector, which is the form Discord uses for reactions.
+var pollEmoji = []string{"1\ufe0f\u20e3", "2\ufe0f\u20e3", "3\ufe0f\u20e3", "4\ufe0f\u20e3", "5\ufe0f\u20e3", "6\ufe0f\u20e3", "7\ufe0f\u20e3", "8\ufe0f\u20e3", "9\ufe0f\u20e3", "\U0001f51f"}
+
 // PollModule manages the polling system
 type PollModule struct {
 }
@@ -26,12 +30,213 @@ func (w *PollModule) Commands() []Command {
 		&voteCommand{},
 		&resultsCommand{},
 		&addOptionCommand{},
+		&postPollCommand{},
+		&pollSettingsCommand{},
+		&votersCommand{},
 	}
 }
 
 // Description of the module
 func (w *PollModule) Description() string { return "Manages the polling system." }
 
+// ScheduleEvents defines the event that closes a poll
+func (w *PollModule) ScheduleEvents() []ScheduleEventKind {
+	return []ScheduleEventKind{
+		&scheduleKind{SCHEDULE_POLL, "poll", false, false, closePollEvent, func(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+			p := SchedulePollPayload{}
+			e.Payload(&p)
+			if poll := sb.db.GetPollByID(p.Poll); poll != nil {
+				return "POLL", "Closes the " + poll.Name + " poll"
+			}
+			return "POLL", "Closes a deleted poll"
+		}},
+	}
+}
+
+// OnMessageReactionAdd discord hook
+func (w *PollModule) OnMessageReactionAdd(info *GuildInfo, m *discordgo.MessageReactionAdd) {
+	option := pollEmojiOption(m.Emoji.Name)
+	if option == 0 || !sb.db.CheckStatus() {
+		return
+	}
+	poll := sb.db.GetPollByMessage(SBatoi(info.ID), SBatoi(m.MessageID))
+	if poll == nil || !sb.db.CheckOption(poll.ID, option) {
+		return
+	}
+	if poll.Anonymous {
+		sb.dg.MessageReactionRemove(m.ChannelID, m.MessageID, m.Emoji.Name, m.UserID) // Reactions show who voted for what, so anonymous polls take them back right away
+	}
+	votes := sb.db.GetVotes(SBatoi(m.UserID), poll.ID)
+	i := -1
+	for k, v := range votes {
+		if v == option {
+			i = k
+		}
+	}
+	switch {
+	case pollVoteError(info, poll, m.UserID) != "":
+		votes = nil
+	case i >= 0 && poll.Anonymous: // Members can't take back a reaction that is already gone, so reacting again takes back the vote instead
+		votes = append(votes[:i], votes[i+1:]...)
+	case i >= 0:
+		return
+	case poll.Choices == 1:
+		votes = []uint64{option}
+	case poll.Choices == 0 || len(votes) < poll.Choices:
+		votes = append(votes, option)
+	default:
+		votes = nil
+	}
+	if votes == nil {
+		if !poll.Anonymous {
+			sb.dg.MessageReactionRemove(m.ChannelID, m.MessageID, m.Emoji.Name, m.UserID)
+		}
+		return
+	}
+	castPollVote(info, m.UserID, poll, votes)
+}
+
+// OnMessageReactionRemove discord hook
+func (w *PollModule) OnMessageReactionRemove(info *GuildInfo, m *discordgo.MessageReactionRemove) {
+	option := pollEmojiOption(m.Emoji.Name)
+	if option == 0 || !sb.db.CheckStatus() {
+		return
+	}
+	poll := sb.db.GetPollByMessage(SBatoi(info.ID), SBatoi(m.MessageID))
+	if poll == nil || poll.Anonymous || poll.Closed {
+		return
+	}
+	if sb.db.RemoveVote(SBatoi(m.UserID), poll.ID, option) > 0 {
+		updatePollMessage(info, poll)
+	}
+}
+
+// pollEmojiOption returns the option index a reaction votes for, or 0 if it isn't one of the poll reactions
+func pollEmojiOption(emoji string) uint64 {
+	emoji = strings.Replace(emoji, "\ufe0f", "", -1) // Reactions from some clients include the variation selector and others don't, so keycaps are compared without it
+	for i, v := range pollEmoji {
+		if strings.Replace(v, "\ufe0f", "", -1) == emoji {
+			return uint64(i + 1)
+		}
+	}
+	return 0
+}
+
+// addPollReactions adds the reactions members use to vote for each option to the message a poll was posted in
+func addPollReactions(channel string, message string, options []PollOptionStruct) {
+	for _, v := range options {
+		if v.index <= uint64(len(pollEmoji)) {
+			sb.dg.MessageReactionAdd(channel, message, pollEmoji[v.index-1])
+		}
+	}
+}
+
+// pollOptionLine shows the votes for a single option as a bar graph. If emoji is true, the option is labeled with its reaction instead of its index.
+func pollOptionLine(v PollOptionStruct, count uint64, max uint64, wide bool, emoji bool) string {
+	graph := barGraph(count, max)
+	if emoji && v.index <= uint64(len(pollEmoji)) {
+		return fmt.Sprintf("%s %s %s (%v votes)", pollEmoji[v.index-1], graph, v.option, count)
+	}
+	buf := ""
+	if v.index < 10 && wide {
+		buf = "_"
+	}
+	return fmt.Sprintf("`%s%v. `%s %s (%v votes)", buf, v.index, graph, v.option, count)
+}
+
+// pollResults returns the description of a poll followed by a bar graph of the votes for each option. Ranked polls show each round of the instant runoff, or only the last one if short is true, which also labels options with their reactions.
+func pollResults(poll *PollStruct, short bool) []string {
+	options := sb.db.GetOptions(poll.ID)
+	str := make([]string, 0, len(options)+3)
+	str = append(str, poll.Description)
+	if poll.Ranked {
+		return append(str, rankedResults(poll, options, short)...)
+	}
+	results := sb.db.GetResults(poll.ID)
+	max := uint64(0)
+	for _, v := range results {
+		if v.count > max {
+			max = v.count
+		}
+	}
+
+	k := 0
+	var count uint64
+	for _, v := range options {
+		count = 0
+		if k < len(results) && v.index == results[k].index {
+			count = results[k].count
+			k++
+		}
+		str = append(str, pollOptionLine(v, count, max, len(options) > 9, short))
+	}
+	if poll.Choices != 1 {
+		str = append(str, Pluralize(int64(len(sb.db.GetBallots(poll.ID))), " member")+" voted")
+	}
+	return str
+}
+
+// pollEmbed builds the message a poll is posted in, which shows the current results
+func pollEmbed(info *GuildInfo, poll *PollStruct) *discordgo.MessageEmbed {
+	footer := pollSettingsText(info, poll, nil)
+	if !poll.Closed {
+		footer += ". React to vote, or use " + info.config.Basic.CommandPrefix + "vote " + poll.Name + " <option>"
+	}
+	return &discordgo.MessageEmbed{
+		Type: "rich",
+		Author: &discordgo.MessageEmbedAuthor{
+			URL:     "https://github.com/blackhole12/sweetiebot",
+			Name:    "Poll: " + poll.Name,
+			IconURL: fmt.Sprintf("https://cdn.discordapp.com/avatars/%v/%s.jpg", sb.SelfID, sb.SelfAvatar),
+		},
+		Description: strings.Join(pollResults(poll, true), "\n"),
+		Color:       0x3e92e5,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: footer,
+		},
+	}
+}
+
+// updatePollMessage shows the current results in the message a poll was posted in, if it was posted with !postpoll
+func updatePollMessage(info *GuildInfo, poll *PollStruct) {
+	channel, message := sb.db.GetPollMessage(poll.ID)
+	if message != 0 {
+		sb.dg.ChannelMessageEditEmbed(SBitoa(channel), SBitoa(message), pollEmbed(info, poll))
+	}
+}
+
+// pollVoteError returns why a member can't vote in a poll, or an empty string if they can
+func pollVoteError(info *GuildInfo, poll *PollStruct, user string) string {
+	if poll.Closed {
+		return "```That poll is closed.```"
+	}
+	if poll.Role != 0 && !info.UserHasRole(user, SBitoa(poll.Role)) {
+		return "```Only members with the " + pollRoleName(info, poll.Role) + " role can vote in that poll.```"
+	}
+	return ""
+}
+
+// castPollVote replaces a member's vote using SetVotes, so votes cast with !vote and with reactions agree. If the poll was posted, the reactions for options the member no longer votes for are taken back and the results are updated.
+func castPollVote(info *GuildInfo, user string, poll *PollStruct, options []uint64) error {
+	old := sb.db.GetVotes(SBatoi(user), poll.ID)
+	if err := sb.db.SetVotes(SBatoi(user), poll.ID, options); err != nil {
+		return err
+	}
+	channel, message := sb.db.GetPollMessage(poll.ID)
+	if message == 0 {
+		return nil
+	}
+	if !poll.Anonymous {
+		for _, v := range old {
+			if !FindIntSlice(v, options) && v <= uint64(len(pollEmoji)) {
+				sb.dg.MessageReactionRemove(SBitoa(channel), SBitoa(message), pollEmoji[v-1], user)
+			}
+		}
+	}
+	sb.dg.ChannelMessageEditEmbed(SBitoa(channel), SBitoa(message), pollEmbed(info, poll))
+	return nil
+}
+
 type pollCommand struct {
 }
 
@@ -54,14 +259,15 @@ func (c *pollCommand) Process(args []string, msg *discordgo.Message, indices []i
 		return strings.Join(str, "\n"), len(str) > 5, nil
 	}
 	arg := strings.ToLower(msg.Content[indices[0]:])
-	id, desc := sb.db.GetPoll(arg, gID)
-	if id == 0 {
+	id, _ := sb.db.GetPoll(arg, gID)
+	poll := sb.db.GetPollByID(id)
+	if poll == nil {
 		return "```That poll doesn't exist!```", false, nil
 	}
 	options := sb.db.GetOptions(id)
 
-	str := make([]string, 0, len(options)+2)
-	str = append(str, desc)
+	str := make([]string, 0, len(options)+3)
+	str = append(str, poll.Description, "*"+pollSettingsText(info, poll, msg.Author)+"*")
 
 	for _, v := range options {
 		str = append(str, fmt.Sprintf("%v. %s", v.index, v.option))
@@ -147,6 +353,7 @@ func (c *deletePollCommand) Process(args []string, msg *discordgo.Message, indic
 	if err != nil {
 		return "```Error removing poll.```", false, nil
 	}
+	sb.db.RemoveScheduleByData(gID, SCHEDULE_POLL, EncodeSchedulePayload(SchedulePollPayload{id}))
 	return fmt.Sprintf("```Successfully removed %s.```", arg), false, nil
 }
 func (c *deletePollCommand) Usage(info *GuildInfo) *CommandUsage {
@@ -180,34 +387,68 @@ func (c *voteCommand) Process(args []string, msg *discordgo.Message, indices []i
 	}
 	name := strings.ToLower(args[0])
 	id, _ := sb.db.GetPoll(name, gID)
-	if id == 0 {
+	poll := sb.db.GetPollByID(id)
+	if poll == nil {
 		return "```That poll doesn't exist! Use " + info.config.Basic.CommandPrefix + "poll with no arguments to list all active polls.```", false, nil
 	}
+	if poll.Anonymous {
+		hideAnonymousVote(info, msg, name)
+	}
+	if s := pollVoteError(info, poll, msg.Author.ID); s != "" {
+		return s, false, nil
+	}
 
-	option, err := strconv.ParseUint(args[1], 10, 64)
-	if err != nil {
+	options := []uint64{}
+	for _, v := range strings.FieldsFunc(msg.Content[indices[1]:], func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		option, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			options = nil
+			break
+		}
+		if !sb.db.CheckOption(id, option) {
+			return fmt.Sprintf("```%v is not a valid option index! Use \""+info.config.Basic.CommandPrefix+"poll %s\" to get all available options for this poll.```", option, name), false, nil
+		}
+		if FindIntSlice(option, options) {
+			return "```You can't pick the same option twice!```", false, nil
+		}
+		options = append(options, option)
+	}
+	if options == nil {
 		opt := sb.db.GetOption(id, msg.Content[indices[1]:])
 		if opt == nil {
 			return fmt.Sprintf("```That's not one of the poll options! You have to either type in the exact name of the option you want, or provide the numeric index. Use \""+info.config.Basic.CommandPrefix+"poll %s\" to list the available options.```", name), false, nil
 		}
-		option = *opt
-	} else if !sb.db.CheckOption(id, option) {
-		return fmt.Sprintf("```That's not a valid option index! Use \""+info.config.Basic.CommandPrefix+"poll %s\" to get all available options for this poll.```", name), false, nil
+		options = []uint64{*opt}
+	}
+	if poll.Choices == 1 && len(options) > 1 {
+		return "```You can only pick one option in this poll.```", false, nil
+	}
+	if poll.Choices > 0 && len(options) > poll.Choices {
+		return fmt.Sprintf("```You can only pick up to %v options in this poll.```", poll.Choices), false, nil
 	}
 
-	err = sb.db.AddVote(SBatoi(msg.Author.ID), id, option)
+	err := castPollVote(info, msg.Author.ID, poll, options)
 	if err != nil {
 		return "```Error adding vote.```", false, nil
 	}
 
 	return "```Voted! Use " + info.config.Basic.CommandPrefix + "results to check the results.```", false, nil
 }
+
+// hideAnonymousVote deletes a vote in an anonymous poll from the channel and the chatlog, and removes the options from the command audit
+func hideAnonymousVote(info *GuildInfo, msg *discordgo.Message, name string) {
+	if _, private := channelIsPrivate(msg.ChannelID); !private {
+		sb.db.RemoveMessage(SBatoi(msg.ID)) // Removed first so deleting it from the channel doesn't add it to the deletelog
+		sb.dg.ChannelMessageDelete(msg.ChannelID, msg.ID)
+	}
+	sb.db.RedactAudit(AUDIT_TYPE_COMMAND, SBatoi(msg.Author.ID), SBatoi(info.ID), msg.Content, info.config.Basic.CommandPrefix+"vote "+name+" (anonymous)")
+}
 func (c *voteCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Adds your vote to a given poll. If you have already voted in the poll, it changes your vote instead.",
+		Desc: "Adds your vote to a given poll. If you have already voted in the poll, it changes your vote instead. Some polls let you pick several options, or rank them from best to worst. Votes in anonymous polls are deleted from the channel and aren't logged.",
 		Params: []CommandUsageParam{
 			{Name: "poll", Desc: "Name of the poll you want to vote in.", Optional: false},
-			{Name: "option", Desc: "The numeric index of the option you want to vote for, or the precise text of the option instead.", Optional: false},
+			{Name: "option", Desc: "The numeric index of the option you want to vote for, or the precise text of the option instead. If the poll allows it, give several indices like `3 1 2` to pick several options, starting with your favorite.", Optional: false, Variadic: true},
 		},
 	}
 }
@@ -228,52 +469,12 @@ func (c *resultsCommand) Process(args []string, msg *discordgo.Message, indices
 		return "```You have to give me a valid poll name! Use \"" + info.config.Basic.CommandPrefix + "poll\" to list active polls.```", false, nil
 	}
 	arg := strings.ToLower(msg.Content[indices[0]:])
-	id, desc := sb.db.GetPoll(arg, gID)
-	if id == 0 {
+	id, _ := sb.db.GetPoll(arg, gID)
+	poll := sb.db.GetPollByID(id)
+	if poll == nil {
 		return "```That poll doesn't exist! Use \"" + info.config.Basic.CommandPrefix + "poll\" to list active polls.```", false, nil
 	}
-	results := sb.db.GetResults(id)
-	options := sb.db.GetOptions(id)
-	max := uint64(0)
-	for _, v := range results {
-		if v.count > max {
-			max = v.count
-		}
-	}
-
-	str := make([]string, 0, len(results)+2)
-	str = append(str, desc)
-	k := 0
-	var count uint64
-	for _, v := range options {
-		count = 0
-		if k < len(results) && v.index == results[k].index {
-			count = results[k].count
-			k++
-		}
-		normalized := count
-		if max > 10 {
-			normalized = uint64(float32(count) * (10.0 / float32(max)))
-		}
-		if count > 0 && normalized < 1 {
-			normalized = 1
-		}
-
-		graph := ""
-		for i := 0; i < 10; i++ {
-			if uint64(i) < normalized {
-				graph += "\u2588" // this isn't very efficient but the maximum is 10 so it doesn't matter
-			} else {
-				graph += "\u2591"
-			}
-		}
-		buf := ""
-		if v.index < 10 && len(options) > 9 {
-			buf = "_"
-		}
-		str = append(str, fmt.Sprintf("`%s%v. `%s %s (%v votes)", buf, v.index, graph, v.option, count))
-	}
-
+	str := pollResults(poll, false)
 	return strings.Join(str, "\n"), len(str) > 11, nil
 }
 func (c *resultsCommand) Usage(info *GuildInfo) *CommandUsage {
@@ -303,8 +504,10 @@ func (c *addOptionCommand) Process(args []string, msg *discordgo.Message, indice
 		return "```You have to give me an option to add!```", false, nil
 	}
 	gID := SBatoi(info.ID)
-	id, _ := sb.db.GetPoll(args[0], gID)
-	if id == 0 {
+	name := strings.ToLower(args[0])
+	id, _ := sb.db.GetPoll(name, gID)
+	poll := sb.db.GetPollByID(id)
+	if poll == nil {
 		return "```That poll doesn't exist!```", false, nil
 	}
 	arg := msg.Content[indices[1]:]
@@ -312,6 +515,10 @@ func (c *addOptionCommand) Process(args []string, msg *discordgo.Message, indice
 	if err != nil {
 		return "```Error appending option, make sure no other option has this value!```", false, nil
 	}
+	if channel, message := sb.db.GetPollMessage(id); message != 0 {
+		updatePollMessage(info, poll)
+		addPollReactions(SBitoa(channel), SBitoa(message), sb.db.GetOptions(id))
+	}
 	return fmt.Sprintf("```Successfully added %s to %s.```", arg, args[0]), false, nil
 }
 func (c *addOptionCommand) Usage(info *GuildInfo) *CommandUsage {
@@ -324,3 +531,42 @@ func (c *addOptionCommand) Usage(info *GuildInfo) *CommandUsage {
 	}
 }
 func (c *addOptionCommand) UsageShort() string { return "Appends an option to a poll." }
+
+type postPollCommand struct {
+}
+
+func (c *postPollCommand) Name() string {
+	return "PostPoll"
+}
+func (c *postPollCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You have to give me a poll name to post!```", false, nil
+	}
+	name := strings.ToLower(msg.Content[indices[0]:])
+	id, _ := sb.db.GetPoll(name, SBatoi(info.ID))
+	poll := sb.db.GetPollByID(id)
+	if poll == nil {
+		return "```That poll doesn't exist! Use \"" + info.config.Basic.CommandPrefix + "poll\" to list active polls.```", false, nil
+	}
+	post, err := sb.dg.ChannelMessageSendEmbed(msg.ChannelID, pollEmbed(info, poll))
+	if err != nil {
+		return "```Error posting poll: " + err.Error() + "```", false, nil
+	}
+	if err = sb.db.SetPollMessage(id, SBatoi(msg.ChannelID), SBatoi(post.ID)); err != nil {
+		return "```Error saving poll message: " + err.Error() + "```", false, nil
+	}
+	addPollReactions(msg.ChannelID, post.ID, sb.db.GetOptions(id))
+	return "", false, nil
+}
+func (c *postPollCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Posts a poll in this channel with a reaction for each of its first 10 options. Members can vote by reacting, which counts the same as using `" + info.config.Basic.CommandPrefix + "vote`, and the message always shows the current results. Posting a poll again moves it to the new message.",
+		Params: []CommandUsageParam{
+			{Name: "poll", Desc: "Name of the poll to post.", Optional: false},
+		},
+	}
+}
+func (c *postPollCommand) UsageShort() string { return "Posts a poll members can react to." }
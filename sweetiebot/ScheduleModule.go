@@ -2,6 +2,7 @@ package sweetiebot
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -9,6 +10,9 @@ import (
 	"github.com/blackhole12/discordgo"
 )
 
+// Members can RSVP to community events by reacting to their announcement with this emoji
+const rsvpEmoji = "✅"
+
 // ScheduleModule manages the scheduling system
 type ScheduleModule struct {
 }
@@ -26,7 +30,15 @@ func (w *ScheduleModule) Commands() []Command {
 		&addEventCommand{},
 		&removeEventCommand{},
 		&remindMeCommand{},
+		&remindersCommand{},
+		&snoozeCommand{},
+		&editReminderCommand{},
 		&addBirthdayCommand{},
+		&myBirthdayCommand{},
+		&birthdaysCommand{},
+		&failedEventsCommand{},
+		&rsvpCommand{},
+		&calendarCommand{},
 	}
 }
 
@@ -35,15 +47,67 @@ func (w *ScheduleModule) Description() string {
 	return "Manages the scheduling system, and periodically checks for events that need to be processed."
 }
 
+// ScheduleEvents defines the built-in kinds of scheduled events
+func (w *ScheduleModule) ScheduleEvents() []ScheduleEventKind {
+	return builtinScheduleKinds()
+}
+
 // OnTick discord hook
 func (w *ScheduleModule) OnTick(info *GuildInfo) {
 	if !sb.db.CheckStatus() {
 		return
 	}
+	remindAttendees(info)
 	events := sb.db.GetSchedule(SBatoi(info.ID))
+	channel := scheduleChannel(info)
+	if len(channel) == 0 {
+		return
+	}
+
+	threshold := time.Duration(info.config.Schedule.StaleThreshold) * time.Second
+	modchannel := SBitoa(info.config.Basic.ModChannel)
+	skipped := []string{}
+	for _, v := range events {
+		stale := false
+		kind, ok := info.hooks.ScheduleEvents[v.Type]
+		if !ok {
+			info.Log("Scheduled event #", v.ID, " has unknown type ", v.Type)
+		} else if late := time.Now().UTC().Sub(v.Date); v.Status == EVENT_PENDING && threshold > 0 && late > threshold && kind.Skippable() {
+			stale = true
+			mt, data := kind.Describe(info, &v, &discordgo.Message{ChannelID: modchannel})
+			skipped = append(skipped, fmt.Sprintf("#%v [%s] %s (due %s ago)", v.ID, mt, data, TimeDiff(late)))
+		} else if err := kind.Process(info, &v, channel); err != nil && !isPermanentError(err) {
+			retryScheduledEvent(info, &v, err)
+			continue
+		} else if err != nil {
+			info.LogError("Dropped scheduled event #"+SBitoa(v.ID)+" because retrying it can't help: ", err)
+		}
+
+		if len(v.Cron) > 0 {
+			if next := nextCronEvent(&v, info); !next.IsZero() {
+				sb.db.RescheduleEvent(v.ID, next)
+				continue
+			}
+		}
+		if v.Status != EVENT_PENDING {
+			sb.db.ResetEvent(v.ID)
+		}
+		if stale {
+			skipMissedEvents(v.ID)
+		} else {
+			sb.db.RemoveSchedule(v.ID)
+		}
+	}
+	if len(skipped) > 0 {
+		info.SendMessage(modchannel, "Skipped these scheduled events because they are more than "+TimeDiff(threshold)+" late:\n"+ReplaceAllMentions(strings.Join(skipped, "\n")))
+	}
+}
+
+// scheduleChannel returns the channel that scheduled events are announced in: the first channel the scheduler module is restricted to, falling back to the bored module's channel, then a free channel, then the mod channel
+func scheduleChannel(info *GuildInfo) string {
 	channel := SBitoa(info.config.Basic.ModChannel)
-	if len(info.config.Modules.Channels[strings.ToLower(w.Name())]) > 0 {
-		for k := range info.config.Modules.Channels[strings.ToLower(w.Name())] {
+	if len(info.config.Modules.Channels["scheduler"]) > 0 {
+		for k := range info.config.Modules.Channels["scheduler"] {
 			channel = k
 			break
 		}
@@ -58,60 +122,79 @@ func (w *ScheduleModule) OnTick(info *GuildInfo) {
 			break
 		}
 	}
+	return channel
+}
 
-	if len(channel) == 0 {
+// OnGuildMemberRemove discord hook
+func (w *ScheduleModule) OnGuildMemberRemove(info *GuildInfo, m *discordgo.Member) {
+	if sb.db.CheckStatus() {
+		sb.db.RemoveBirthday(SBatoi(info.ID), m.User.ID)
+	}
+}
+
+// OnMessageReactionAdd discord hook
+func (w *ScheduleModule) OnMessageReactionAdd(info *GuildInfo, m *discordgo.MessageReactionAdd) {
+	if m.Emoji.Name != rsvpEmoji || !sb.db.CheckStatus() {
 		return
 	}
+	if e := sb.db.FindEventByPost(SBatoi(info.ID), m.MessageID); e != nil {
+		sb.db.AddRSVP(e.ID, SBatoi(m.UserID))
+	}
+}
 
-	for _, v := range events {
-		switch v.Type {
-		case 0:
-			err := sb.dg.GuildBanDelete(info.ID, v.Data)
-			if err != nil {
-				info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Error unbanning <@"+v.Data+">: "+err.Error())
-			} else {
-				info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Unbanned <@"+v.Data+">")
-			}
-		case 1:
-			if info.config.Schedule.BirthdayRole == 0 {
-				info.Log("No birthday role set!")
-			} else {
-				err := sb.dg.GuildMemberRoleAdd(info.ID, v.Data, SBitoa(info.config.Schedule.BirthdayRole))
-				info.LogError("Failed to set birthday role: ", err)
-			}
-			info.SendMessage(channel, "Happy Birthday <@"+v.Data+">!")
-		case 2:
-			info.SendMessage(channel, v.Data)
-		case 5, 3:
-			info.SendMessage(channel, v.Data+" is starting now!")
-		case 4:
-			if info.config.Schedule.BirthdayRole == 0 {
-				info.Log("No birthday role set!")
-			} else {
-				err := sb.dg.GuildMemberRoleRemove(info.ID, v.Data, SBitoa(info.config.Schedule.BirthdayRole))
-				info.LogError("Failed to remove birthday role: ", err)
-			}
-		case 6:
-			dat := strings.SplitN(v.Data, "|", 2)
-			ch, err := sb.dg.UserChannelCreate(dat[0])
-			info.LogError("Error opening private channel: ", err)
-			if err == nil {
-				info.SendMessage(ch.ID, dat[1])
-			}
-		case 7:
-			dat := strings.SplitN(v.Data, "|", 2)
-			info.SendMessage(channel, dat[0]+" "+dat[1])
-		case 8:
-			err := UnsilenceMember(SBatoi(v.Data), info)
-			if err != nil {
-				info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Error unsilencing <@"+v.Data+">: "+err.Error())
-			} else {
-				info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Unsilenced <@"+v.Data+">")
-			}
+// OnMessageReactionRemove discord hook
+func (w *ScheduleModule) OnMessageReactionRemove(info *GuildInfo, m *discordgo.MessageReactionRemove) {
+	if m.Emoji.Name != rsvpEmoji || !sb.db.CheckStatus() {
+		return
+	}
+	if e := sb.db.FindEventByPost(SBatoi(info.ID), m.MessageID); e != nil {
+		sb.db.RemoveRSVP(e.ID, SBatoi(m.UserID))
+	}
+}
+
+// retryScheduledEvent schedules another attempt at processing an event, waiting twice as long after every failure, or gives up and notifies the moderators once it failed too often
+func retryScheduledEvent(info *GuildInfo, e *ScheduleEvent, err error) {
+	attempts := e.Attempts + 1
+	info.LogError("Error processing scheduled event #"+SBitoa(e.ID)+": ", err)
+	if attempts >= scheduleMaxAttempts {
+		sb.db.FailEvent(e.ID, attempts, err.Error())
+		info.SendMessage(SBitoa(info.config.Basic.ModChannel), fmt.Sprintf("Gave up on scheduled event #%v after %v failed attempts, use `%sfailedevents` to retry it. The last error was: %s", e.ID, attempts, info.config.Basic.CommandPrefix, err.Error()))
+		return
+	}
+	sb.db.RetryEvent(e.ID, attempts, time.Now().UTC().Add(time.Minute<<uint(attempts-1)), err.Error())
+}
+
+// skipMissedEvents removes an event that is no longer relevant. Repeating events are advanced past every occurrence that was missed, so only the next one in the future remains.
+func skipMissedEvents(id uint64) {
+	for i := 0; i < 1000; i++ {
+		sb.db.RemoveSchedule(id) // Removes the event if it isn't repeating, or if its next occurrence is already in the future
+		e := sb.db.GetEvent(id)
+		if e == nil || e.Date.After(time.Now().UTC()) {
+			break
 		}
+	}
+}
 
-		sb.db.RemoveSchedule(v.ID)
+// nextCronEvent returns when a recurring event should happen next in the server's timezone, skipping any occurrences that were missed while the bot was offline
+func nextCronEvent(e *ScheduleEvent, info *GuildInfo) time.Time {
+	c, err := parseCron(e.Cron)
+	if err != nil {
+		info.LogError("Invalid cron expression on event #"+SBitoa(e.ID)+": ", err)
+		return time.Time{}
+	}
+	tz := getTimezone(info, nil)
+	next := c.Next(e.Date, tz)
+	if now := time.Now().UTC(); !next.IsZero() && !next.After(now) {
+		next = c.Next(now, tz)
+	}
+	return next
+}
+
+func formatEventTime(t time.Time, info *GuildInfo, user *discordgo.User) string {
+	if t.Year() == time.Now().UTC().Year() {
+		return ApplyTimezone(t, info, user).Format("Jan 2 3:04pm")
 	}
+	return ApplyTimezone(t, info, user).Format("Jan 2 2006 3:04pm")
 }
 
 type scheduleCommand struct {
@@ -128,7 +211,7 @@ func (c *scheduleCommand) Process(args []string, msg *discordgo.Message, indices
 	var ty uint8
 	ty = 255
 	if len(args) > 1 {
-		ty = getScheduleType(args[0])
+		ty = getScheduleType(args[0], info)
 		if ty == 255 {
 			return "```Unknown schedule type.```", false, nil
 		}
@@ -138,7 +221,7 @@ func (c *scheduleCommand) Process(args []string, msg *discordgo.Message, indices
 		maxresults, err = strconv.Atoi(args[0])
 		if err != nil {
 			maxresults = 5
-			ty = getScheduleType(args[0])
+			ty = getScheduleType(args[0], info)
 			if ty == 255 {
 				return "```Unknown schedule type.```", false, nil
 			}
@@ -150,13 +233,13 @@ func (c *scheduleCommand) Process(args []string, msg *discordgo.Message, indices
 	if maxresults < 1 {
 		maxresults = 1
 	}
-	if !info.UserHasRole(msg.Author.ID, SBitoa(info.config.Basic.AlertRole)) && (ty == 0 || ty == 4 || ty == 8) {
+	if kind, ok := info.hooks.ScheduleEvents[ty]; ok && kind.Private() && !info.UserHasRole(msg.Author.ID, SBitoa(info.config.Basic.AlertRole)) {
 		return "```You aren't allowed to view those events.```", false, nil
 	}
 	var events []ScheduleEvent
 	if ty == 255 {
 		events = sb.db.GetEvents(SBatoi(info.ID), maxresults)
-	} else if ty == 6 {
+	} else if ty == SCHEDULE_REMINDER {
 		events = sb.db.GetReminders(SBatoi(info.ID), msg.Author.ID, maxresults)
 	} else {
 		events = sb.db.GetEventsByType(SBatoi(info.ID), ty, maxresults)
@@ -167,36 +250,19 @@ func (c *scheduleCommand) Process(args []string, msg *discordgo.Message, indices
 	lines := make([]string, len(events)+1, len(events)+1)
 	lines[0] = "Upcoming Events:"
 	for k, v := range events {
-		t := ""
-		if v.Date.Year() == time.Now().UTC().Year() {
-			t = ApplyTimezone(v.Date, info, msg.Author).Format("Jan 2 3:04pm")
-		} else {
-			t = ApplyTimezone(v.Date, info, msg.Author).Format("Jan 2 2006 3:04pm")
-		}
-		data := v.Data
-		mt := "UNKNOWN"
-		switch v.Type {
-		case 1:
-			mt = "BIRTHDAY"
-			data = "<@" + data + ">"
-		case 2:
-			mt = "MESSAGE"
-		case 3:
-			mt = "EPISODE"
-			if len(info.config.Spoiler.Channels) > 0 && !FindIntSlice(SBatoi(msg.ChannelID), info.config.Spoiler.Channels) {
-				data = "(title removed)"
-			}
-		case 5:
-			mt = "EVENT"
-		case 6:
-			mt = "REMINDER"
-			data = strings.SplitN(data, "|", 2)[1]
-		case 7:
-			datas := strings.SplitN(data, "|", 2)
-			mt = "ROLE:" + ReplaceAllRolePings(datas[0], info)
-			data = datas[1]
+		t := formatEventTime(v.Date, info, msg.Author)
+		mt, data := "UNKNOWN", v.Data
+		if kind, ok := info.hooks.ScheduleEvents[v.Type]; ok {
+			mt, data = kind.Describe(info, &v, msg)
 		}
 		lines[k+1] = fmt.Sprintf("#%v **%s** [%s] %s", SBitoa(v.ID), t, mt, ReplaceAllMentions(data))
+		if c, err := parseCron(v.Cron); err == nil {
+			upcoming := []string{}
+			for _, next := range c.Upcoming(v.Date, getTimezone(info, nil), 3) {
+				upcoming = append(upcoming, formatEventTime(next, info, msg.Author))
+			}
+			lines[k+1] += fmt.Sprintf("\n    Repeats `%s`, then on %s", c.Expr, strings.Join(upcoming, ", "))
+		}
 	}
 
 	return strings.Join(lines, "\n"), len(lines) > 6, nil
@@ -212,24 +278,9 @@ func (c *scheduleCommand) Usage(info *GuildInfo) *CommandUsage {
 }
 func (c *scheduleCommand) UsageShort() string { return "Gets a list of upcoming scheduled events." }
 
-func getScheduleType(s string) uint8 {
-	switch strings.ToLower(s) {
-	case "bans", "ban":
-		return 0
-	case "birthdays", "birthday":
-		return 1
-	case "messages", "message":
-		return 2
-	case "episodes", "episode":
-		return 3
-	case "events", "event":
-		return 5
-	case "reminders", "reminder":
-		return 6
-	case "roles", "role":
-		return 7
-	case "silences", "silence":
-		return 8
+func getScheduleType(s string, info *GuildInfo) uint8 {
+	if kind := info.getScheduleKind(s); kind != nil {
+		return kind.Type()
 	}
 	return 255
 }
@@ -247,7 +298,7 @@ func (c *nextCommand) Process(args []string, msg *discordgo.Message, indices []i
 	if len(args) < 1 {
 		return "```You must specify an event type.```", false, nil
 	}
-	ty := getScheduleType(args[0])
+	ty := getScheduleType(args[0], info)
 	if ty == 255 {
 		return "```Error: Invalid type specified.```", false, nil
 	}
@@ -258,19 +309,27 @@ func (c *nextCommand) Process(args []string, msg *discordgo.Message, indices []i
 	}
 	diff := TimeDiff(event.Date.Sub(time.Now().UTC()))
 	switch event.Type {
-	case 1:
-		return ReplaceAllMentions("```It'll be <@" + event.Data + ">'s birthday in " + diff + "```"), false, nil
-	case 2:
+	case SCHEDULE_BIRTHDAY:
+		p := ScheduleUserPayload{}
+		event.Payload(&p)
+		return ReplaceAllMentions("```It'll be <@" + p.User + ">'s birthday in " + diff + "```"), false, nil
+	case SCHEDULE_MESSAGE:
 		return "```Sweetie is scheduled to send a message in " + diff + "```", false, nil
-	case 3:
+	case SCHEDULE_EPISODE:
 		if len(info.config.Spoiler.Channels) > 0 && !FindIntSlice(SBatoi(msg.ChannelID), info.config.Spoiler.Channels) {
 			return "```The next episode airs in " + diff + "```", false, nil
 		}
-		return "```" + event.Data + " airs in " + diff + "```", false, nil
-	case 5:
-		return "```" + event.Data + " starts in " + diff + "```", false, nil
-	case 7:
-		return "```Sweetie is scheduled to send a message to " + ReplaceAllRolePings(strings.SplitN(event.Data, "|", 2)[0], info) + " in " + diff + "```", false, nil
+		p := ScheduleMessagePayload{}
+		event.Payload(&p)
+		return "```" + p.Message + " airs in " + diff + "```", false, nil
+	case SCHEDULE_EVENT:
+		p := ScheduleEventPayload{}
+		event.Payload(&p)
+		return "```" + p.Message + " starts in " + diff + "```", false, nil
+	case SCHEDULE_ROLE:
+		p := ScheduleRolePayload{}
+		event.Payload(&p)
+		return "```Sweetie is scheduled to send a message to " + ReplaceAllRolePings(p.Role, info) + " in " + diff + "```", false, nil
 	default:
 		return "```There are no upcoming events of that type (or you aren't allowed to view them).```", false, nil
 	}
@@ -320,83 +379,134 @@ func (c *addEventCommand) Process(args []string, msg *discordgo.Message, indices
 	if len(args) < 2 {
 		return "```At least a type and a date must be specified!```", false, nil
 	}
-	ty := getScheduleType(args[0])
+	ty := getScheduleType(args[0], info)
 	if ty == 255 {
 		return "```Error: Invalid type specified.```", false, nil
 	}
-	data := ""
-	if ty == 7 {
-		data = strings.ToLower(args[1])
-		data += "|"
+	target := ""
+	if ty == SCHEDULE_ROLE {
+		target = strings.ToLower(args[1])
 		args = append(args[:1], args[2:]...)
 		indices = append(indices[:1], indices[2:]...)
 	}
-	if ty == 6 {
-		data = StripPing(args[1])
-		_, err := sb.dg.GuildMember(info.ID, data)
+	if ty == SCHEDULE_REMINDER {
+		target = StripPing(args[1])
+		_, err := sb.dg.GuildMember(info.ID, target)
 		if err != nil {
 			return "Error: user ID doesn't exist.", false, nil
 		}
-		data += "|"
 		args = append(args[:1], args[2:]...)
 		indices = append(indices[:1], indices[2:]...)
 	}
+	if len(args) < 2 {
+		return "```At least a type and a date must be specified!```", false, nil
+	}
+	var cron *cronSchedule
 	t, err := parseCommonTime(args[1], info, msg.Author)
 	if err != nil {
-		return "```Error: Could not parse time! Make sure it's in the format \"2 January 2006 3:04pm -0700\" (or something similar, year, time and timezone are optional)```", false, nil
+		cron, err = parseRecurrence(args[1])
+		if err != nil {
+			return "```Error: Could not parse time! Make sure it's in the format \"2 January 2006 3:04pm -0700\" (or something similar, year, time and timezone are optional), or that it's a recurrence like \"every Friday 8pm\" or a cron expression (" + err.Error() + ")```", false, nil
+		}
+		t = cron.Next(time.Now().UTC(), getTimezone(info, nil))
 	}
 	t = t.UTC()
 	if t.Before(time.Now().UTC()) {
 		return "```Error: Cannot specify an event in the past!```", false, nil
 	}
 
-	if len(args) > 2 && repeatregex.MatchString(strings.ToLower(args[2])) {
+	repeat := 0
+	repeatinterval := uint8(255)
+	textindex := 2
+	if cron == nil && len(args) > 2 && repeatregex.MatchString(strings.ToLower(args[2])) {
 		repeats := strings.Split(args[2], " ")
-		repeat, err := strconv.Atoi(repeats[1])
+		repeat, err = strconv.Atoi(repeats[1])
 		if err != nil {
 			return "```Error: Repeat number was not an integer.```", false, nil
 		}
 
-		repeatinterval := parseRepeatInterval(repeats[2])
+		repeatinterval = parseRepeatInterval(repeats[2])
 		if repeatinterval == 255 {
 			return "```Error: unrecognized interval.```", false, nil
 		}
-
-		if len(args) > 3 {
-			data += msg.Content[indices[3]:]
-		}
-		if !sb.db.AddScheduleRepeat(SBatoi(info.ID), t, repeatinterval, repeat, ty, data) {
-			return "```Error: servers can't have more than 5000 events!```", false, nil
-		}
-	} else {
-		if len(args) > 2 {
-			data += msg.Content[indices[2]:]
+		textindex = 3
+	}
+	text := ""
+	if len(args) > textindex {
+		text = msg.Content[indices[textindex]:]
+	}
+	data := newSchedulePayload(ty, target, text)
+	if len(data) == 0 {
+		return "```Error: Events of that type can't be added manually.```", false, nil
+	}
+	post := ""
+	postchannel := scheduleChannel(info)
+	if ty == SCHEDULE_EVENT && len(postchannel) > 0 {
+		announcement := "**" + text + "** starts on " + ApplyTimezone(t, info, nil).Format("Jan 2 3:04pm MST") + ". React with " + rsvpEmoji + " or use `" + info.config.Basic.CommandPrefix + "rsvp " + text + "` to attend."
+		m, err := sb.dg.ChannelMessageSend(postchannel, info.sanitizeOutput(announcement))
+		if err != nil {
+			info.LogError("Failed to announce event: ", err)
+		} else {
+			post = m.ID
+			sb.dg.MessageReactionAdd(postchannel, post, rsvpEmoji)
+			data = EncodeSchedulePayload(ScheduleEventPayload{Message: text, Post: post, Channel: postchannel})
 		}
+	}
 
-		if !sb.db.AddSchedule(SBatoi(info.ID), t, ty, data) {
-			return "```Error: servers can't have more than 5000 events!```", false, nil
+	var added bool
+	switch {
+	case cron != nil:
+		added = sb.db.AddScheduleCron(SBatoi(info.ID), t, cron.Expr, ty, data)
+	case repeatinterval != 255:
+		added = sb.db.AddScheduleRepeat(SBatoi(info.ID), t, repeatinterval, repeat, ty, data)
+	default:
+		added = sb.db.AddSchedule(SBatoi(info.ID), t, ty, data)
+	}
+	if !added {
+		if len(post) > 0 {
+			sb.dg.ChannelMessageDelete(postchannel, post)
 		}
+		return "```Error: servers can't have more than 5000 events!```", false, nil
+	}
+	if cron != nil {
+		return "```Added recurring event to schedule. It will first happen on " + formatEventTime(t, info, msg.Author) + ".```", false, nil
 	}
-
 	return "```Added event to schedule.```", false, nil
 }
+
+// newSchedulePayload builds the payload of a built-in event from the target role or user and the text given to addevent. Returns an empty string for event types that can't be created this way.
+func newSchedulePayload(ty uint8, target string, text string) string {
+	switch ty {
+	case SCHEDULE_UNBAN, SCHEDULE_BIRTHDAY, SCHEDULE_BIRTHDAY_END, SCHEDULE_UNSILENCE:
+		return EncodeSchedulePayload(ScheduleUserPayload{StripPing(text)})
+	case SCHEDULE_MESSAGE, SCHEDULE_EPISODE:
+		return EncodeSchedulePayload(ScheduleMessagePayload{text})
+	case SCHEDULE_EVENT:
+		return EncodeSchedulePayload(ScheduleEventPayload{Message: text})
+	case SCHEDULE_REMINDER:
+		return EncodeSchedulePayload(ScheduleReminderPayload{User: target, Message: text})
+	case SCHEDULE_ROLE:
+		return EncodeSchedulePayload(ScheduleRolePayload{target, text})
+	}
+	return ""
+}
 func (c *addEventCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Adds an arbitrary event to the schedule table. For example: `" + info.config.Basic.CommandPrefix + "addevent message \"12 Jun 16\" \"REPEAT 1 YEAR\" happy birthday!`, or `" + info.config.Basic.CommandPrefix + "addevent episode \"9 Dec 15\" Slice of Life`. ",
+		Desc: "Adds an arbitrary event to the schedule table. For example: `" + info.config.Basic.CommandPrefix + "addevent message \"12 Jun 16\" \"REPEAT 1 YEAR\" happy birthday!`, `" + info.config.Basic.CommandPrefix + "addevent event \"every Friday 8pm\" Movie night` or `" + info.config.Basic.CommandPrefix + "addevent episode \"9 Dec 15\" Slice of Life`. ",
 		Params: []CommandUsageParam{
 			{Name: "type", Desc: "Can be one of: ban, birthday, message, episode, event, reminder, role. You shouldn't add birthday or reminder events manually, though.", Optional: false},
 			{Name: "role/user", Desc: "The target role or user to ping. Only include this if the type is role or reminder. If the type is \"role\", it must be an actual ping for the role, not just the name.", Optional: true},
-			{Name: "date", Desc: "A date in the format 12 Jun 16 2:10pm, in quotes. The time, year, and timezone are all optional.", Optional: false},
-			{Name: "REPEAT N INTERVAL", Desc: "INTERVAL can be one of SECONDS/MINUTES/HOURS/DAYS/WEEKS/MONTHS/YEARS. This parameter MUST be surrounded by quotes!", Optional: true},
+			{Name: "date", Desc: "A date in the format 12 Jun 16 2:10pm, in quotes. The time, year, and timezone are all optional. Relative times like \"tomorrow at 5pm\", \"next friday 8pm\" or \"in 3 days\" work too. Can also be a recurrence like `every Friday 20:00`, `every weekday 9am`, `every month on the 15th` or `first Monday of the month 6pm`, or a cron expression like `0 20 * * 5`, which are evaluated in the server's timezone. Recurrences without a time happen at midnight.", Optional: false},
+			{Name: "REPEAT N INTERVAL", Desc: "INTERVAL can be one of SECONDS/MINUTES/HOURS/DAYS/WEEKS/MONTHS/YEARS. This parameter MUST be surrounded by quotes! Can't be used with a recurrence.", Optional: true},
 		},
 	}
 }
 func (c *addEventCommand) UsageShort() string { return "Adds an event to the schedule." }
 
 func userOwnsEvent(e *ScheduleEvent, u *discordgo.User) bool {
-	if e.Type == 6 {
-		dat := strings.SplitN(e.Data, "|", 2)
-		if dat[0] == u.ID {
+	if e.Type == SCHEDULE_REMINDER {
+		p := ScheduleReminderPayload{}
+		if e.Payload(&p) == nil && p.User == u.ID {
 			return true
 		}
 	}
@@ -443,6 +553,106 @@ func (c *removeEventCommand) Usage(info *GuildInfo) *CommandUsage {
 }
 func (c *removeEventCommand) UsageShort() string { return "Removes an event." }
 
+type failedEventsCommand struct {
+}
+
+func (c *failedEventsCommand) Name() string {
+	return "FailedEvents"
+}
+func (c *failedEventsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	gID := SBatoi(info.ID)
+	if len(args) > 0 && strings.ToLower(args[0]) == "retry" {
+		if len(args) < 2 {
+			return "```You must specify an event ID, or \"all\" to retry every failed event.```", false, nil
+		}
+		var id uint64
+		if strings.ToLower(args[1]) != "all" {
+			var err error
+			id, err = strconv.ParseUint(args[1], 10, 64)
+			if err != nil || id == 0 {
+				return "```Could not parse event ID. Make sure you only specify the number itself.```", false, nil
+			}
+		}
+		n := sb.db.RetryFailedEvents(gID, id)
+		if n == 0 {
+			return "```There are no failed events with that ID.```", false, nil
+		}
+		return "```Retrying " + Pluralize(n, " failed event") + ".```", false, nil
+	}
+
+	events := sb.db.GetFailedEvents(gID, 20)
+	if len(events) == 0 {
+		return "```There are no failed events.```", false, nil
+	}
+	lines := []string{"Failed Events:"}
+	for _, v := range events {
+		mt, data := "UNKNOWN", v.Data
+		if kind, ok := info.hooks.ScheduleEvents[v.Type]; ok {
+			mt, data = kind.Describe(info, &v, msg)
+		}
+		lines = append(lines, fmt.Sprintf("#%v **%s** [%s] %s\n    Failed %s: %s", v.ID, formatEventTime(v.Date, info, msg.Author), mt, ReplaceAllMentions(data), Pluralize(int64(v.Attempts), " time"), v.LastError))
+	}
+	return strings.Join(lines, "\n"), len(lines) > 6, nil
+}
+func (c *failedEventsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Lists scheduled events that kept failing, for example because the bot lacked permissions or a channel was deleted, along with the last error. Failed events are not processed again until they are retried. Repeating events stop repeating while they are failed.",
+		Params: []CommandUsageParam{
+			{Name: "retry ID|all", Desc: "Tries the event with the given ID again right away, or every failed event if `all` is given.", Optional: true},
+		},
+	}
+}
+func (c *failedEventsCommand) UsageShort() string { return "Lists failed scheduled events." }
+
+type rsvpCommand struct {
+}
+
+func (c *rsvpCommand) Name() string {
+	return "RSVP"
+}
+func (c *rsvpCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must specify the name or ID of an event.```", false, nil
+	}
+	arg := msg.Content[indices[0]:]
+	id, _ := strconv.ParseUint(arg, 10, 64)
+	var event *ScheduleEvent
+	var p ScheduleEventPayload
+	for _, v := range sb.db.GetEventsByType(SBatoi(info.ID), SCHEDULE_EVENT, 50) {
+		payload := ScheduleEventPayload{}
+		v.Payload(&payload)
+		if v.ID == id || strings.EqualFold(payload.Message, arg) {
+			event = &v
+			p = payload
+			break
+		}
+	}
+	if event == nil {
+		return "```There is no upcoming event called " + arg + ". Use " + info.config.Basic.CommandPrefix + "schedule events to see a list of events.```", false, nil
+	}
+	user := SBatoi(msg.Author.ID)
+	if sb.db.AddRSVP(event.ID, user) {
+		return "```You are now attending " + p.Message + " on " + formatEventTime(event.Date, info, msg.Author) + " (" + strconv.Itoa(sb.db.GetRSVPCount(event.ID)) + " attending). Use this command again to cancel.```", false, nil
+	}
+	sb.db.RemoveRSVP(event.ID, user)
+	return "```You are no longer attending " + p.Message + ".```", false, nil
+}
+func (c *rsvpCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Marks you as attending an upcoming event, or removes you from its attendees if you were already attending. You can also react to the announcement of an event with " + rsvpEmoji + ". Attendees get a private reminder before the event starts, and are given the attendee role while it is running, if the server has one.",
+		Params: []CommandUsageParam{
+			{Name: "event", Desc: "The name of the event, or its ID as shown by `" + info.config.Basic.CommandPrefix + "schedule events`.", Optional: false},
+		},
+	}
+}
+func (c *rsvpCommand) UsageShort() string { return "RSVPs to an upcoming event." }
+
 type remindMeCommand struct {
 }
 
@@ -453,6 +663,12 @@ func (c *remindMeCommand) Process(args []string, msg *discordgo.Message, indices
 	if !sb.db.CheckStatus() {
 		return "```A temporary database outage is preventing this command from being executed.```", false, nil
 	}
+	channel := ""
+	if len(args) > 0 && strings.ToLower(args[0]) == "here" {
+		channel = msg.ChannelID
+		args = args[1:]
+		indices = indices[1:]
+	}
 	if len(args) < 3 {
 		return "```You must start your message with 'in' or 'on', followed by a date (in quotes!) or duration, followed by a message.```", false, nil
 	}
@@ -464,38 +680,28 @@ func (c *remindMeCommand) Process(args []string, msg *discordgo.Message, indices
 		t = time.Now().UTC()
 		d, err := strconv.Atoi(args[1])
 		if err != nil {
-			return "```Duration is not numeric! Make sure it's in the format 'in 99 days', and DON'T put quotes around it.```", false, nil
+			if t, err = addDuration(t, args[1], 1); err != nil {
+				return "```Could not parse duration! Make sure it's in the format 'in 99 days' without quotes, or something like 'in 3d4h' or 'in \"2 hours 30 minutes\"'.```", false, nil
+			}
+			arg = msg.Content[indices[2]:]
+			break
 		}
 		if d <= 0 {
 			return "```That was " + TimeDiff(time.Now().UTC().Sub(t)) + " ago, you idiot! Do you think I have a time machine or something?```", false, nil
 		}
-		switch parseRepeatInterval(args[2]) {
-		case 1:
-			t = t.Add(time.Duration(d) * time.Second)
-		case 2:
-			t = t.Add(time.Duration(d) * time.Minute)
-		case 3:
-			t = t.Add(time.Duration(d) * time.Hour)
-		case 4:
-			t = t.AddDate(0, 0, d)
-		case 5:
-			t = t.AddDate(0, 0, d*7)
-		case 6:
-			t = t.AddDate(0, d, 0)
-		case 8:
-			t = t.AddDate(d, 0, 0)
-		default:
+		var ok bool
+		if t, ok = addInterval(t, d, parseDurationUnit(strings.ToLower(args[2]))); !ok {
 			return "```Unknown duration type! Acceptable types are seconds, minutes, hours, days, weeks, months, and years.```", false, nil
 		}
 		if len(indices) < 4 {
 			return "```You have to tell me what to say!```", false, nil
 		}
 		arg = msg.Content[indices[3]:]
-	case "on":
+	case "on", "at":
 		var err error
-		t, err = parseCommonTime(strings.ToLower(args[1]), info, msg.Author)
+		t, err = parseCommonTime(args[1], info, msg.Author)
 		if err != nil {
-			return "```Could not parse time! Make sure its in the format \"2 January 2006 3:04pm -0700\" (or something similar, time, year, and timezone are optional). Make sure you surround it with quotes!```", false, nil
+			return "```Could not parse time! Make sure its in the format \"2 January 2006 3:04pm -0700\" (or something similar, time, year, and timezone are optional), or something like \"tomorrow at 5pm\" or \"next tuesday\". Make sure you surround it with quotes!```", false, nil
 		}
 		t = t.UTC()
 		if t.Before(time.Now().UTC()) {
@@ -507,17 +713,25 @@ func (c *remindMeCommand) Process(args []string, msg *discordgo.Message, indices
 	if len(arg) == 0 {
 		return "```What am I reminding you about? I can't send you a blank message!```", false, nil
 	}
-	if !sb.db.AddSchedule(SBatoi(info.ID), t, 6, msg.Author.ID+"|"+arg) {
+	p := ScheduleReminderPayload{User: msg.Author.ID, Message: arg}
+	if len(channel) > 0 {
+		if _, private := channelIsPrivate(channel); !private {
+			p.Channel = channel
+			p.Link = fmt.Sprintf("https://discordapp.com/channels/%s/%s/%s", info.ID, channel, msg.ID)
+		}
+	}
+	if !sb.db.AddSchedule(SBatoi(info.ID), t, SCHEDULE_REMINDER, EncodeSchedulePayload(p)) {
 		return "```Error: servers can't have more than 5000 events!```", false, nil
 	}
 	return "Reminder set for " + TimeDiff(t.Sub(time.Now().UTC())) + " from now.", false, nil
 }
 func (c *remindMeCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Tells sweetiebot to remind you about something in the future. ",
+		Desc: "Tells sweetiebot to remind you about something in the future. Use `" + info.config.Basic.CommandPrefix + "reminders` to see your reminders.",
 		Params: []CommandUsageParam{
-			{Name: "in N seconds/minutes/hours/etc.", Desc: "represents a time `N` units from the current time. The available units are: seconds, minutes, hours, days, weeks, months, years.", Optional: true},
-			{Name: "on \"2 January 2006 3:04pm -0700\"", Desc: "represents an absolute date and time, which must be in quotes. You must choose the `in` syntax OR the `on` syntax to specify your time, not both.", Optional: true},
+			{Name: "here", Desc: "If the first argument is `here`, the reminder is posted in this channel along with a link back to your message, instead of being sent to you privately.", Optional: true},
+			{Name: "in N seconds/minutes/hours/etc.", Desc: "represents a time `N` units from the current time. The available units are: seconds, minutes, hours, days, weeks, months, years. Short forms like `in 3d4h` also work.", Optional: true},
+			{Name: "on \"2 January 2006 3:04pm -0700\"", Desc: "represents an absolute date and time, which must be in quotes. Relative times like `on \"tomorrow at 5pm\"`, `on \"next tuesday\"` or `at \"18:00 Europe/Berlin\"` also work. You must choose the `in` syntax OR the `on` syntax to specify your time, not both.", Optional: true},
 			{Name: "message", Desc: "An arbitrary string that will be sent to you at the appropriate time.", Optional: false},
 		},
 	}
@@ -526,6 +740,188 @@ func (c *remindMeCommand) UsageShort() string {
 	return "Tells sweetiebot to remind you about something."
 }
 
+// getOwnReminder looks up a reminder by its ID and makes sure it belongs to the given user
+func getOwnReminder(arg string, u *discordgo.User) (*ScheduleEvent, string) {
+	id, err := strconv.ParseUint(arg, 10, 64)
+	if err != nil {
+		return nil, "```Could not parse reminder ID. Make sure you only specify the number itself.```"
+	}
+	e := sb.db.GetEvent(id)
+	if e == nil || e.Type != SCHEDULE_REMINDER || !userOwnsEvent(e, u) {
+		return nil, "```Error: You don't have a reminder with that ID.```"
+	}
+	return e, ""
+}
+
+type remindersCommand struct {
+}
+
+func (c *remindersCommand) Name() string {
+	return "Reminders"
+}
+func (c *remindersCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	events := sb.db.GetReminders(SBatoi(info.ID), msg.Author.ID, 20)
+	if len(events) == 0 {
+		return "```You don't have any reminders.```", false, nil
+	}
+	lines := []string{"Your Reminders:"}
+	for _, v := range events {
+		p := ScheduleReminderPayload{}
+		v.Payload(&p)
+		line := fmt.Sprintf("#%v **%s** %s", v.ID, formatEventTime(v.Date, info, msg.Author), ReplaceAllMentions(p.Message))
+		if len(p.Channel) > 0 {
+			line += " (in <#" + p.Channel + ">)"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n"), len(lines) > 6, nil
+}
+func (c *remindersCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Lists your upcoming reminders on this server along with their IDs, which can be used with `" + info.config.Basic.CommandPrefix + "snooze`, `" + info.config.Basic.CommandPrefix + "editreminder` and `" + info.config.Basic.CommandPrefix + "removeevent`.",
+	}
+}
+func (c *remindersCommand) UsageShort() string { return "Lists your reminders." }
+
+type snoozeCommand struct {
+}
+
+func (c *snoozeCommand) Name() string {
+	return "Snooze"
+}
+func (c *snoozeCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 2 {
+		return "```You must specify a reminder ID and how long to snooze it for, like '2 hours' or '1d'.```", false, nil
+	}
+	e, errmsg := getOwnReminder(args[0], msg.Author)
+	if e == nil {
+		return errmsg, false, nil
+	}
+	t := e.Date
+	if now := time.Now().UTC(); t.Before(now) {
+		t = now
+	}
+	t, err := addDuration(t, msg.Content[indices[1]:], 1)
+	if err != nil {
+		return "```Error: " + err.Error() + ". Specify a duration like '2 hours', '1d' or '1 week and 2 days'.```", false, nil
+	}
+	sb.db.RescheduleEvent(e.ID, t)
+	return "```Reminder #" + SBitoa(e.ID) + " snoozed until " + formatEventTime(t, info, msg.Author) + ", " + TimeDiff(t.Sub(time.Now().UTC())) + " from now.```", false, nil
+}
+func (c *snoozeCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Postpones one of your reminders by the given amount of time.",
+		Params: []CommandUsageParam{
+			{Name: "ID", Desc: "The reminder ID as gotten from `" + info.config.Basic.CommandPrefix + "reminders`.", Optional: false},
+			{Name: "duration", Desc: "How long to postpone the reminder, like `2 hours`, `1d` or `1 week and 2 days`.", Optional: false},
+		},
+	}
+}
+func (c *snoozeCommand) UsageShort() string { return "Postpones one of your reminders." }
+
+type editReminderCommand struct {
+}
+
+func (c *editReminderCommand) Name() string {
+	return "EditReminder"
+}
+func (c *editReminderCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 2 {
+		return "```You must specify a reminder ID and the new message.```", false, nil
+	}
+	e, errmsg := getOwnReminder(args[0], msg.Author)
+	if e == nil {
+		return errmsg, false, nil
+	}
+	p := ScheduleReminderPayload{}
+	if err := e.Payload(&p); err != nil {
+		return "```Error: " + err.Error() + "```", false, nil
+	}
+	p.Message = msg.Content[indices[1]:]
+	sb.db.SetEventData(e.ID, EncodeSchedulePayload(p))
+	return "```Changed the message of reminder #" + SBitoa(e.ID) + ".```", false, nil
+}
+func (c *editReminderCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Changes what one of your reminders will say. To change when it happens, use `" + info.config.Basic.CommandPrefix + "snooze`.",
+		Params: []CommandUsageParam{
+			{Name: "ID", Desc: "The reminder ID as gotten from `" + info.config.Basic.CommandPrefix + "reminders`.", Optional: false},
+			{Name: "message", Desc: "The new message of the reminder.", Optional: false},
+		},
+	}
+}
+func (c *editReminderCommand) UsageShort() string { return "Changes the message of a reminder." }
+
+// parseBirthday accepts a date like "2 Jan" or "January 2", optionally followed by the year, and returns the start of the next birthday in the server's timezone along with the year, which is 0 if it wasn't given. Birthdays on February 29 are celebrated on February 28 in years without one, and leapday is true for them.
+func parseBirthday(s string, info *GuildInfo) (time.Time, int, bool, error) {
+	tz := getTimezone(info, nil) // Deliberately do not include the user timezone here. We want this to operate on the server timezone.
+	now := time.Now().In(tz)
+	year := 0
+	var t time.Time
+	var err error
+	for _, format := range []string{"_2 Jan", "Jan _2", "January _2", "_2 January"} {
+		if t, err = time.ParseInLocation(format+" 2006", s, tz); err == nil {
+			year = t.Year()
+			break
+		}
+		if t, err = time.ParseInLocation(format+" 2006", s+" 2000", tz); err == nil { // 2000 is a leap year, so February 29 is accepted
+			break
+		}
+	}
+	if err != nil {
+		return t, 0, false, err
+	}
+	if year != 0 && (year < 1900 || year >= now.Year()) {
+		return t, 0, false, fmt.Errorf("%v is not a valid birth year", year)
+	}
+	month, day := t.Month(), t.Day()
+	leapday := month == time.February && day == 29
+	cutoff := time.Now().AddDate(0, 0, -1).UTC()
+	for y := now.Year(); ; y++ {
+		if leapday {
+			day = time.Date(y, time.March, 0, 0, 0, 0, 0, tz).Day() // The last day of February
+		}
+		if t = time.Date(y, month, day, 0, 0, 0, 0, tz).UTC(); !t.Before(cutoff) {
+			break
+		}
+	}
+	return t, year, leapday, nil
+}
+
+// removeBirthday deletes the birthday of a member on this server. This also deletes the event that takes away their birthday role, so if their birthday is happening right now, the role is removed immediately instead. Returns the number of deleted events.
+func removeBirthday(info *GuildInfo, user string) int64 {
+	n := sb.db.RemoveBirthday(SBatoi(info.ID), user)
+	if n > 0 && info.config.Schedule.BirthdayRole != 0 {
+		role := SBitoa(info.config.Schedule.BirthdayRole)
+		if info.UserHasRole(user, role) {
+			info.LogError("Failed to remove birthday role: ", sb.dg.GuildMemberRoleRemove(info.ID, user, role))
+		}
+	}
+	return n
+}
+
+// setBirthday replaces the birthday of a member on this server. Returns false if the server has too many events.
+func setBirthday(info *GuildInfo, p ScheduleBirthdayPayload, t time.Time, leapday bool) bool {
+	gID := SBatoi(info.ID)
+	removeBirthday(info, p.User)
+	data := EncodeSchedulePayload(p)
+	if leapday { // Repeating every year would move the birthday to February 28 for good, so it happens on the last day of February instead
+		sb.db.AddScheduleCron(gID, t, "0 0 L 2 *", SCHEDULE_BIRTHDAY, data)
+		return sb.db.AddScheduleCron(gID, t.AddDate(0, 0, 1), "0 0 1 3 *", SCHEDULE_BIRTHDAY_END, data)
+	}
+	sb.db.AddScheduleRepeat(gID, t, 8, 1, SCHEDULE_BIRTHDAY, data)                             // Create the normal birthday event at 12 AM on this server's timezone
+	return sb.db.AddScheduleRepeat(gID, t.AddDate(0, 0, 1), 8, 1, SCHEDULE_BIRTHDAY_END, data) // Create the hidden "remove birthday role" event 24 hours later.
+}
+
 type addBirthdayCommand struct {
 }
 
@@ -540,42 +936,148 @@ func (c *addBirthdayCommand) Process(args []string, msg *discordgo.Message, indi
 		return "```You must first ping the member and then provide the date!```", false, nil
 	}
 	ping := StripPing(args[0])
-	arg := strings.Join(args[1:], " ") + " " + strconv.Itoa(time.Now().Year())
-	t, err := time.ParseInLocation("_2 Jan 2006", arg, getTimezone(info, nil)) // Deliberately do not include the user timezone here. We want this to operate on the server timezone.
-	if err != nil {
-		t, err = time.ParseInLocation("Jan _2 2006", arg, getTimezone(info, nil))
-	}
-	if err != nil {
-		t, err = time.ParseInLocation("January _2 2006", arg, getTimezone(info, nil))
-	}
-	if err != nil {
-		t, err = time.ParseInLocation("_2 January 2006", arg, getTimezone(info, nil))
-	}
-	t = t.UTC()
+	t, year, leapday, err := parseBirthday(strings.Join(args[1:], " "), info)
 	if err != nil {
 		return "```Error: Could not parse time! Make sure it's in the format \"2 Jan\"```", false, nil
 	}
-	for t.Before(time.Now().AddDate(0, 0, -1).UTC()) {
-		t = t.AddDate(1, 0, 0)
-	}
 	_, err = strconv.ParseUint(ping, 10, 64)
 	if len(ping) == 0 || err != nil {
 		return "```Error: Invalid ping for member! Make sure you actually ping them via @MemberName, don't just type the name in.```", false, nil
 	}
 
-	sb.db.AddScheduleRepeat(SBatoi(info.ID), t, 8, 1, 1, ping)                        // Create the normal birthday event at 12 AM on this server's timezone
-	if !sb.db.AddScheduleRepeat(SBatoi(info.ID), t.AddDate(0, 0, 1), 8, 1, 4, ping) { // Create the hidden "remove birthday role" event 24 hours later.
+	if !setBirthday(info, ScheduleBirthdayPayload{User: ping, Year: year}, t, leapday) {
 		return "```Error: servers can't have more than 5000 events!```", false, nil
 	}
 	return ReplaceAllMentions("```Added a birthday for <@" + ping + ">```"), false, nil
 }
 func (c *addBirthdayCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Adds member's birthday to the schedule.",
+		Desc: "Adds member's birthday to the schedule, replacing their old one. Members can also set their own birthday with `" + info.config.Basic.CommandPrefix + "mybirthday`.",
 		Params: []CommandUsageParam{
 			{Name: "member", Desc: "A user ping in the form @User.", Optional: false},
-			{Name: "date", Desc: "The date in the form `Jan 2` or `2 Jan` - **do not** include the year!", Optional: false},
+			{Name: "date", Desc: "The date in the form `Jan 2` or `2 Jan`. The year is optional, and is only used to show their age. Birthdays on `Feb 29` are celebrated on Feb 28 in other years.", Optional: false},
 		},
 	}
 }
 func (c *addBirthdayCommand) UsageShort() string { return "Adds a birthday to the schedule." }
+
+type myBirthdayCommand struct {
+}
+
+func (c *myBirthdayCommand) Name() string {
+	return "MyBirthday"
+}
+func (c *myBirthdayCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must provide your birthday in the format \"2 Jan\", or \"remove\" to remove it.```", false, nil
+	}
+	if strings.ToLower(args[0]) == "remove" {
+		if removeBirthday(info, msg.Author.ID) == 0 {
+			return "```You don't have a birthday on this server.```", false, nil
+		}
+		return "```Removed your birthday.```", false, nil
+	}
+	hide := strings.ToLower(args[len(args)-1]) == "hideyear"
+	if hide {
+		args = args[:len(args)-1]
+	}
+	t, year, leapday, err := parseBirthday(strings.Join(args, " "), info)
+	if err != nil {
+		return "```Error: Could not parse your birthday! Make sure it's in the format \"2 Jan\" or \"2 Jan 1990\".```", false, nil
+	}
+	if !setBirthday(info, ScheduleBirthdayPayload{User: msg.Author.ID, Year: year, HideYear: hide}, t, leapday) {
+		return "```Error: servers can't have more than 5000 events!```", false, nil
+	}
+	date := ApplyTimezone(t, info, nil).Format("January 2")
+	if leapday {
+		date = "February 29, and is celebrated on February 28 in years without one"
+	}
+	return "```Your birthday is now " + date + ". Use " + info.config.Basic.CommandPrefix + "mybirthday remove to remove it.```", false, nil
+}
+func (c *myBirthdayCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Sets your birthday on this server, replacing your old one. On your birthday, you will be congratulated and given the birthday role, if the server has one. Your birthday is removed if you leave the server.",
+		Params: []CommandUsageParam{
+			{Name: "date|remove", Desc: "The date in the form `Jan 2` or `2 Jan`, or `remove` to remove your birthday. You can add your birth year to have your age shown, like `2 Jan 1990`.", Optional: false},
+			{Name: "hideyear", Desc: "If given, your birth year is stored but your age is never shown.", Optional: true},
+		},
+	}
+}
+func (c *myBirthdayCommand) UsageShort() string { return "Sets your birthday." }
+
+// parseMonth accepts a month name, any abbreviation of at least 3 letters, or its number
+func parseMonth(s string) time.Month {
+	s = strings.ToLower(s)
+	if i, err := strconv.Atoi(s); err == nil && i >= 1 && i <= 12 {
+		return time.Month(i)
+	}
+	for i := time.January; i <= time.December; i++ {
+		if len(s) >= 3 && strings.HasPrefix(strings.ToLower(i.String()), s) {
+			return i
+		}
+	}
+	return 0
+}
+
+type birthdaysCommand struct {
+}
+
+func (c *birthdaysCommand) Name() string {
+	return "Birthdays"
+}
+func (c *birthdaysCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	var month time.Month
+	if len(args) > 0 {
+		if month = parseMonth(args[0]); month == 0 {
+			return "```Error: " + args[0] + " is not a month.```", false, nil
+		}
+	}
+	type birthday struct {
+		date time.Time
+		line string
+	}
+	birthdays := []birthday{}
+	for _, v := range sb.db.GetEventsByType(SBatoi(info.ID), SCHEDULE_BIRTHDAY, 5000) {
+		local := ApplyTimezone(v.Date, info, nil)
+		if month != 0 && local.Month() != month {
+			continue
+		}
+		p := ScheduleBirthdayPayload{}
+		v.Payload(&p)
+		line := "**" + local.Format("Jan 2") + "** " + getUserName(SBatoi(p.User), info)
+		if age := p.Age(local); age > 0 {
+			line += fmt.Sprintf(" (turns %v)", age)
+		}
+		birthdays = append(birthdays, birthday{local, line})
+		if month == 0 && len(birthdays) >= 10 {
+			break
+		}
+	}
+	if len(birthdays) == 0 {
+		return "```There are no birthdays to show.```", false, nil
+	}
+	lines := []string{"Upcoming Birthdays:"}
+	if month != 0 {
+		sort.Slice(birthdays, func(i, j int) bool { return birthdays[i].date.Day() < birthdays[j].date.Day() })
+		lines[0] = "Birthdays in " + month.String() + ":"
+	}
+	for _, v := range birthdays {
+		lines = append(lines, v.line)
+	}
+	return ReplaceAllMentions(strings.Join(lines, "\n")), len(lines) > 11, nil
+}
+func (c *birthdaysCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Lists the next 10 birthdays on this server, or every birthday in the given month.",
+		Params: []CommandUsageParam{
+			{Name: "month", Desc: "A month like `March` or `mar`.", Optional: true},
+		},
+	}
+}
+func (c *birthdaysCommand) UsageShort() string { return "Lists upcoming birthdays." }
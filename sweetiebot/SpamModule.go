@@ -1,28 +1,90 @@
 package sweetiebot
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"math"
 
 	"github.com/blackhole12/discordgo"
 )
 
+// pressureParts breaks down the pressure generated by a single message into each of its sources
+type pressureParts struct {
+	base      float32
+	length    float32
+	lines     float32
+	pings     float32
+	images    float32
+	repeat    float32
+	duplicate float32
+}
+
+func (p *pressureParts) Total() float32 {
+	return p.base + p.length + p.lines + p.pings + p.images + p.repeat + p.duplicate
+}
+
+func (p *pressureParts) Scale(f float32) {
+	p.base *= f
+	p.length *= f
+	p.lines *= f
+	p.pings *= f
+	p.images *= f
+	p.repeat *= f
+	p.duplicate *= f
+}
+
+type pressureEvent struct {
+	timestamp int64 // in milliseconds
+	channel   string
+	parts     pressureParts
+	decay     float32
+	pressure  float32
+}
+
+const maxPressureHistory = 20
+
 type userPressure struct {
 	pressure    float32
 	lastmessage int64
 	lastcache   string
+	history     []pressureEvent
+}
+
+type duplicateEntry struct {
+	msg       *discordgo.Message
+	timestamp int64 // in milliseconds
+	penalized bool
 }
 
+// duplicateGroup tracks recent messages from any user that share the same fingerprint
+type duplicateGroup struct {
+	entries []*duplicateEntry
+	alerted bool
+}
+
+type duplicateKill struct {
+	msg         *discordgo.Message
+	oldpressure float32
+	newpressure float32
+}
+
+const minDuplicateLength = 8
+
 // SpamModule detects banned emotes and deletes them
 type SpamModule struct {
 	sync.Mutex
-	tracker  map[uint64]*userPressure
-	lastraid int64
+	tracker    map[uint64]*userPressure
+	duplicates map[string]*duplicateGroup
+	lastraid   int64
 }
 
 // Name of the module
@@ -36,14 +98,18 @@ func (w *SpamModule) Commands() []Command {
 		&autoSilenceCommand{w},
 		&wipeCommand{},
 		&getPressureCommand{w},
+		&simulateSpamCommand{},
 		&getRaidCommand{w},
 		&banRaidCommand{w},
+		&raidReportCommand{w},
+		&lockdownCommand{},
+		&unlockCommand{},
 	}
 }
 
 // Description of the module
 func (w *SpamModule) Description() string {
-	return "Tracks all channels it is active on for spammers. Each message someone sends generates \"pressure\", which decays rapidly. Long messages, messages with links, or messages with pings will generate more pressure. If a user generates too much pressure, they will be silenced and the moderators notified. Also detects groups of people joining at the same time and alerts the moderators of a potential raid."
+	return "Tracks all channels it is active on for spammers. Each message someone sends generates \"pressure\", which decays rapidly. Long messages, messages with links, or messages with pings will generate more pressure. If a user generates too much pressure, they will be silenced and the moderators notified. Also detects groups of people joining at the same time, or several people posting the same message at the same time, and alerts the moderators of a potential raid."
 }
 
 func isSilenced(m *discordgo.Member, info *GuildInfo) bool {
@@ -114,7 +180,12 @@ func killSpammer(u *discordgo.User, info *GuildInfo, msg *discordgo.Message, rea
 	}
 	logmsg := fmt.Sprintf("Killing spammer %s (pressure: %v -> %v). Last message sent on #%s in %s: \n%s%s", u.Username, oldpressure, newpressure, chname, info.Name, lastmsg, msgembeds)
 	if SBatoi(msg.ChannelID) == info.config.Users.WelcomeChannel {
-		sb.dg.GuildBanCreateWithReason(info.ID, u.ID, "Autobanned for "+reason+" in the welcome channel.", 1)
+		appealID, appealMsg := OpenAppeal(info, u.ID, APPEAL_TYPE_BAN, reason+" in the welcome channel")
+		if err := sb.dg.GuildBanCreateWithReason(info.ID, u.ID, "Autobanned for "+reason+" in the welcome channel.", 1); err != nil {
+			CancelAppeal(info, appealID, appealMsg)
+			info.LogError("Error banning spammer: ", err)
+			return
+		}
 		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+u.ID+"> was banned for "+reason+" in the welcome channel.")
 		info.Log(logmsg)
 		return
@@ -152,25 +223,160 @@ func killSpammer(u *discordgo.User, info *GuildInfo, msg *discordgo.Message, rea
 	if !silenced { // Only send the alert if they weren't silenced already
 		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Alert: <@"+u.ID+"> was silenced for "+reason+". Please investigate.") // Alert admins
 		info.Log(logmsg)
+		OpenAppeal(info, u.ID, APPEAL_TYPE_SILENCE, reason)
 	} else {
 		info.Log("Killing spammer " + u.Username)
 	}
 }
 
 // Gets the pressure generated from an isolated message, ignoring the context.
-func getPressure(info *GuildInfo, m *discordgo.Message, edited bool) float32 {
-	p := info.config.Spam.ImagePressure * float32(len(m.Attachments))
-	p += info.config.Spam.PingPressure * float32(len(m.Mentions))
-	p += info.config.Spam.ImagePressure * float32(len(m.Embeds))
-	p += info.config.Spam.LengthPressure * float32(len(m.Content))
-	p += info.config.Spam.LinePressure * float32(strings.Count(m.Content, "\n"))
-	p += info.config.Spam.BasePressure
-	if edited { // Editing a message contributes only the square root of the total (so you can edit a post with lots of pictures and not get instabanned)
-		p = float32(math.Sqrt(float64(p)))
+func getPressure(config *BotConfig, m *discordgo.Message, edited bool) pressureParts {
+	p := getContentPressure(config, m.Content, len(m.Mentions), len(m.Attachments)+len(m.Embeds))
+	if total := p.Total(); edited && total > 0 { // Editing a message contributes only the square root of the total (so you can edit a post with lots of pictures and not get instabanned)
+		p.Scale(float32(math.Sqrt(float64(total))) / total)
 	}
 	return p
 }
 
+// Gets the pressure generated by message content with the given number of pings and images
+func getContentPressure(config *BotConfig, content string, pings int, images int) pressureParts {
+	return pressureParts{
+		base:   config.Spam.BasePressure,
+		length: config.Spam.LengthPressure * float32(len(content)),
+		lines:  config.Spam.LinePressure * float32(strings.Count(content, "\n")),
+		pings:  config.Spam.PingPressure * float32(pings),
+		images: config.Spam.ImagePressure * float32(images),
+	}
+}
+
+// Applies the pressure from a message sent at tm (in milliseconds) and returns the pressure the user had before it. Returns false if the timestamp was invalid.
+func (track *userPressure) addPressure(config *BotConfig, p pressureParts, content string, channelID string, tm int64) (float32, bool) {
+	if len(content) > 0 && strings.ToLower(content) == track.lastcache {
+		p.repeat = config.Spam.RepeatPressure
+	}
+	track.lastcache = strings.ToLower(content)
+	last := track.lastmessage
+	track.lastmessage = tm
+	if track.lastmessage < last { // This can happen because discord has a bad habit of re-sending timestamps if anything so much as touches a message
+		track.lastmessage = last
+		return track.pressure, false // An invalid timestamp is never spam
+	}
+	interval := track.lastmessage - last
+
+	override, ok := config.Spam.MaxChannelPressure[SBatoi(channelID)]
+	if ok && override > 0.0 {
+		p.Scale(config.Spam.MaxPressure / override)
+	}
+	oldpressure := track.pressure
+	track.pressure -= config.Spam.BasePressure * (float32(interval) / (config.Spam.PressureDecay * 1000.0))
+	if track.pressure < 0 {
+		track.pressure = 0
+	}
+	decay := oldpressure - track.pressure
+	track.pressure += p.Total()
+
+	if len(track.history) >= maxPressureHistory {
+		track.history = track.history[1:]
+	}
+	track.history = append(track.history, pressureEvent{tm, channelID, p, decay, track.pressure})
+	return oldpressure, true
+}
+
+// Normalizes a message so that case, punctuation, pings and spacing can't be used to disguise duplicated spam
+func getFingerprint(s string) string {
+	s = mentionregex.ReplaceAllString(strings.ToLower(s), "")
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }), " ")
+}
+
+// Tracks message fingerprints across the entire guild. If enough different users post the same message within Spam.DuplicateTime seconds, returns the duplicate
+// pressure for this message and applies it to everyone else who posted it. Returns any other users that went over the pressure limit, and whether the mods should be alerted.
+// Must be called while holding the module lock.
+func (w *SpamModule) checkDuplicates(info *GuildInfo, m *discordgo.Message, tm int64) (float32, []duplicateKill, []*duplicateEntry) {
+	if info.config.Spam.DuplicateUsers < 2 || info.config.Spam.DuplicateTime <= 0 {
+		return 0, nil, nil
+	}
+	cutoff := tm - info.config.Spam.DuplicateTime*1000
+	for k, g := range w.duplicates {
+		i := 0
+		for i < len(g.entries) && g.entries[i].timestamp < cutoff {
+			i++
+		}
+		g.entries = g.entries[i:]
+		if len(g.entries) == 0 {
+			delete(w.duplicates, k)
+		}
+	}
+
+	fingerprint := getFingerprint(m.Content)
+	if len(fingerprint) < minDuplicateLength {
+		return 0, nil, nil
+	}
+	g, ok := w.duplicates[fingerprint]
+	if !ok {
+		g = &duplicateGroup{}
+		w.duplicates[fingerprint] = g
+	}
+	entry := &duplicateEntry{m, tm, false}
+	g.entries = append(g.entries, entry)
+
+	users := make(map[string]bool)
+	for _, v := range g.entries {
+		users[v.msg.Author.ID] = true
+	}
+	if len(users) < info.config.Spam.DuplicateUsers {
+		return 0, nil, nil
+	}
+
+	kills := []duplicateKill{}
+	for _, v := range g.entries {
+		if v.penalized || v == entry {
+			continue
+		}
+		v.penalized = true
+		track, ok := w.tracker[SBatoi(v.msg.Author.ID)]
+		if !ok {
+			continue
+		}
+		oldpressure := track.pressure
+		track.pressure += info.config.Spam.DuplicatePressure
+		if len(track.history) >= maxPressureHistory {
+			track.history = track.history[1:]
+		}
+		track.history = append(track.history, pressureEvent{tm, v.msg.ChannelID, pressureParts{duplicate: info.config.Spam.DuplicatePressure}, 0, track.pressure})
+		if track.pressure > info.config.Spam.MaxPressure {
+			kills = append(kills, duplicateKill{v.msg, oldpressure, track.pressure})
+		}
+	}
+	entry.penalized = true
+
+	var alert []*duplicateEntry
+	if !g.alerted {
+		g.alerted = true
+		alert = append(alert, g.entries...)
+	}
+	return info.config.Spam.DuplicatePressure, kills, alert
+}
+
+func (w *SpamModule) alertDuplicates(info *GuildInfo, entries []*duplicateEntry) {
+	s := make([]string, 0, len(entries))
+	seen := make(map[string]bool)
+	for _, v := range entries {
+		if !seen[v.msg.Author.ID] {
+			seen[v.msg.Author.ID] = true
+			s = append(s, v.msg.Author.Username+"#"+v.msg.Author.Discriminator+" ("+v.msg.Author.ID+")")
+		}
+	}
+	text := ExtraSanitize(entries[0].msg.Content)
+	if t := truncateString(text, 200); t != text {
+		text = t + " [truncated]"
+	}
+	ch := SBitoa(info.config.Basic.ModChannel)
+	if sb.Debug {
+		ch, _ = sb.DebugChannels[info.ID]
+	}
+	info.SendMessage(ch, fmt.Sprintf("<@&%v> Possible coordinated spam detected! %v users posted the same message within %v seconds:\n```%s```Message: %s", info.config.Basic.AlertRole, len(s), info.config.Spam.DuplicateTime, strings.Join(s, "\n"), text))
+}
+
 func (w *SpamModule) checkSpam(info *GuildInfo, m *discordgo.Message, edited bool) bool {
 	if m.Author != nil {
 		if info.UserHasRole(m.Author.ID, SBitoa(info.config.Spam.SilentRole)) && SBatoi(m.ChannelID) != info.config.Users.WelcomeChannel {
@@ -194,36 +400,34 @@ func (w *SpamModule) checkSpam(info *GuildInfo, m *discordgo.Message, edited boo
 		w.Lock()
 		_, ok := w.tracker[id]
 		if !ok {
-			w.tracker[id] = &userPressure{0, tm.Unix()*1000 + int64(tm.Nanosecond()/1000000), ""}
+			w.tracker[id] = &userPressure{0, tm.Unix()*1000 + int64(tm.Nanosecond()/1000000), "", nil}
 		}
 		track := w.tracker[id]
-		w.Unlock()
-		p := getPressure(info, m, edited)
-		if len(m.Content) > 0 && strings.ToLower(m.Content) == track.lastcache {
-			p += info.config.Spam.RepeatPressure
+		p := getPressure(&info.config, m, edited)
+		var kills []duplicateKill
+		var alert []*duplicateEntry
+		if !edited {
+			p.duplicate, kills, alert = w.checkDuplicates(info, m, tm.Unix()*1000+int64(tm.Nanosecond()/1000000))
 		}
-		track.lastcache = strings.ToLower(m.Content)
-		last := track.lastmessage
-		track.lastmessage = tm.Unix()*1000 + int64(tm.Nanosecond()/1000000)
-		if track.lastmessage < last { // This can happen because discord has a bad habit of re-sending timestamps if anything so much as touches a message
-			track.lastmessage = last
-			return false // An invalid timestamp is never spam
+		oldpressure, valid := track.addPressure(&info.config, p, m.Content, m.ChannelID, tm.Unix()*1000+int64(tm.Nanosecond()/1000000))
+		newpressure := track.pressure
+		w.Unlock()
+		if len(alert) > 0 {
+			w.alertDuplicates(info, alert)
 		}
-		interval := track.lastmessage - last
-
-		override, ok := info.config.Spam.MaxChannelPressure[SBatoi(m.ChannelID)]
-		if ok && override > 0.0 {
-			p *= (info.config.Spam.MaxPressure / override)
+		for _, v := range kills {
+			killSpammer(v.msg.Author, info, v.msg, "posting the same message as several other users", v.oldpressure, v.newpressure)
 		}
-		oldpressure := track.pressure
-		track.pressure -= info.config.Spam.BasePressure * (float32(interval) / (info.config.Spam.PressureDecay * 1000.0))
-		if track.pressure < 0 {
-			track.pressure = 0
+		if !valid {
+			return false
 		}
-		track.pressure += p
-		//fmt.Println("Current Pressure: ", track.pressure)
-		if track.pressure > info.config.Spam.MaxPressure {
-			killSpammer(m.Author, info, m, "spamming too many messages", oldpressure, track.pressure)
+		//fmt.Println("Current Pressure: ", newpressure)
+		if newpressure > info.config.Spam.MaxPressure {
+			reason := "spamming too many messages"
+			if p.duplicate > 0 {
+				reason = "posting the same message as several other users"
+			}
+			killSpammer(m.Author, info, m, reason, oldpressure, newpressure)
 			return true
 		}
 	}
@@ -240,39 +444,447 @@ func (w *SpamModule) OnCommand(info *GuildInfo, m *discordgo.Message) bool {
 	return w.checkSpam(info, m, false)
 }
 
-// DisableLockdown disables the guild lockdown, if there is one
-func DisableLockdown(info *GuildInfo) {
-	if info.lockdown != -1 {
-		modchan := SBitoa(info.config.Basic.ModChannel)
-		if sb.Debug {
-			modchan, _ = sb.DebugChannels[info.ID]
+// Actions that can be assigned to a lockdown level in Spam.LockdownLevels
+const (
+	lockdownVerification = "verification"
+	lockdownSlowmode     = "slowmode"
+	lockdownLock         = "lock"
+	lockdownInvites      = "invites"
+	lockdownSilence      = "silence"
+)
+
+const featureInvitesDisabled = "INVITES_DISABLED"
+
+// lockdownState remembers everything a lockdown changed so it can be restored afterwards. It is stored in Spam.ActiveLockdown, so it survives a restart.
+type lockdownState struct {
+	Level        string                                    `json:"level"`
+	Duration     time.Duration                             `json:"duration"`     // if 0, the lockdown lasts until someone manually disables it
+	Engaged      time.Time                                 `json:"engaged"`      // when the lockdown was engaged, or its timer was last reset
+	Verification discordgo.VerificationLevel               `json:"verification"` // if -1 the verification level wasn't changed, otherwise remembers the previous setting
+	Slowmode     map[string]int                            `json:"slowmode"`     // previous slowmode of every channel that was changed
+	Overwrites   map[string]*discordgo.PermissionOverwrite `json:"overwrites"`   // previous @everyone overwrite of every locked channel, or nil if there wasn't one
+	Invites      bool                                      `json:"invites"`      // true if invites were paused
+	Silence      bool                                      `json:"silence"`      // true if new members are being autosilenced
+}
+
+// Every action that can be assigned to a lockdown level
+var lockdownActions = []string{lockdownVerification, lockdownSlowmode, lockdownLock, lockdownInvites, lockdownSilence}
+
+// checkLockdownActions lowercases the actions given for a lockdown level and returns an error if any of them don't exist
+func checkLockdownActions(actions []string) error {
+	for i, v := range actions {
+		actions[i] = strings.ToLower(v)
+		found := false
+		for _, a := range lockdownActions {
+			found = found || actions[i] == a
+		}
+		if !found {
+			return fmt.Errorf("%s is not a lockdown action. Valid actions are: %s", v, strings.Join(lockdownActions, ", "))
 		}
+	}
+	return nil
+}
+
+func getSlowmode(channelID string) (int, error) {
+	body, err := sb.dg.RequestWithBucketID("GET", discordgo.EndpointChannel(channelID), nil, discordgo.EndpointChannel(channelID))
+	if err != nil {
+		return 0, err
+	}
+	ch := struct {
+		RateLimitPerUser int `json:"rate_limit_per_user"`
+	}{}
+	err = json.Unmarshal(body, &ch)
+	return ch.RateLimitPerUser, err
+}
+
+func setSlowmode(channelID string, seconds int) error {
+	data := struct {
+		RateLimitPerUser int `json:"rate_limit_per_user"`
+	}{seconds}
+	_, err := sb.dg.RequestWithBucketID("PATCH", discordgo.EndpointChannel(channelID), data, discordgo.EndpointChannel(channelID))
+	return err
+}
+
+func getGuildFeatures(guildID string) ([]string, error) {
+	body, err := sb.dg.RequestWithBucketID("GET", discordgo.EndpointGuild(guildID), nil, discordgo.EndpointGuild(guildID))
+	if err != nil {
+		return nil, err
+	}
+	g := struct {
+		Features []string `json:"features"`
+	}{}
+	err = json.Unmarshal(body, &g)
+	return g.Features, err
+}
+
+func setGuildFeatures(guildID string, features []string) error {
+	data := struct {
+		Features []string `json:"features"`
+	}{features}
+	_, err := sb.dg.RequestWithBucketID("PATCH", discordgo.EndpointGuild(guildID), data, discordgo.EndpointGuild(guildID))
+	return err
+}
+
+func setInvitesPaused(guildID string, paused bool) error {
+	features, err := getGuildFeatures(guildID)
+	if err != nil {
+		return err
+	}
+	result := make([]string, 0, len(features)+1)
+	for _, v := range features {
+		if v != featureInvitesDisabled {
+			result = append(result, v)
+		}
+	}
+	if paused {
+		result = append(result, featureInvitesDisabled)
+	}
+	return setGuildFeatures(guildID, result)
+}
+
+func getLockdownActions(info *GuildInfo, level string) (map[string]bool, bool) {
+	actions, ok := info.config.Spam.LockdownLevels[strings.ToLower(level)]
+	return actions, ok
+}
+
+func getLockdownLevels(info *GuildInfo) []string {
+	levels := make([]string, 0, len(info.config.Spam.LockdownLevels))
+	for k := range info.config.Spam.LockdownLevels {
+		levels = append(levels, k)
+	}
+	sort.Strings(levels)
+	return levels
+}
+
+func describeLockdownActions(actions map[string]bool) string {
+	s := MapToSlice(actions)
+	sort.Strings(s)
+	return strings.Join(s, ", ")
+}
+
+// EngageLockdown engages the given lockdown level. If a lockdown is already engaged, it is disengaged first, unless it's the same level, in which case the timer is simply reset.
+func EngageLockdown(info *GuildInfo, level string, duration time.Duration) ([]string, error) {
+	level = strings.ToLower(level)
+	actions, ok := getLockdownActions(info, level)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a lockdown level. Valid lockdown levels are: %s", level, strings.Join(getLockdownLevels(info), ", "))
+	}
+	lines := []string{}
+	if info.config.Spam.ActiveLockdown != nil {
+		if info.config.Spam.ActiveLockdown.Level == level {
+			info.config.Spam.ActiveLockdown.Duration = duration
+			resetLockdownTimer(info)
+			return []string{"The " + level + " lockdown was already engaged, so its timer has been reset."}, nil
+		}
+		lines = append(lines, disableLockdown(info)...)
+	}
+
+	state := &lockdownState{
+		Level:        level,
+		Duration:     duration,
+		Verification: -1,
+		Slowmode:     make(map[string]int),
+		Overwrites:   make(map[string]*discordgo.PermissionOverwrite),
+	}
+	guild, err := sb.dg.State.Guild(info.ID)
+	if err != nil {
+		return nil, errors.New("Guild cannot be found in state?!")
+	}
+
+	if actions[lockdownVerification] {
+		sb.dg.State.RLock()
+		previous := guild.VerificationLevel
+		sb.dg.State.RUnlock()
+		high := discordgo.VerificationLevelHigh
+		g := discordgo.GuildParams{"", "", &high, 0, "", 0, "", "", ""}
+		if _, err = sb.dg.GuildEdit(info.ID, g); err != nil {
+			lines = append(lines, "Could not raise the verification level! Make sure you've given Sweetie Bot the Manage Server permission.")
+		} else {
+			state.Verification = previous
+			lines = append(lines, "Raised the server verification level.")
+		}
+	}
+	if actions[lockdownSlowmode] && len(info.config.Spam.SlowmodeChannels) > 0 {
+		failed := []string{}
+		for ch := range info.config.Spam.SlowmodeChannels {
+			previous, err := getSlowmode(ch)
+			if err == nil {
+				err = setSlowmode(ch, info.config.Spam.LockdownSlowmode)
+			}
+			if err != nil {
+				failed = append(failed, "<#"+ch+">")
+			} else {
+				state.Slowmode[ch] = previous
+			}
+		}
+		lines = append(lines, fmt.Sprintf("Set slowmode to %s on %v channels.", Pluralize(int64(info.config.Spam.LockdownSlowmode), " second"), len(state.Slowmode)))
+		if len(failed) > 0 {
+			lines = append(lines, "Could not set slowmode on "+strings.Join(failed, ", ")+". Make sure you've given Sweetie Bot the Manage Channels permission.")
+		}
+	}
+	if actions[lockdownLock] && len(info.config.Spam.LockdownChannels) > 0 {
+		failed := []string{}
+		for ch := range info.config.Spam.LockdownChannels {
+			channel, err := sb.dg.State.Channel(ch)
+			if err != nil {
+				failed = append(failed, "<#"+ch+">")
+				continue
+			}
+			var previous *discordgo.PermissionOverwrite
+			allow := 0
+			deny := 0
+			sb.dg.State.RLock()
+			for _, v := range channel.PermissionOverwrites {
+				if strings.ToLower(v.Type) == "role" && v.ID == info.ID {
+					overwrite := *v
+					previous = &overwrite
+					allow = v.Allow
+					deny = v.Deny
+					break
+				}
+			}
+			sb.dg.State.RUnlock()
+			allow &= (^0x00000800)
+			deny |= 0x00000800
+			if err = sb.dg.ChannelPermissionSet(ch, info.ID, "role", allow, deny); err != nil {
+				failed = append(failed, "<#"+ch+">")
+			} else {
+				state.Overwrites[ch] = previous
+			}
+		}
+		lines = append(lines, fmt.Sprintf("Locked %v channels.", len(state.Overwrites)))
+		if len(failed) > 0 {
+			lines = append(lines, "Could not lock "+strings.Join(failed, ", ")+". Make sure you've given Sweetie Bot the Manage Roles permission.")
+		}
+	}
+	if actions[lockdownInvites] {
+		if err = setInvitesPaused(info.ID, true); err != nil {
+			lines = append(lines, "Could not pause invites! Make sure you've given Sweetie Bot the Manage Server permission.")
+		} else {
+			state.Invites = true
+			lines = append(lines, "Paused all invite links.")
+		}
+	}
+	if actions[lockdownSilence] {
+		state.Silence = true
+		lines = append(lines, "Everyone who joins will be silenced.")
+	}
+
+	state.Engaged = time.Now().UTC()
+	info.config.Spam.ActiveLockdown = state
+	info.SaveConfig()
+	return lines, nil
+}
+
+// resetLockdownTimer restarts the timer of the current lockdown, if there is one
+func resetLockdownTimer(info *GuildInfo) {
+	if info.config.Spam.ActiveLockdown != nil {
+		info.config.Spam.ActiveLockdown.Engaged = time.Now().UTC()
+		info.SaveConfig()
+	}
+}
+
+func disableLockdown(info *GuildInfo) []string {
+	state := info.config.Spam.ActiveLockdown
+	if state == nil {
+		return []string{}
+	}
+	info.config.Spam.ActiveLockdown = nil
+	info.SaveConfig()
+	lines := []string{}
+
+	if state.Verification != -1 {
 		guild, err := sb.dg.State.Guild(info.ID)
 		if err != nil {
-			info.SendMessage(modchan, "Guild cannot be found in state?!")
+			lines = append(lines, "Guild cannot be found in state?!")
 		} else if guild.VerificationLevel != discordgo.VerificationLevelHigh {
-			info.SendMessage(modchan, fmt.Sprintf("The verification level is at %v instead of %v, which means it was manually changed by someone other than sweetiebot, so it has not been restored.", guild.VerificationLevel, discordgo.VerificationLevelHigh))
+			lines = append(lines, fmt.Sprintf("The verification level is at %v instead of %v, which means it was manually changed by someone other than sweetiebot, so it has not been restored.", guild.VerificationLevel, discordgo.VerificationLevelHigh))
 		} else {
-			g := discordgo.GuildParams{
-				Name:                        "",
-				Region:                      "",
-				VerificationLevel:           &info.lockdown,
-				DefaultMessageNotifications: 0,
-				AfkChannelID:                "",
-				AfkTimeout:                  0,
-				Icon:                        "",
-				OwnerID:                     "",
-				Splash:                      "",
+			g := discordgo.GuildParams{"", "", &state.Verification, 0, "", 0, "", "", ""}
+			if _, err = sb.dg.GuildEdit(info.ID, g); err != nil {
+				lines = append(lines, "Could not restore the verification level! Make sure you've given the Sweetie Bot role the Manage Server permission, you'll have to manually restore it yourself this time.")
+			} else {
+				lines = append(lines, "Server verification level restored.")
 			}
-			_, err = sb.dg.GuildEdit(info.ID, g)
 		}
-		if err != nil {
-			info.SendMessage(modchan, "Could not disengage lockdown! Make sure you've given the Sweetie Bot role the Manage Server permission, you'll have to manually restore it yourself this time.")
+	}
+	if len(state.Slowmode) > 0 {
+		failed := []string{}
+		for ch, v := range state.Slowmode {
+			if setSlowmode(ch, v) != nil {
+				failed = append(failed, "<#"+ch+">")
+			}
+		}
+		if len(failed) > 0 {
+			lines = append(lines, "Could not restore slowmode on "+strings.Join(failed, ", ")+", you'll have to manually restore it yourself this time.")
 		} else {
-			info.SendMessage(modchan, "Lockdown disengaged, server verification levels restored.")
+			lines = append(lines, "Slowmode restored.")
 		}
-		info.lockdown = -1
 	}
+	if len(state.Overwrites) > 0 {
+		failed := []string{}
+		for ch, v := range state.Overwrites {
+			var err error
+			if v == nil {
+				err = sb.dg.ChannelPermissionDelete(ch, info.ID)
+			} else {
+				err = sb.dg.ChannelPermissionSet(ch, info.ID, "role", v.Allow, v.Deny)
+			}
+			if err != nil {
+				failed = append(failed, "<#"+ch+">")
+			}
+		}
+		if len(failed) > 0 {
+			lines = append(lines, "Could not unlock "+strings.Join(failed, ", ")+", you'll have to manually restore the @everyone permissions yourself this time.")
+		} else {
+			lines = append(lines, "Channels unlocked.")
+		}
+	}
+	if state.Invites {
+		if setInvitesPaused(info.ID, false) != nil {
+			lines = append(lines, "Could not resume invites! You'll have to manually resume them yourself this time.")
+		} else {
+			lines = append(lines, "Invites resumed.")
+		}
+	}
+	return append([]string{"Lockdown disengaged."}, lines...)
+}
+
+// DisableLockdown disables the guild lockdown, if there is one, and reports what was restored to the mod channel
+func DisableLockdown(info *GuildInfo) {
+	if info.config.Spam.ActiveLockdown != nil {
+		modchan := SBitoa(info.config.Basic.ModChannel)
+		if sb.Debug {
+			modchan, _ = sb.DebugChannels[info.ID]
+		}
+		info.SendMessage(modchan, strings.Join(disableLockdown(info), "\n"))
+	}
+}
+
+type raidSuspect struct {
+	User    *discordgo.User
+	Joined  time.Time
+	Score   float32
+	Reasons []string
+}
+
+type raidSuspects []raidSuspect
+
+func (s raidSuspects) Len() int {
+	return len(s)
+}
+func (s raidSuspects) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+func (s raidSuspects) Less(i, j int) bool {
+	return s[i].Score > s[j].Score
+}
+
+// Strips everything except letters from a username, so "raider1" and "Raider_2" are considered the same name
+func normalizeUsername(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+func levenshtein(a []rune, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func similarUsernames(a string, b string) bool {
+	ra := []rune(a)
+	rb := []rune(b)
+	if len(ra) < 3 || len(rb) < 3 {
+		return false
+	}
+	n := len(ra)
+	if len(rb) < n {
+		n = len(rb)
+	}
+	return levenshtein(ra, rb) <= n/4
+}
+
+// Scores a group of recent joins on how likely each user is to be part of a raid. Each heuristic contributes up to 1 point: account age, having a default avatar,
+// having a username similar to another user in the group, and how many other users joined within Spam.RaidBurstTime seconds of them.
+func scoreRaidSuspects(info *GuildInfo, joins []struct {
+	User      *discordgo.User
+	FirstSeen time.Time
+}) []raidSuspect {
+	names := make([]string, len(joins))
+	for i, v := range joins {
+		names[i] = normalizeUsername(v.User.Username)
+	}
+	r := make([]raidSuspect, 0, len(joins))
+	for i, v := range joins {
+		s := raidSuspect{v.User, v.FirstSeen, 0, []string{}}
+		if info.config.Spam.RaidAccountAge > 0 {
+			age := v.FirstSeen.Sub(snowflakeTime(SBatoi(v.User.ID)))
+			maxage := time.Duration(info.config.Spam.RaidAccountAge) * time.Second
+			if age < maxage {
+				if age < 0 {
+					age = 0
+				}
+				s.Score += 1.0 - float32(age)/float32(maxage)
+				s.Reasons = append(s.Reasons, "account age: "+TimeDiff(age))
+			}
+		}
+		if len(v.User.Avatar) == 0 {
+			s.Score += 1.0
+			s.Reasons = append(s.Reasons, "default avatar")
+		}
+		for j := range joins {
+			if j != i && similarUsernames(names[i], names[j]) {
+				s.Score += 1.0
+				s.Reasons = append(s.Reasons, "similar to "+joins[j].User.Username)
+				break
+			}
+		}
+		if info.config.Spam.RaidBurstTime > 0 && info.config.Spam.RaidSize > 1 {
+			window := time.Duration(info.config.Spam.RaidBurstTime) * time.Second
+			n := 0
+			for j := range joins {
+				d := joins[j].FirstSeen.Sub(v.FirstSeen)
+				if j != i && d <= window && d >= -window {
+					n++
+				}
+			}
+			if n > 0 {
+				burst := float32(n) / float32(info.config.Spam.RaidSize-1)
+				if burst > 1.0 {
+					burst = 1.0
+				}
+				s.Score += burst
+				s.Reasons = append(s.Reasons, Pluralize(int64(n), " other join")+" within "+TimeDiff(window))
+			}
+		}
+		r = append(r, s)
+	}
+	return r
 }
 
 func (w *SpamModule) checkRaid(info *GuildInfo, m *discordgo.Member) {
@@ -280,12 +892,20 @@ func (w *SpamModule) checkRaid(info *GuildInfo, m *discordgo.Member) {
 		return
 	}
 	raidsize := sb.db.CountNewUsers(info.config.Spam.RaidTime, SBatoi(info.ID))
-	if info.config.Spam.RaidSize > 0 && raidsize >= info.config.Spam.RaidSize && RateLimit(&w.lastraid, info.config.Spam.RaidTime*2) {
-		r := sb.db.GetNewestUsers(raidsize, SBatoi(info.ID))
-		s := make([]string, 0, len(r))
+	if info.config.Spam.RaidSize <= 0 || raidsize < info.config.Spam.RaidSize {
+		return
+	}
+	suspects := []raidSuspect{}
+	for _, v := range scoreRaidSuspects(info, sb.db.GetNewestUsers(raidsize, SBatoi(info.ID))) {
+		if v.Score >= info.config.Spam.RaidMinScore {
+			suspects = append(suspects, v)
+		}
+	}
+	if len(suspects) >= info.config.Spam.RaidSize && RateLimit(&w.lastraid, info.config.Spam.RaidTime*2) {
+		s := make([]string, 0, len(suspects))
 
-		for _, v := range r {
-			s = append(s, v.User.Username+"  (joined: "+ApplyTimezone(v.FirstSeen, info, nil).Format(time.ANSIC)+")")
+		for _, v := range suspects {
+			s = append(s, fmt.Sprintf("%s  (joined: %s, score: %.2f)", v.User.Username, ApplyTimezone(v.Joined, info, nil).Format(time.ANSIC), v.Score))
 			if info.config.Spam.AutoSilence >= 1 {
 				silenceMember(v.User, info)
 			}
@@ -295,25 +915,17 @@ func (w *SpamModule) checkRaid(info *GuildInfo, m *discordgo.Member) {
 			ch, _ = sb.DebugChannels[info.ID]
 		}
 		info.SendMessage(ch, "<@&"+SBitoa(info.config.Basic.AlertRole)+"> Possible Raid Detected! Use `"+info.config.Basic.CommandPrefix+"autosilence all` to silence them!\n```"+strings.Join(s, "\n")+"```")
-		if info.config.Spam.LockdownDuration > 0 {
-			if info.lockdown == -1 { // Only engage lockdown if it wasn't already engaged
-				guild, err := sb.dg.State.Guild(info.ID)
+		if info.config.Spam.LockdownDuration > 0 && len(info.config.Spam.RaidLockdown) > 0 {
+			if info.config.Spam.ActiveLockdown == nil { // Only engage lockdown if it wasn't already engaged
+				lines, err := EngageLockdown(info, info.config.Spam.RaidLockdown, time.Duration(info.config.Spam.LockdownDuration)*time.Second)
 				if err != nil {
-					info.lockdown = discordgo.VerificationLevelHigh
+					info.SendMessage(ch, "Could not engage lockdown! "+err.Error()+". Change the lockdown level via `"+info.config.Basic.CommandPrefix+"setconfig spam.raidlockdown <level>`, or disable the lockdown entirely via `"+info.config.Basic.CommandPrefix+"setconfig spam.lockdownduration 0`.")
 				} else {
-					info.lockdown = guild.VerificationLevel
-				}
-				level := discordgo.VerificationLevelHigh
-				g := discordgo.GuildParams{"", "", &level, 0, "", 0, "", "", ""}
-				_, err = sb.dg.GuildEdit(info.ID, g)
-				if err != nil {
-					info.SendMessage(ch, "Could not engage lockdown! Make sure you've given Sweetie Bot the Manage Server permission, or disable the lockdown entirely via `"+info.config.Basic.CommandPrefix+"setconfig spam.lockdownduration 0`.")
-				} else {
-					info.SendMessage(ch, fmt.Sprintf("Lockdown engaged! Server verification level will be reset in %v seconds. This lockdown can be manually ended via `"+info.config.Basic.CommandPrefix+"autosilence off/alert/log`.", info.config.Spam.LockdownDuration))
+					info.SendMessage(ch, fmt.Sprintf("Lockdown engaged! It will be disengaged in %v seconds. This lockdown can be manually ended via `"+info.config.Basic.CommandPrefix+"unlock`.\n", info.config.Spam.LockdownDuration)+strings.Join(lines, "\n"))
 				}
 			}
 			// Otherwise just reset the timer
-			info.lastlockdown = time.Now().UTC()
+			resetLockdownTimer(info)
 		}
 	}
 }
@@ -321,7 +933,7 @@ func (w *SpamModule) checkRaid(info *GuildInfo, m *discordgo.Member) {
 // OnGuildMemberAdd discord hook
 func (w *SpamModule) OnGuildMemberAdd(info *GuildInfo, m *discordgo.Member) {
 	created := "(Created " + TimeDiff(time.Now().UTC().Sub(snowflakeTime(SBatoi(m.User.ID)))) + " ago)"
-	if info.config.Spam.AutoSilence >= 2 || (info.config.Spam.AutoSilence >= 1 && w.lastraid+info.config.Spam.RaidTime*2 > time.Now().UTC().Unix()) {
+	if info.config.Spam.AutoSilence >= 2 || (info.config.Spam.AutoSilence >= 1 && w.lastraid+info.config.Spam.RaidTime*2 > time.Now().UTC().Unix()) || (info.config.Spam.ActiveLockdown != nil && info.config.Spam.ActiveLockdown.Silence) {
 		silenceMember(m.User, info)
 		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "<@"+m.User.ID+"> "+created+" joined the server and was autosilenced. Please vet them before unsilencing them.")
 		if len(info.config.Users.WelcomeMessage) > 0 {
@@ -353,13 +965,61 @@ func (w *SpamModule) OnGuildMemberRemove(info *GuildInfo, m *discordgo.Member) {
 		}
 	}
 }
+
+// getRaidUsers returns everyone who joined since the most recent raid began and has a raid score of at least Spam.RaidMinScore, so that !getraid, !banraid and autosilence act on the same suspects as the raid alert
 func (w *SpamModule) getRaidUsers(info *GuildInfo) []*discordgo.User {
-	return sb.db.GetRecentUsers(time.Unix(w.lastraid-info.config.Spam.RaidTime, 0).UTC(), SBatoi(info.ID))
+	r := []*discordgo.User{}
+	for _, v := range scoreRaidSuspects(info, sb.db.GetRecentUsers(time.Unix(w.lastraid-info.config.Spam.RaidTime, 0).UTC(), SBatoi(info.ID))) {
+		if v.Score >= info.config.Spam.RaidMinScore {
+			r = append(r, v.User)
+		}
+	}
+	return r
 }
 func (w *SpamModule) isRecentRaid(info *GuildInfo) bool {
 	return w.lastraid+info.config.Spam.RaidTime*2 > time.Now().UTC().Unix()
 }
 
+// inRecentRaid returns true if the user is part of the most recent raid, which means !banraid would ban them
+func (w *SpamModule) inRecentRaid(user uint64, info *GuildInfo) bool {
+	if !w.isRecentRaid(info) {
+		return false
+	}
+	for _, v := range w.getRaidUsers(info) {
+		if SBatoi(v.ID) == user {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentPressure returns a user's spam pressure, accounting for how much it has decayed since their last message
+func (w *SpamModule) CurrentPressure(user uint64, info *GuildInfo) float32 {
+	w.Lock()
+	defer w.Unlock()
+	track, ok := w.tracker[user]
+	if !ok {
+		return 0
+	}
+	now := time.Now().UTC()
+	interval := now.Unix()*1000 + int64(now.Nanosecond()/1000000) - track.lastmessage
+	pressure := track.pressure - info.config.Spam.BasePressure*(float32(interval)/(info.config.Spam.PressureDecay*1000.0))
+	if pressure < 0 {
+		return 0
+	}
+	return pressure
+}
+
+// getSpamModule returns the anti-spam module of a guild
+func getSpamModule(info *GuildInfo) *SpamModule {
+	for _, v := range info.modules {
+		if s, ok := v.(*SpamModule); ok {
+			return s
+		}
+	}
+	return nil
+}
+
 type autoSilenceCommand struct {
 	s *SpamModule
 }
@@ -394,7 +1054,7 @@ func (c *autoSilenceCommand) Process(args []string, msg *discordgo.Message, indi
 	if info.config.Spam.AutoSilence <= 0 {
 		DisableLockdown(info)
 	} else if c.s.isRecentRaid(info) { // If there has recently been a raid, silence everyone who joined or theoretically could have joined since the beginning of the raid.
-		info.lastlockdown = time.Now().UTC() // Reset lockdown timer just in case
+		resetLockdownTimer(info) // Reset lockdown timer just in case
 		if !sb.db.CheckStatus() {
 			return "```Autosilence was engaged, but a database error prevents me from retroactively applying it!```", false, nil
 		}
@@ -519,15 +1179,32 @@ func (c *getPressureCommand) Process(args []string, msg *discordgo.Message, indi
 
 	c.s.Lock()
 	u, ok := c.s.tracker[IDs[0]]
-	c.s.Unlock()
 	if !ok {
+		c.s.Unlock()
 		return "0", false, nil
 	}
-	return fmt.Sprint(u.pressure), false, nil
+	pressure := u.pressure
+	history := append([]pressureEvent{}, u.history...)
+	c.s.Unlock()
+
+	tz := getTimezone(info, msg.Author)
+	lines := make([]string, 0, len(history)+2)
+	lines = append(lines, fmt.Sprintf("Current pressure: %.2f (max: %v)", pressure, info.config.Spam.MaxPressure))
+	lines = append(lines, "    Time  Channel          Decay   Base Length  Lines  Pings Images Repeat  Dupes  Total")
+	for _, v := range history {
+		chname := v.channel
+		if ch, err := sb.dg.State.Channel(v.channel); err == nil {
+			chname = ch.Name
+		}
+		chname = truncateString(chname, 15)
+		tm := time.Unix(v.timestamp/1000, 0).In(tz)
+		lines = append(lines, fmt.Sprintf("%s  #%-15s %6.2f %6.2f %6.2f %6.2f %6.2f %6.2f %6.2f %6.2f %6.2f", tm.Format("15:04:05"), chname, -v.decay, v.parts.base, v.parts.length, v.parts.lines, v.parts.pings, v.parts.images, v.parts.repeat, v.parts.duplicate, v.pressure))
+	}
+	return "```\n" + strings.Join(lines, "\n") + "```", len(lines) > 8, nil
 }
 func (c *getPressureCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Restricted command that gets the current spam pressure of a user.",
+		Desc: fmt.Sprintf("Restricted command that gets the current spam pressure of a user, followed by a timeline of the last %v messages they sent, showing how much pressure decayed before each message, how much pressure each part of the message contributed, and the total pressure after the message was sent.", maxPressureHistory),
 		Params: []CommandUsageParam{
 			{Name: "user", Desc: "User to retrieve pressure from.", Optional: false},
 		},
@@ -535,6 +1212,169 @@ func (c *getPressureCommand) Usage(info *GuildInfo) *CommandUsage {
 }
 func (c *getPressureCommand) UsageShort() string { return "[RESTRICTED] Gets user's spam pressure." }
 
+type spamSimResult struct {
+	id       uint64
+	peak     float32
+	count    int
+	silenced time.Time
+}
+
+type spamSimResults []*spamSimResult
+
+func (s spamSimResults) Len() int {
+	return len(s)
+}
+func (s spamSimResults) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+func (s spamSimResults) Less(i, j int) bool {
+	return s[i].peak > s[j].peak
+}
+
+type simulateSpamCommand struct {
+}
+
+func (c *simulateSpamCommand) Name() string {
+	return "SimulateSpam"
+}
+func (c *simulateSpamCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	config := info.config // Copy the config so we can override options without touching the real one
+	spam := reflect.ValueOf(&config.Spam).Elem()
+	maxmessages := 0
+	names := []string{}
+	overrides := []string{}
+	for _, arg := range args {
+		if kv := strings.SplitN(arg, ":", 2); len(kv) == 2 {
+			key := strings.TrimPrefix(strings.ToLower(kv[0]), "spam.")
+			found := false
+			for i := 0; i < spam.NumField(); i++ {
+				if strings.ToLower(spam.Type().Field(i).Name) == key && spam.Field(i).Kind() == reflect.Float32 {
+					f, err := strconv.ParseFloat(kv[1], 32)
+					if err != nil {
+						return "```Error: " + kv[1] + " is not a number.```", false, nil
+					}
+					spam.Field(i).SetFloat(f)
+					overrides = append(overrides, fmt.Sprintf("%s: %v", spam.Type().Field(i).Name, float32(f)))
+					found = true
+				}
+			}
+			if !found {
+				return "```Error: " + kv[0] + " is not a spam pressure option. Only options like Spam.MaxPressure or Spam.PressureDecay can be simulated.```", false, nil
+			}
+		} else if n, err := strconv.Atoi(arg); err == nil && maxmessages == 0 && n > 0 {
+			maxmessages = n
+		} else {
+			names = append(names, arg)
+		}
+	}
+
+	var user *uint64
+	if len(names) > 0 {
+		arg := strings.Join(names, " ")
+		IDs := FindUsername(arg, info)
+		if len(IDs) == 0 { // no matches!
+			return "```Error: Could not find any usernames or aliases matching " + arg + "!```", false, nil
+		}
+		if len(IDs) > 1 {
+			return "```Could be any of the following users or their aliases:\n" + strings.Join(IDsToUsernames(IDs, info, true), "\n") + "```", len(IDs) > 5, nil
+		}
+		user = &IDs[0]
+	}
+	if maxmessages == 0 {
+		maxmessages = 500
+		if user != nil {
+			maxmessages = 50
+		}
+	}
+	if maxmessages > 10000 {
+		maxmessages = 10000
+	}
+
+	messages := sb.db.GetChatlog(SBatoi(info.ID), user, maxmessages)
+	if len(messages) == 0 {
+		return "```There are no logged messages to simulate.```", false, nil
+	}
+	tracker := make(map[uint64]*userPressure)
+	results := make(map[uint64]*spamSimResult)
+	order := spamSimResults{}
+	ignored := make(map[uint64]bool)
+	for _, v := range messages {
+		if v.Author == SBatoi(sb.SelfID) || ignored[v.Author] {
+			continue
+		}
+		r, ok := results[v.Author]
+		if !ok {
+			uid := SBitoa(v.Author)
+			if (info.config.Basic.AlertRole != 0 && info.UserHasRole(uid, SBitoa(info.config.Basic.AlertRole))) ||
+				(info.config.Spam.IgnoreRole != 0 && info.UserHasRole(uid, SBitoa(info.config.Spam.IgnoreRole))) {
+				ignored[v.Author] = true
+				continue
+			}
+			r = &spamSimResult{id: v.Author}
+			results[v.Author] = r
+			order = append(order, r)
+			tracker[v.Author] = &userPressure{0, int64((v.ID >> 22) + DiscordEpoch), "", nil}
+		}
+		if !r.silenced.IsZero() {
+			continue // Silenced users can't send any more messages
+		}
+		track := tracker[v.Author]
+		tm := int64((v.ID >> 22) + DiscordEpoch) // Message IDs are much more precise than the chatlog timestamps
+		p := getContentPressure(&config, v.Message, strings.Count(v.Message, "@"), strings.Count(v.Message, "http://")+strings.Count(v.Message, "https://"))
+		if _, valid := track.addPressure(&config, p, v.Message, SBitoa(v.Channel), tm); !valid {
+			continue
+		}
+		r.count++
+		if track.pressure > r.peak {
+			r.peak = track.pressure
+		}
+		if track.pressure > config.Spam.MaxPressure {
+			r.silenced = snowflakeTime(v.ID)
+		}
+	}
+
+	sort.Stable(order)
+	if len(overrides) == 0 {
+		overrides = append(overrides, "current settings")
+	}
+	lines := []string{fmt.Sprintf("Simulated %v messages from %v users using %s.", len(messages), len(order), strings.Join(overrides, ", "))}
+	silenced := 0
+	for _, v := range order {
+		if !v.silenced.IsZero() {
+			silenced++
+		}
+	}
+	lines = append(lines, Pluralize(int64(silenced), " user")+" would have been silenced.")
+	for i, v := range order {
+		if i >= 20 {
+			lines = append(lines, fmt.Sprintf("...and %v more.", len(order)-i))
+			break
+		}
+		status := ""
+		if !v.silenced.IsZero() {
+			status = " [SILENCED " + ApplyTimezone(v.silenced, info, msg.Author).Format(time.RFC822) + "]"
+		}
+		lines = append(lines, fmt.Sprintf("%s: peak pressure %.2f over %s%s", IDsToUsernames([]uint64{v.id}, info, false)[0], v.peak, Pluralize(int64(v.count), " message"), status))
+	}
+	return "```\n" + strings.Join(lines, "\n") + "```", len(lines) > 8, nil
+}
+func (c *simulateSpamCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Replays messages from the chat log through the anti-spam system using alternative pressure settings, and reports who would have been silenced. Use this to tune options like `Spam.MaxPressure` and `Spam.PressureDecay`. Because the chat log doesn't store attachments, pings and images are estimated from the message text. Example: `" + info.config.Basic.CommandPrefix + "simulatespam 1000 maxpressure:80 pressuredecay:2`",
+		Params: []CommandUsageParam{
+			{Name: "user", Desc: "If provided, only replays messages sent by this user. Otherwise, replays messages from everyone.", Optional: true},
+			{Name: "messages", Desc: "Number of recent messages to replay. Defaults to 50 for a single user, or 500 for everyone.", Optional: true},
+			{Name: "option:value", Desc: "Overrides a spam pressure option for the simulation, like `maxpressure:80`. Options that aren't overridden use their current values.", Optional: true, Variadic: true},
+		},
+	}
+}
+func (c *simulateSpamCommand) UsageShort() string {
+	return "Simulates anti-spam with different settings."
+}
+
 type getRaidCommand struct {
 	s *SpamModule
 }
@@ -553,7 +1393,7 @@ func (c *getRaidCommand) Process(args []string, msg *discordgo.Message, indices
 	return "```" + strings.Join(s, "\n") + "```", false, nil
 }
 func (c *getRaidCommand) Usage(info *GuildInfo) *CommandUsage {
-	return &CommandUsage{Desc: "Lists all users that are considered part of the most recent raid, if there was one."}
+	return &CommandUsage{Desc: "Lists all users that are considered part of the most recent raid, if there was one. Only users who joined since the raid began and have a raid score of at least `Spam.RaidMinScore` are included, see `" + info.config.Basic.CommandPrefix + "raidreport`."}
 }
 func (c *getRaidCommand) UsageShort() string { return "Lists users in most recent raid." }
 
@@ -579,3 +1419,154 @@ func (c *banRaidCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{Desc: "Bans all users that are considered part of the most recent raid, if there was one. Use !getraid to check who will be banned before using this command."}
 }
 func (c *banRaidCommand) UsageShort() string { return "Bans all users in most recent raid." }
+
+type raidReportCommand struct {
+	s *SpamModule
+}
+
+func (c *raidReportCommand) Name() string {
+	return "RaidReport"
+}
+func (c *raidReportCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	lookback := info.config.Spam.RaidTime
+	if c.s.isRecentRaid(info) {
+		lookback = time.Now().UTC().Unix() - c.s.lastraid + info.config.Spam.RaidTime
+	}
+	if len(args) > 0 {
+		var err error
+		lookback, err = strconv.ParseInt(args[0], 10, 64)
+		if err != nil || lookback <= 0 {
+			return "```Error: " + args[0] + " is not a valid number of seconds.```", false, nil
+		}
+	}
+	n := sb.db.CountNewUsers(lookback, SBatoi(info.ID))
+	if n == 0 {
+		return fmt.Sprintf("```No one has joined in the past %s.```", TimeDiff(time.Duration(lookback)*time.Second)), false, nil
+	}
+	suspects := scoreRaidSuspects(info, sb.db.GetNewestUsers(n, SBatoi(info.ID)))
+	sort.Stable(raidSuspects(suspects))
+
+	count := 0
+	for _, v := range suspects {
+		if v.Score >= info.config.Spam.RaidMinScore {
+			count++
+		}
+	}
+	lines := []string{fmt.Sprintf("%s joined in the past %s, %v of which scored at least %v (Spam.RaidMinScore):", Pluralize(int64(n), " user"), TimeDiff(time.Duration(lookback)*time.Second), count, info.config.Spam.RaidMinScore)}
+	for _, v := range suspects {
+		flag := "  "
+		if v.Score >= info.config.Spam.RaidMinScore {
+			flag = "! "
+		}
+		reasons := strings.Join(v.Reasons, ", ")
+		if len(reasons) == 0 {
+			reasons = "nothing suspicious"
+		}
+		lines = append(lines, fmt.Sprintf("%s%.2f  %s#%s (joined %s ago): %s", flag, v.Score, v.User.Username, v.User.Discriminator, TimeDiff(time.Now().UTC().Sub(v.Joined)), reasons))
+	}
+	return "```\n" + PartialSanitize(strings.Join(lines, "\n")) + "```", len(lines) > 6, nil
+}
+func (c *raidReportCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Lists everyone who joined recently along with their raid score and the reasons for it. Each user gets up to 1 point for having a new account (see `Spam.RaidAccountAge`), 1 point for a default avatar, 1 point for a username similar to someone else who joined, and up to 1 point for joining at the same time as other users (see `Spam.RaidBurstTime`). A raid is only detected if at least `Spam.RaidSize` users with a score of at least `Spam.RaidMinScore` join within `Spam.RaidTime` seconds.",
+		Params: []CommandUsageParam{
+			{Name: "seconds", Desc: "How many seconds to look back. Defaults to the most recent raid, or `Spam.RaidTime` if there hasn't been one recently.", Optional: true},
+		},
+	}
+}
+func (c *raidReportCommand) UsageShort() string { return "Lists raid scores of recent joins." }
+
+type lockdownCommand struct {
+}
+
+func (c *lockdownCommand) Name() string {
+	return "Lockdown"
+}
+func (c *lockdownCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 1 {
+		lines := []string{}
+		if info.config.Spam.ActiveLockdown != nil {
+			if info.config.Spam.ActiveLockdown.Duration > 0 {
+				lines = append(lines, fmt.Sprintf("The %s lockdown is currently engaged and will be disengaged in %s.", info.config.Spam.ActiveLockdown.Level, TimeDiff(info.config.Spam.ActiveLockdown.Duration-time.Now().UTC().Sub(info.config.Spam.ActiveLockdown.Engaged))))
+			} else {
+				lines = append(lines, fmt.Sprintf("The %s lockdown is currently engaged until someone uses %sunlock.", info.config.Spam.ActiveLockdown.Level, info.config.Basic.CommandPrefix))
+			}
+		}
+		levels := getLockdownLevels(info)
+		if len(levels) == 0 {
+			return "```No lockdown levels have been configured. Add one via " + info.config.Basic.CommandPrefix + "setconfig spam.lockdownlevels <level> <actions...>```", false, nil
+		}
+		lines = append(lines, "Lockdown levels:")
+		for _, v := range levels {
+			lines = append(lines, fmt.Sprintf("  %s: %s", v, describeLockdownActions(info.config.Spam.LockdownLevels[v])))
+		}
+		return "```\n" + strings.Join(lines, "\n") + "```", false, nil
+	}
+
+	var duration time.Duration
+	if len(args) > 1 {
+		d, err := strconv.Atoi(args[1])
+		if err != nil || d <= 0 {
+			return "```Duration must be a positive number, like '30 minutes'.```", false, nil
+		}
+		unit := time.Second
+		if len(args) > 2 {
+			switch parseRepeatInterval(args[2]) {
+			case 1:
+				unit = time.Second
+			case 2:
+				unit = time.Minute
+			case 3:
+				unit = time.Hour
+			case 4:
+				unit = time.Hour * 24
+			default:
+				return "```Unknown duration type! Acceptable types are seconds, minutes, hours, and days.```", false, nil
+			}
+		}
+		duration = time.Duration(d) * unit
+	}
+
+	lines, err := EngageLockdown(info, args[0], duration)
+	if err != nil {
+		return "```" + err.Error() + "```", false, nil
+	}
+	if duration > 0 {
+		lines = append([]string{"Lockdown engaged for " + TimeDiff(duration) + "."}, lines...)
+	} else {
+		lines = append([]string{"Lockdown engaged until someone uses " + info.config.Basic.CommandPrefix + "unlock."}, lines...)
+	}
+	return "```\n" + strings.Join(lines, "\n") + "```", false, nil
+}
+func (c *lockdownCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Engages the given lockdown level, which can raise the server verification level, set slowmode on all channels in `Spam.SlowmodeChannels`, stop @everyone from sending messages in `Spam.LockdownChannels`, pause all invite links, and silence everyone who joins, depending on how the level was configured in `Spam.LockdownLevels`. Everything is restored when the lockdown ends. If no level is given, lists all lockdown levels and whether one is engaged.",
+		Params: []CommandUsageParam{
+			{Name: "level", Desc: "Name of the lockdown level to engage.", Optional: true},
+			{Name: "duration", Desc: "How long the lockdown should last, like `30 minutes`. If omitted, the lockdown lasts until someone uses `" + info.config.Basic.CommandPrefix + "unlock`.", Optional: true},
+		},
+	}
+}
+func (c *lockdownCommand) UsageShort() string { return "Engages a lockdown." }
+
+type unlockCommand struct {
+}
+
+func (c *unlockCommand) Name() string {
+	return "Unlock"
+}
+func (c *unlockCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if info.config.Spam.ActiveLockdown == nil {
+		return "```There is no lockdown engaged.```", false, nil
+	}
+	return "```\n" + strings.Join(disableLockdown(info), "\n") + "```", false, nil
+}
+func (c *unlockCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Disengages the current lockdown, restoring everything it changed.",
+	}
+}
+func (c *unlockCommand) UsageShort() string { return "Disengages the current lockdown." }
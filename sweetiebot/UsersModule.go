@@ -22,6 +22,7 @@ func (w *UsersModule) Name() string {
 func (w *UsersModule) Commands() []Command {
 	return []Command{
 		&newUsersCommand{},
+		&joinStatsCommand{},
 		&akaCommand{},
 		&banCommand{},
 		&banNewcomersCommand{},
@@ -31,6 +32,8 @@ func (w *UsersModule) Commands() []Command {
 		&defaultServerCommand{},
 		&silenceCommand{},
 		&unsilenceCommand{},
+		&appealCommand{},
+		&myDataCommand{},
 	}
 }
 
@@ -113,11 +116,64 @@ func (c *akaCommand) Process(args []string, msg *discordgo.Message, indices []in
 	if len(u.Nick) > 0 {
 		nick = u.Nick + " (" + nick + ")"
 	}
-	return fmt.Sprintf("```All known aliases for %s [%s]\n  %s```", nick, u.User.ID, PartialSanitize(strings.Join(r, "\n  "))), false, nil
+	history := sb.db.GetNameHistory(IDs[0], SBatoi(info.ID), 10)
+	if len(history) == 0 {
+		return fmt.Sprintf("```All known aliases for %s [%s]\n  %s```", nick, u.User.ID, PartialSanitize(strings.Join(r, "\n  "))), false, nil
+	}
+	changes := make([]string, 0, len(history))
+	for _, v := range history {
+		changes = append(changes, fmt.Sprintf("[%s] %s", ApplyTimezone(v.Timestamp, info, msg.Author).Format("Jan 2 2006 3:04PM"), describeNameChange(v)))
+	}
+	return fmt.Sprintf("```All known aliases for %s [%s]\n  %s\nRecent changes:\n  %s```", nick, u.User.ID, PartialSanitize(strings.Join(r, "\n  ")), PartialSanitize(strings.Join(changes, "\n  "))), false, nil
+}
+
+func describeNameChange(c NameChange) string {
+	none := func(s string) string {
+		if len(s) == 0 {
+			return "(none)"
+		}
+		return s
+	}
+	switch c.Type {
+	case NAMELOG_USERNAME:
+		return "Username: " + c.Old + " -> " + c.New
+	case NAMELOG_NICKNAME:
+		return "Nickname: " + none(c.Old) + " -> " + none(c.New)
+	case NAMELOG_AVATAR:
+		return "Changed avatar"
+	}
+	return "Unknown change"
+}
+
+// notifySilencedRename tells the moderators if a silenced member changed their username or nickname. This has to be called before the member is saved to the database.
+func notifySilencedRename(info *GuildInfo, m *discordgo.Member) {
+	silenced := false
+	for _, r := range m.Roles {
+		if SBatoi(r) == info.config.Spam.SilentRole {
+			silenced = true
+		}
+	}
+	if !silenced || !sb.db.CheckStatus() {
+		return
+	}
+	old, _, _ := sb.db.GetMember(SBatoi(m.User.ID), SBatoi(info.ID))
+	if old == nil {
+		return
+	}
+	changes := []string{}
+	if len(m.User.Username) > 0 && m.User.Username != old.User.Username {
+		changes = append(changes, describeNameChange(NameChange{Type: NAMELOG_USERNAME, Old: old.User.Username, New: m.User.Username}))
+	}
+	if m.Nick != old.Nick {
+		changes = append(changes, describeNameChange(NameChange{Type: NAMELOG_NICKNAME, Old: old.Nick, New: m.Nick}))
+	}
+	if len(changes) > 0 {
+		info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Silenced user <@"+m.User.ID+"> changed their name. ```"+PartialSanitize(strings.Join(changes, "\n"))+"```")
+	}
 }
 func (c *akaCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Lists all known aliases of the user in question, up to a maximum of 10, with the names used the longest first.",
+		Desc: "Lists all known aliases of the user in question, up to a maximum of 10, with the names used the longest first, followed by when they last changed their username, nickname or avatar.",
 		Params: []CommandUsageParam{
 			{Name: "user", Desc: "A ping of the user, or simply their name.", Optional: true},
 		},
@@ -129,35 +185,29 @@ func ProcessDurationAndReason(args []string, msg *discordgo.Message, indices []i
 	reason := ""
 	if len(args) > 0 {
 		if strings.ToLower(args[0]) == "for:" {
-			if len(args) < 3 {
-				return "", "```Error: Duration should be specified as 'for: 5 DAYS' or 'for: 72 HOURS'```"
-			}
-			duration, err := strconv.Atoi(args[1])
-			if err != nil {
-				return "", "```Error: Duration number was not an integer.```"
+			if len(args) < 2 {
+				return "", "```Error: Duration should be specified as 'for: 5 DAYS', 'for: 72 HOURS' or 'for: 3d12h'```"
 			}
 
 			t := time.Now().UTC()
-			switch parseRepeatInterval(args[2]) {
-			case 1:
-				t = t.Add(time.Duration(duration) * time.Second)
-			case 2:
-				t = t.Add(time.Duration(duration) * time.Minute)
-			case 3:
-				t = t.Add(time.Duration(duration) * time.Hour)
-			case 4:
-				t = t.AddDate(0, 0, duration)
-			case 5:
-				t = t.AddDate(0, 0, duration*7)
-			case 6:
-				t = t.AddDate(0, duration, 0)
-			case 8:
-				t = t.AddDate(duration, 0, 0)
-			case 7, 255:
-				return "", "```Error: unrecognized interval.```"
+			reasonindex := 2
+			if duration, err := strconv.Atoi(args[1]); err == nil && len(args) > 2 {
+				var ok bool
+				if t, ok = addInterval(t, duration, parseDurationUnit(strings.ToLower(args[2]))); !ok {
+					return "", "```Error: unrecognized interval.```"
+				}
+				reasonindex = 3
+			} else {
+				var err error
+				if t, err = addDuration(t, args[1], 1); err != nil {
+					return "", "```Error: " + err.Error() + ". Duration should be specified as 'for: 5 DAYS', 'for: 72 HOURS' or 'for: 3d12h'```"
+				}
+			}
+			if !t.After(time.Now().UTC()) {
+				return "", "```Error: Duration must be positive.```"
 			}
 
-			if !sb.db.AddSchedule(gID, t, ty, uID) {
+			if !sb.db.AddSchedule(gID, t, ty, EncodeSchedulePayload(ScheduleUserPayload{uID})) {
 				return "", "```Error: servers can't have more than 5000 events!```"
 			}
 
@@ -166,8 +216,8 @@ func ProcessDurationAndReason(args []string, msg *discordgo.Message, indices []i
 				return "", "```Error: Could not find inserted event!```"
 			}
 
-			if len(args) > 3 {
-				reason = msg.Content[indices[3]:]
+			if len(args) > reasonindex {
+				reason = msg.Content[indices[reasonindex]:]
 			}
 		} else {
 			reason = msg.Content[indices[0]:]
@@ -208,14 +258,17 @@ func (c *banCommand) Process(args []string, msg *discordgo.Message, indices []in
 		return "```Error: User does not exist!```", false, nil
 	}
 	uID := SBitoa(IDs[0])
-	reason, e := ProcessDurationAndReason(args[1:], msg, indices[1:], 0, uID, gID)
+	reason, e := ProcessDurationAndReason(args[1:], msg, indices[1:], SCHEDULE_UNBAN, uID, gID)
 	if len(e) > 0 {
 		return e, false, nil
 	}
 
 	fmt.Printf("Banned %s because: %s\n", u.Username, reason)
+	// This has to happen before the ban, because discord won't let us PM someone we no longer share a server with, so we retract it if the ban fails
+	appealID, appealMsg := OpenAppeal(info, uID, APPEAL_TYPE_BAN, reason)
 	err := sb.dg.GuildBanCreate(info.ID, uID, 1) // Note that this will probably generate a SawBan event
 	if err != nil {
+		CancelAppeal(info, appealID, appealMsg)
 		return "```Error: " + err.Error() + "```", false, nil
 	}
 	return "```Banned " + u.Username + " from the server. Harmony restored.```", false, nil
@@ -225,7 +278,7 @@ func (c *banCommand) Usage(info *GuildInfo) *CommandUsage {
 		Desc: "Bans the given user. Examples: `'" + info.config.Basic.CommandPrefix + "ban @CrystalFlash for: 5 MINUTES because he's a dunce` or `" + info.config.Basic.CommandPrefix + "ban \"Name With Spaces\" caught stealing cookies`",
 		Params: []CommandUsageParam{
 			{Name: "user", Desc: "A ping of the user, or simply their name. If the name has spaces, this argument must be put in quotes.", Optional: false},
-			{Name: "for: duration", Desc: "If the keyword `for:` is used after the username, looks for a duration of the form `for: 50 MINUTES` and creates an unban event that will be fired after that much time has passed from now.", Optional: true},
+			{Name: "for: duration", Desc: "If the keyword `for:` is used after the username, looks for a duration of the form `for: 50 MINUTES` or `for: 3d12h` and creates an unban event that will be fired after that much time has passed from now.", Optional: true},
 			{Name: "reason", Desc: "The rest of the message is treated as a reason for the ban (currently not saved anywhere).", Optional: true},
 		},
 	}
@@ -454,6 +507,9 @@ func (c *userInfoCommand) Process(args []string, msg *discordgo.Message, indices
 	if firstmessage != nil {
 		firstmessagestring = fmt.Sprintf("%s ago (%v)", TimeDiff(time.Now().UTC().Sub(firstmessage.In(authortz))), firstmessage.In(authortz).Format(time.RFC822))
 	}
+	if info.config.Basic.AlertRole != 0 && info.UserHasRole(msg.Author.ID, SBitoa(info.config.Basic.AlertRole)) {
+		return "", false, userInfoEmbed(m, fullusername, aliases, roles, tz, lastseenstring, firstmessagestring, info, authortz)
+	}
 	s := fmt.Sprintf("        ID: %v\n  Username: %s\n  Nickname: %v\n   Aliases: %v\n     Roles: %v\n  Timezone: %v\nLocal Time: %v\n   Created: %s ago (%v)\n    Joined: %s\n Last Seen: %s\nFirst Msg: %s\n    Avatar: ",
 		m.User.ID,
 		fullusername,
@@ -472,9 +528,104 @@ func (c *userInfoCommand) Process(args []string, msg *discordgo.Message, indices
 	//s := fmt.Sprintf("**ID:** %v\n**Username:** %s\n**Nickname:** %v\n**Timezone:** %v\n**Local Time:** %v\n**Created:** %s ago (%v)\n **Joined:** %s\n**Roles:** %v\n**Last Seen:** %s ago (%v)\n**Aliases:** %v\n**Avatar:** %s", m.User.ID, fullusername, m.Nick, tz, localtime, TimeDiff(time.Now().UTC().Sub(created)), created.Format(time.RFC822), joined, strings.Join(roles, ", "), TimeDiff(time.Now().UTC().Sub(lastseen.In(authortz))), lastseen.In(authortz).Format(time.RFC822), strings.Join(aliases, ", "), discordgo.EndpointUserAvatar(m.User.ID, m.User.Avatar))
 	//return SanitizeMentions(PartialSanitize(s)), false, nil
 }
+
+// userInfoEmbed builds the moderator view of !userinfo, which adds moderation context to the basic information
+func userInfoEmbed(m *discordgo.Member, fullusername string, aliases []string, roles []string, tz *time.Location, lastseen string, firstmessage string, info *GuildInfo, authortz *time.Location) *discordgo.MessageEmbed {
+	uID := SBatoi(m.User.ID)
+	gID := SBatoi(info.ID)
+	orNone := func(s string) string {
+		if len(s) == 0 {
+			return "None"
+		}
+		return s
+	}
+	ago := func(t time.Time) string {
+		return TimeDiff(time.Now().UTC().Sub(t)) + " ago (" + t.In(authortz).Format(time.RFC822) + ")"
+	}
+
+	created := snowflakeTime(uID)
+	joined := "Unknown"
+	if joinedat, err := time.Parse(time.RFC3339, m.JoinedAt); err == nil {
+		joined = ago(joinedat) + "\n" + TimeDiff(joinedat.Sub(created)) + " after account creation"
+	}
+
+	lastmessage := "Not logged"
+	recent := "Not logged"
+	if sb.IsDBGuild(info) {
+		lastmessage = "None logged"
+		if last := sb.db.GetLastMessage(uID, gID); last != nil {
+			lastmessage = ago(*last)
+		}
+		count := uint64(0)
+		for _, v := range sb.db.GetActivity(gID, 0, uID, time.Now().UTC().AddDate(0, 0, -7)) {
+			count += v.Count
+		}
+		recent = strconv.FormatUint(count, 10)
+	}
+
+	pressure := "0"
+	spam := getSpamModule(info)
+	if spam != nil {
+		pressure = fmt.Sprintf("%.1f / %v", spam.CurrentPressure(uID, info), info.config.Spam.MaxPressure)
+	}
+
+	silenced := "No"
+	if info.config.Spam.SilentRole != 0 && info.UserHasRole(m.User.ID, SBitoa(info.config.Spam.SilentRole)) {
+		silenced = "Yes, indefinitely"
+		if unsilence := sb.db.GetUnsilenceDate(gID, uID); unsilence != nil {
+			silenced = "Yes, until " + unsilence.In(authortz).Format(time.RFC822) + " (" + TimeDiff(unsilence.Sub(time.Now().UTC())) + ")"
+		}
+	}
+
+	bans := []string{}
+	if spam != nil && spam.inRecentRaid(uID, info) {
+		bans = append(bans, "Part of the latest raid, "+info.config.Basic.CommandPrefix+"banraid will ban them")
+	}
+	if id := sb.db.FindEvent(m.User.ID, gID, SCHEDULE_UNBAN); id != nil {
+		if e := sb.db.GetEvent(*id); e != nil {
+			bans = append(bans, "Banned, will be unbanned on "+e.Date.In(authortz).Format(time.RFC822))
+		}
+	}
+	if appeal := sb.db.GetOpenAppeal(uID); appeal != nil && appeal.Guild == gID {
+		bans = append(bans, fmt.Sprintf("Appeal #%v is open: %s", appeal.ID, appeal.Reason))
+	}
+
+	audit := []string{}
+	for _, v := range sb.db.GetAuditMentions(uID, m.User.Username, gID, 5) {
+		line := fmt.Sprintf("[%s] %s: %s", v.Timestamp.In(authortz).Format("1/2 3:04PM"), v.Author, v.Message)
+		if t := truncateString(line, 200); t != line {
+			line = t + "..."
+		}
+		audit = append(audit, line)
+	}
+
+	return &discordgo.MessageEmbed{
+		Type:      "rich",
+		Title:     fullusername,
+		Color:     0x3e92e5,
+		Thumbnail: &discordgo.MessageEmbedThumbnail{URL: discordgo.EndpointUserAvatar(m.User.ID, m.User.Avatar)},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "ID", Value: m.User.ID, Inline: true},
+			{Name: "Nickname", Value: orNone(m.Nick), Inline: true},
+			{Name: "Timezone", Value: tz.String(), Inline: true},
+			{Name: "Created", Value: ago(created), Inline: true},
+			{Name: "Joined", Value: joined, Inline: true},
+			{Name: "Last Seen", Value: lastseen, Inline: true},
+			{Name: "First Message", Value: orNone(firstmessage), Inline: true},
+			{Name: "Last Message", Value: lastmessage, Inline: true},
+			{Name: "Messages (7 days)", Value: recent, Inline: true},
+			{Name: "Spam Pressure", Value: pressure, Inline: true},
+			{Name: "Silenced", Value: silenced, Inline: true},
+			{Name: "Pending Bans", Value: orNone(strings.Join(bans, "\n")), Inline: false},
+			{Name: "Aliases", Value: orNone(strings.Join(aliases, ", ")), Inline: false},
+			{Name: "Roles", Value: orNone(strings.Join(roles, ", ")), Inline: false},
+			{Name: "Recent Audit Entries", Value: orNone(PartialSanitize(strings.Join(audit, "\n"))), Inline: false},
+		},
+	}
+}
 func (c *userInfoCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "Lists the ID, username, nickname, timezone, roles, avatar, join date, and other information about a given user.",
+		Desc: "Lists the ID, username, nickname, timezone, roles, avatar, join date, and other information about a given user. Moderators also see moderation context: account age, when they last spoke and how much, their current spam pressure, whether they are silenced or about to be banned, and recent audit log entries mentioning them.",
 		Params: []CommandUsageParam{
 			{Name: "user", Desc: "A ping of the user, or simply their name.", Optional: false},
 		},
@@ -563,7 +714,7 @@ func (c *silenceCommand) Process(args []string, msg *discordgo.Message, indices
 
 	gID := SBatoi(info.ID)
 	uID := SBitoa(IDs[0])
-	reason, e := ProcessDurationAndReason(args[index:], msg, indices[index:], 8, uID, gID)
+	reason, e := ProcessDurationAndReason(args[index:], msg, indices[index:], SCHEDULE_UNSILENCE, uID, gID)
 	if len(e) > 0 {
 		return e, false, nil
 	}
@@ -584,6 +735,7 @@ func (c *silenceCommand) Process(args []string, msg *discordgo.Message, indices
 	if len(info.config.Spam.SilenceMessage) > 0 {
 		sb.dg.ChannelMessageSend(SBitoa(info.config.Users.WelcomeChannel), "<@"+SBitoa(IDs[0])+"> "+info.config.Spam.SilenceMessage)
 	}
+	OpenAppeal(info, uID, APPEAL_TYPE_SILENCE, reason)
 	if len(reason) > 0 {
 		reason = " because " + reason
 	}
@@ -594,7 +746,7 @@ func (c *silenceCommand) Usage(info *GuildInfo) *CommandUsage {
 		Desc: "Silences the given user.",
 		Params: []CommandUsageParam{
 			{Name: "user", Desc: "A ping of the user, or simply their name.", Optional: false},
-			{Name: "for: duration", Desc: "If the keyword `for:` is used after the username, looks for a duration of the form `for: 50 MINUTES` and creates an unsilence event that will be fired after that much time has passed from now.", Optional: true},
+			{Name: "for: duration", Desc: "If the keyword `for:` is used after the username, looks for a duration of the form `for: 50 MINUTES` or `for: 3d12h` and creates an unsilence event that will be fired after that much time has passed from now.", Optional: true},
 		},
 	}
 }
@@ -634,3 +786,173 @@ func (c *unsilenceCommand) Usage(info *GuildInfo) *CommandUsage {
 	}
 }
 func (c *unsilenceCommand) UsageShort() string { return "Unsilences a user." }
+
+// OpenAppeal creates a new appeal case for a punished user and sends them a PM explaining how to appeal it. Returns the case ID and the PM
+// that was sent, so the caller can retract both with CancelAppeal if the punishment fails. Returns 0 if no case was opened.
+func OpenAppeal(info *GuildInfo, userID string, ty uint8, reason string) (uint64, *discordgo.Message) {
+	if !info.config.Users.Appeals || !sb.db.CheckStatus() {
+		return 0, nil
+	}
+	id := sb.db.AddAppeal(SBatoi(info.ID), SBatoi(userID), ty, reason)
+	if id == 0 {
+		return 0, nil
+	}
+	ch, err := sb.dg.UserChannelCreate(userID)
+	info.LogError("Error opening private channel: ", err)
+	if err != nil {
+		return id, nil
+	}
+	action := "silenced"
+	if ty == APPEAL_TYPE_BAN {
+		action = "banned"
+	}
+	if len(reason) > 0 {
+		reason = " Reason given: " + reason
+	}
+	m, err := sb.dg.ChannelMessageSend(ch.ID, fmt.Sprintf("You have been %s on %s. Your case number is **#%v**.%s\n\nIf you believe this was a mistake, reply to this message with a single message explaining why, and it will be forwarded to the moderators as your appeal.", action, info.Name, id, reason))
+	if err != nil {
+		return id, nil
+	}
+	return id, m
+}
+
+// CancelAppeal deletes an appeal case opened by OpenAppeal and retracts the PM, for when the punishment it was opened for didn't go through.
+func CancelAppeal(info *GuildInfo, id uint64, m *discordgo.Message) {
+	if m != nil {
+		info.LogError("Error retracting appeal message: ", sb.dg.ChannelMessageDelete(m.ChannelID, m.ID))
+	}
+	if id != 0 && sb.db.CheckStatus() {
+		sb.db.RemoveAppeal(id)
+	}
+}
+
+// ReceiveAppeal checks if a private message answers an open appeal case and forwards it to the mod channel. Returns false if there was no open case.
+func ReceiveAppeal(m *discordgo.Message) bool {
+	if !sb.db.CheckStatus() {
+		return false
+	}
+	appeal := sb.db.GetOpenAppeal(SBatoi(m.Author.ID))
+	if appeal == nil {
+		return false
+	}
+	info := getGuildFromID(SBitoa(appeal.Guild))
+	if info == nil {
+		return false
+	}
+	appeal.Appeal = m.Content
+	for _, v := range m.Attachments {
+		appeal.Appeal += "\n" + v.URL
+	}
+	if t := truncateString(appeal.Appeal, 1000); t != appeal.Appeal {
+		appeal.Appeal = t + " [truncated]"
+	}
+	if sb.db.SetAppealMessage(appeal.ID, appeal.Appeal) != nil {
+		sb.dg.ChannelMessageSend(m.ChannelID, "```A temporary database outage prevented your appeal from being sent. Please try again later.```")
+		return true
+	}
+	sb.dg.ChannelMessageSend(m.ChannelID, fmt.Sprintf("```Your appeal for case #%v has been forwarded to the moderators of %s. You will be notified once they have made a decision.```", appeal.ID, info.Name))
+	info.SendEmbed(SBitoa(info.config.Basic.ModChannel), appealEmbed(appeal, m.Author, info))
+	return true
+}
+
+func appealEmbed(appeal *AppealCase, u *discordgo.User, info *GuildInfo) *discordgo.MessageEmbed {
+	action := "Silence"
+	if appeal.Type == APPEAL_TYPE_BAN {
+		action = "Ban"
+	}
+	reason := truncateString(appeal.Reason, 1000)
+	if len(reason) == 0 {
+		reason = "[None]"
+	}
+	return &discordgo.MessageEmbed{
+		Type: "rich",
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    fmt.Sprintf("%s Appeal #%v from %s#%s", action, appeal.ID, u.Username, u.Discriminator),
+			IconURL: fmt.Sprintf("https://cdn.discordapp.com/avatars/%s/%s.jpg", u.ID, u.Avatar),
+		},
+		Color: 0xe5a73e,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "User", Value: "<@" + u.ID + "> (" + u.ID + ")", Inline: true},
+			{Name: "Punished", Value: TimeDiff(time.Now().UTC().Sub(appeal.Timestamp)) + " ago", Inline: true},
+			{Name: "Reason", Value: reason, Inline: false},
+			{Name: "Appeal", Value: appeal.Appeal, Inline: false},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Use %sappeal accept %v or %sappeal deny %v", info.config.Basic.CommandPrefix, appeal.ID, info.config.Basic.CommandPrefix, appeal.ID),
+		},
+	}
+}
+
+type appealCommand struct {
+}
+
+func (c *appealCommand) Name() string {
+	return "Appeal"
+}
+func (c *appealCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 2 {
+		return "```You must specify accept or deny, followed by the case number.```", false, nil
+	}
+	accept := false
+	switch strings.ToLower(args[0]) {
+	case "accept":
+		accept = true
+	case "deny":
+	default:
+		return "```You must specify accept or deny, followed by the case number.```", false, nil
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(args[1], "#"), 10, 64)
+	if err != nil {
+		return "```Error: " + args[1] + " is not a valid case number.```", false, nil
+	}
+	appeal := sb.db.GetAppeal(id, SBatoi(info.ID))
+	if appeal == nil {
+		return fmt.Sprintf("```Error: Case #%v does not exist.```", id), false, nil
+	}
+	if appeal.Status == APPEAL_STATUS_ACCEPTED || appeal.Status == APPEAL_STATUS_DENIED {
+		return fmt.Sprintf("```Case #%v has already been closed.```", id), false, nil
+	}
+
+	status := uint8(APPEAL_STATUS_DENIED)
+	result := "denied"
+	if accept {
+		if appeal.Type == APPEAL_TYPE_BAN {
+			err = sb.dg.GuildBanDelete(info.ID, SBitoa(appeal.User))
+		} else {
+			err = UnsilenceMember(appeal.User, info)
+		}
+		if err != nil {
+			return "```Error: " + err.Error() + "```", false, nil
+		}
+		status = APPEAL_STATUS_ACCEPTED
+		result = "accepted"
+	}
+	sb.db.SetAppealStatus(id, status)
+
+	reason := ""
+	if len(args) > 2 {
+		reason = "\nModerator note: " + msg.Content[indices[2]:]
+	}
+	ch, err := sb.dg.UserChannelCreate(SBitoa(appeal.User))
+	if err == nil {
+		_, err = sb.dg.ChannelMessageSend(ch.ID, fmt.Sprintf("Your appeal for case #%v on %s has been %s.%s", id, info.Name, result, reason))
+	}
+	if err != nil {
+		return fmt.Sprintf("```Case #%v has been %s, but I couldn't notify %s.```", id, result, IDsToUsernames([]uint64{appeal.User}, info, false)[0]), false, nil
+	}
+	return fmt.Sprintf("```Case #%v has been %s and %s has been notified.```", id, result, IDsToUsernames([]uint64{appeal.User}, info, false)[0]), false, nil
+}
+func (c *appealCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Accepts or denies a ban or silence appeal. Accepting a ban appeal unbans the user, and accepting a silence appeal unsilences them. In both cases, the user is notified of the decision.",
+		Params: []CommandUsageParam{
+			{Name: "accept|deny", Desc: "Whether to accept or deny the appeal.", Optional: false},
+			{Name: "case", Desc: "The case number given in the appeal.", Optional: false},
+			{Name: "note", Desc: "An optional note that will be sent to the user along with the decision.", Optional: true},
+		},
+	}
+}
+func (c *appealCommand) UsageShort() string { return "Accepts or denies an appeal." }
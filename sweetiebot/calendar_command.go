@@ -0,0 +1,345 @@
+package sweetiebot
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/blackhole12/discordgo"
+)
+
+const calendarMaxEvents = 200       // Maximum number of events of each type that are exported
+const calendarMaxImport = 100       // Maximum number of events that can be imported at once
+const calendarMaxFileSize = 1 << 20 // Maximum size of an imported .ics file
+const calendarOccurrences = 10      // How many occurrences of a recurring event are exported
+
+var calendarClient = &http.Client{Timeout: 30 * time.Second} // Used to download imported .ics files
+
+var calendarTypes = []uint8{SCHEDULE_EPISODE, SCHEDULE_EVENT, SCHEDULE_BIRTHDAY}
+
+// escapeICS escapes a text value as required by RFC 5545
+func escapeICS(s string) string {
+	return strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r", "", "\n", "\\n").Replace(s)
+}
+
+// unescapeICS reverses escapeICS
+func unescapeICS(s string) string {
+	return strings.NewReplacer("\\\\", "\\", "\\;", ";", "\\,", ",", "\\n", "\n", "\\N", "\n").Replace(s)
+}
+
+// writeICSLine writes a content line, folding it so no line is longer than 75 bytes
+func writeICSLine(w *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		n := limit
+		for n > 1 && line[n]&0xC0 == 0x80 { // Don't split UTF-8 characters
+			n--
+		}
+		w.WriteString(line[:n] + "\r\n ")
+		line = line[n:]
+		limit = 74 // Continuation lines start with a space
+	}
+	w.WriteString(line + "\r\n")
+}
+
+// calendarSummary returns the title of an event as it should appear in a calendar
+func calendarSummary(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) string {
+	switch e.Type {
+	case SCHEDULE_BIRTHDAY:
+		p := ScheduleUserPayload{}
+		e.Payload(&p)
+		return getUserName(SBatoi(p.User), info) + "'s Birthday"
+	case SCHEDULE_EVENT:
+		p := ScheduleEventPayload{}
+		e.Payload(&p)
+		return p.Message
+	}
+	if kind, ok := info.hooks.ScheduleEvents[e.Type]; ok {
+		_, data := kind.Describe(info, e, msg)
+		return data
+	}
+	return e.Data
+}
+
+// buildCalendar exports the upcoming episodes and events of a guild, and birthdays if Schedule.CalendarBirthdays is set, as an iCalendar file. Recurring events are exported as their next few occurrences. msg decides whether episode titles are hidden, like in !schedule.
+func buildCalendar(info *GuildInfo, msg *discordgo.Message) *bytes.Buffer {
+	tz := getTimezone(info, nil)
+	now := time.Now().UTC()
+	w := &bytes.Buffer{}
+	writeICSLine(w, "BEGIN:VCALENDAR")
+	writeICSLine(w, "VERSION:2.0")
+	writeICSLine(w, "PRODID:-//Sweetie Bot//Schedule//EN")
+	writeICSLine(w, "X-WR-CALNAME:"+escapeICS(info.Name))
+	for _, ty := range calendarTypes {
+		if ty == SCHEDULE_BIRTHDAY && !info.config.Schedule.CalendarBirthdays {
+			continue
+		}
+		for _, v := range sb.db.GetEventsByType(SBatoi(info.ID), ty, calendarMaxEvents) {
+			dates := []time.Time{v.Date}
+			if c, err := parseCron(v.Cron); err == nil {
+				dates = append(dates, c.Upcoming(v.Date, tz, calendarOccurrences-1)...)
+			}
+			summary := escapeICS(calendarSummary(info, &v, msg))
+			for i, date := range dates {
+				writeICSLine(w, "BEGIN:VEVENT")
+				writeICSLine(w, fmt.Sprintf("UID:%v-%v-%v@sweetiebot", info.ID, v.ID, i))
+				writeICSLine(w, "DTSTAMP:"+now.Format("20060102T150405Z"))
+				switch ty {
+				case SCHEDULE_BIRTHDAY:
+					writeICSLine(w, "DTSTART;VALUE=DATE:"+date.In(tz).Format("20060102"))
+					writeICSLine(w, "DURATION:P1D")
+				case SCHEDULE_EPISODE:
+					writeICSLine(w, "DTSTART:"+date.UTC().Format("20060102T150405Z"))
+					writeICSLine(w, "DURATION:PT30M")
+				default:
+					writeICSLine(w, "DTSTART:"+date.UTC().Format("20060102T150405Z"))
+					writeICSLine(w, fmt.Sprintf("DURATION:PT%vS", info.config.Schedule.EventDuration))
+				}
+				writeICSLine(w, "SUMMARY:"+summary)
+				writeICSLine(w, "END:VEVENT")
+			}
+		}
+	}
+	writeICSLine(w, "END:VCALENDAR")
+	return w
+}
+
+type icsEvent struct {
+	Start   time.Time
+	Summary string
+}
+
+// parseICSTime parses the value of a DTSTART property. Floating times and dates are in the given timezone, unless a TZID parameter says otherwise.
+func parseICSTime(params string, value string, tz *time.Location) (time.Time, error) {
+	for _, p := range strings.Split(params, ";") {
+		if strings.HasPrefix(strings.ToUpper(p), "TZID=") {
+			if loc, err := time.LoadLocation(strings.Trim(p[5:], "\"")); err == nil {
+				tz = loc
+			}
+		}
+	}
+	switch {
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse("20060102T150405Z", value)
+	case len(value) == 8:
+		return time.ParseInLocation("20060102", value, tz)
+	}
+	return time.ParseInLocation("20060102T150405", value, tz)
+}
+
+// parseICS reads every VEVENT in an iCalendar file. Only the start time and summary are kept.
+func parseICS(r io.Reader, tz *time.Location) ([]icsEvent, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			lines[len(lines)-1] += line[1:] // Unfold lines that were split up
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	events := []icsEvent{}
+	var e *icsEvent
+	for _, line := range lines {
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		name, value := strings.ToUpper(line[:i]), line[i+1:]
+		params := ""
+		if j := strings.Index(name, ";"); j >= 0 {
+			name, params = name[:j], line[j+1:i]
+		}
+		switch {
+		case name == "BEGIN" && strings.ToUpper(value) == "VEVENT":
+			e = &icsEvent{}
+		case name == "END" && strings.ToUpper(value) == "VEVENT" && e != nil:
+			if !e.Start.IsZero() && len(e.Summary) > 0 {
+				events = append(events, *e)
+			}
+			e = nil
+		case name == "DTSTART" && e != nil:
+			t, err := parseICSTime(params, strings.TrimSpace(value), tz)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start time %s", value)
+			}
+			e.Start = t.UTC()
+		case name == "SUMMARY" && e != nil:
+			e.Summary = unescapeICS(value)
+		}
+	}
+	return events, nil
+}
+
+// newCalendarSecret generates the secret that is part of the URL of a guild's calendar
+func newCalendarSecret() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// calendarURL returns the address of a guild's calendar, or an empty string if the calendar server isn't running
+func calendarURL(info *GuildInfo) string {
+	if len(sb.CalendarAddr) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(sb.CalendarURL, "/") + "/calendar/" + info.ID + "/" + info.config.Schedule.CalendarSecret + ".ics"
+}
+
+// serveCalendar answers requests for /calendar/<guild>/<secret>.ics with the guild's calendar
+func serveCalendar(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.TrimPrefix(r.URL.Path, "/calendar/"), "/")
+	if len(path) != 2 || !strings.HasSuffix(path[1], ".ics") {
+		http.NotFound(w, r)
+		return
+	}
+	sb.guildsLock.RLock()
+	info, ok := sb.guilds[SBatoi(path[0])]
+	sb.guildsLock.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	info.configLock.RLock() // We aren't running inside a discord event, so a command could be changing the config right now
+	defer info.configLock.RUnlock()
+	secret := strings.TrimSuffix(path[1], ".ics")
+	if len(info.config.Schedule.CalendarSecret) == 0 || subtle.ConstantTimeCompare([]byte(secret), []byte(info.config.Schedule.CalendarSecret)) != 1 {
+		http.NotFound(w, r)
+		return
+	}
+	if !sb.db.CheckStatus() {
+		http.Error(w, "A temporary database outage is preventing the calendar from being generated.", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	buildCalendar(info, &discordgo.Message{}).WriteTo(w)
+}
+
+// startCalendarServer serves the calendars of every guild until the bot quits
+func startCalendarServer(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/calendar/", serveCalendar)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+	fmt.Println("Serving calendars on ", addr)
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Println("Calendar server stopped: ", err.Error())
+	}
+}
+
+type calendarCommand struct {
+}
+
+func (c *calendarCommand) Name() string {
+	return "Calendar"
+}
+func (c *calendarCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must specify export, import or url.```", false, nil
+	}
+	_, isOwner := sb.Owners[SBatoi(msg.Author.ID)]
+	isMod := isOwner || info.UserHasRole(msg.Author.ID, SBitoa(info.config.Basic.AlertRole))
+
+	switch strings.ToLower(args[0]) {
+	case "export":
+		if _, err := sb.dg.ChannelFileSend(msg.ChannelID, "calendar.ics", buildCalendar(info, msg)); err != nil {
+			return "```Error sending the calendar: " + err.Error() + "```", false, nil
+		}
+		return "", false, nil
+	case "import":
+		if !isMod {
+			return "```Only moderators can import events.```", false, nil
+		}
+		if len(msg.Attachments) == 0 {
+			return "```You must attach the .ics file to your message.```", false, nil
+		}
+		if msg.Attachments[0].Size > calendarMaxFileSize {
+			return fmt.Sprintf("```The file is too large, it can't be bigger than %v KB.```", calendarMaxFileSize>>10), false, nil
+		}
+		resp, err := calendarClient.Get(msg.Attachments[0].URL)
+		if err != nil {
+			return "```Error downloading the file: " + err.Error() + "```", false, nil
+		}
+		defer resp.Body.Close()
+		events, err := parseICS(io.LimitReader(resp.Body, calendarMaxFileSize), getTimezone(info, nil))
+		if err != nil {
+			return "```Error reading the calendar: " + err.Error() + "```", false, nil
+		}
+		added, skipped := 0, 0
+		now := time.Now().UTC()
+		for _, v := range events {
+			if v.Start.Before(now) || added >= calendarMaxImport {
+				skipped++
+				continue
+			}
+			if !sb.db.AddSchedule(SBatoi(info.ID), v.Start, SCHEDULE_EVENT, EncodeSchedulePayload(ScheduleEventPayload{Message: v.Summary})) {
+				return "```Imported " + Pluralize(int64(added), " event") + " before reaching the limit of 5000 events!```", false, nil
+			}
+			added++
+		}
+		return fmt.Sprintf("```Imported %s, skipped %v that were in the past or over the limit of %v per import.```", Pluralize(int64(added), " event"), skipped, calendarMaxImport), false, nil
+	case "url":
+		if !isMod {
+			return "```Only moderators can see the calendar URL.```", false, nil
+		}
+		if len(sb.CalendarAddr) == 0 {
+			return "```The calendar server isn't enabled on this bot. Use " + info.config.Basic.CommandPrefix + "calendar export instead.```", false, nil
+		}
+		reset := len(args) > 1 && strings.ToLower(args[1]) == "reset"
+		if len(info.config.Schedule.CalendarSecret) == 0 || reset {
+			secret, err := newCalendarSecret()
+			if err != nil {
+				info.LogError("Error generating calendar secret: ", err)
+				return "```Error generating a calendar URL, please try again later.```", false, nil
+			}
+			info.configLock.Lock()
+			info.config.Schedule.CalendarSecret = secret
+			info.configLock.Unlock()
+			info.SaveConfig()
+		}
+		ch, err := sb.dg.UserChannelCreate(msg.Author.ID)
+		if err != nil {
+			return "```Error opening a private channel: " + err.Error() + "```", false, nil
+		}
+		info.SendMessage(ch.ID, "Calendar for "+info.Name+": "+calendarURL(info)+"\nAnyone with this link can see the server's upcoming events. Use `"+info.config.Basic.CommandPrefix+"calendar url reset` to replace it with a new one.")
+		if reset {
+			return "```Replaced the calendar URL, the old one no longer works. I've sent you the new one in a private message.```", false, nil
+		}
+		return "```I've sent you the calendar URL in a private message.```", false, nil
+	}
+	return "```Unknown option " + args[0] + ". Use export, import or url.```", false, nil
+}
+func (c *calendarCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Exports the upcoming episodes and events as an iCalendar (.ics) file that can be imported into most calendar apps, or lets moderators import events from one. Recurring events are exported as their next " + fmt.Sprint(calendarOccurrences) + " occurrences.",
+		Params: []CommandUsageParam{
+			{Name: "export", Desc: "Uploads a .ics file with the upcoming events.", Optional: true},
+			{Name: "import", Desc: "Moderators only. Attach a .ics file to your message to add every future event in it to the schedule, up to " + fmt.Sprint(calendarMaxImport) + " at once.", Optional: true},
+			{Name: "url [reset]", Desc: "Moderators only. Privately sends you a secret link to a calendar that stays up to date, if the bot has the calendar server enabled. `reset` replaces the link with a new one.", Optional: true},
+		},
+	}
+}
+func (c *calendarCommand) UsageShort() string { return "Exports or imports the calendar." }
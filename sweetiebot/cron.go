@@ -0,0 +1,345 @@
+package sweetiebot
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How many days ahead we look for the next occurrence of a cron schedule before giving up, so that impossible schedules like "0 0 30 2 *" terminate.
+const cronSearchDays = 366 * 5
+
+// cronSchedule is a parsed cron expression in the format "minute hour day-of-month month day-of-week". On top of the standard syntax, the day of the month can be L (the last day of the month), and a day of the week can be followed by #N (the Nth such weekday of the month) or L (the last such weekday of the month).
+type cronSchedule struct {
+	Expr     string
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	nth      [7]uint8 // Bit N is set if the Nth weekday of the month matches, bit 0 means the last one
+	lastDay  bool
+	anyDay   bool
+	anyWeek  bool
+}
+
+var cronMonthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var cronDayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+func parseCronValue(s string, min int, max int, names []string) (int, error) {
+	for i, v := range names {
+		if strings.HasPrefix(strings.ToLower(s), v) {
+			return i + min, nil
+		}
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil || i < min || i > max {
+		return 0, fmt.Errorf("%s is not a number between %v and %v", s, min, max)
+	}
+	return i, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps into a bitset. It returns true if the field was a wildcard.
+func parseCronField(s string, min int, max int, names []string) (uint64, bool, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, false, errors.New(part + " has an invalid step")
+			}
+			part = part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" && part != "?" {
+			var err error
+			bounds := strings.SplitN(part, "-", 2)
+			if lo, err = parseCronValue(bounds[0], min, max, names); err != nil {
+				return 0, false, err
+			}
+			hi = lo
+			if len(bounds) > 1 {
+				if hi, err = parseCronValue(bounds[1], min, max, names); err != nil {
+					return 0, false, err
+				}
+			} else if step > 1 {
+				hi = max
+			}
+			if hi < lo {
+				return 0, false, errors.New(part + " is an empty range")
+			}
+		} else if s == part {
+			return cronBits(min, max, 1), true, nil
+		}
+		bits |= cronBits(lo, hi, step)
+	}
+	return bits, false, nil
+}
+
+func cronBits(lo int, hi int, step int) uint64 {
+	var bits uint64
+	for i := lo; i <= hi; i += step {
+		bits |= 1 << uint(i)
+	}
+	return bits
+}
+
+// parseCron parses a standard 5 field cron expression
+func parseCron(s string) (*cronSchedule, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return nil, errors.New("a cron expression must have exactly 5 fields: minute, hour, day of month, month and day of week")
+	}
+	c := &cronSchedule{Expr: strings.ToUpper(strings.Join(fields, " "))}
+	var err error
+	if c.minutes, _, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, err
+	}
+	if c.hours, _, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, err
+	}
+	if strings.ToUpper(fields[2]) == "L" {
+		c.lastDay = true
+	} else if c.days, c.anyDay, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, err
+	}
+	if c.months, _, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, err
+	}
+	var plain []string
+	for _, part := range strings.Split(fields[4], ",") {
+		if i := strings.IndexAny(part, "#Ll"); i > 0 && !strings.Contains(part, "-") {
+			day, err := parseCronValue(part[:i], 0, 7, cronDayNames)
+			if err != nil {
+				return nil, err
+			}
+			n := 0
+			if part[i] == '#' {
+				if n, err = strconv.Atoi(part[i+1:]); err != nil || n < 1 || n > 5 {
+					return nil, errors.New(part + " must specify a week between 1 and 5")
+				}
+			} else if i+1 != len(part) {
+				return nil, errors.New(part + " is not a valid day of the week")
+			}
+			c.nth[day%7] |= 1 << uint(n)
+		} else {
+			plain = append(plain, part)
+		}
+	}
+	if len(plain) > 0 {
+		if c.weekdays, c.anyWeek, err = parseCronField(strings.Join(plain, ","), 0, 7, cronDayNames); err != nil {
+			return nil, err
+		}
+		if c.weekdays&(1<<7) != 0 { // Both 0 and 7 are sunday
+			c.weekdays |= 1
+		}
+		c.anyWeek = c.anyWeek && len(plain) == len(strings.Split(fields[4], ","))
+	}
+	if c.Next(time.Now().UTC(), time.UTC).IsZero() {
+		return nil, errors.New(c.Expr + " never happens")
+	}
+	return c, nil
+}
+
+func (c *cronSchedule) matchDay(d time.Time) bool {
+	lastDay := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if c.months&(1<<uint(d.Month())) == 0 {
+		return false
+	}
+	dom := (c.days&(1<<uint(d.Day())) != 0) || (c.lastDay && d.Day() == lastDay)
+	nth := c.nth[d.Weekday()]
+	dow := (c.weekdays&(1<<uint(d.Weekday())) != 0) || (nth&(1<<uint((d.Day()-1)/7+1)) != 0) || (nth&1 != 0 && d.Day()+7 > lastDay)
+	if c.anyDay {
+		return c.anyWeek || dow
+	}
+	if c.anyWeek {
+		return dom
+	}
+	return dom || dow // If both fields are restricted, cron matches either of them
+}
+
+// Next returns the first time after the given time that matches the schedule, evaluated as wall clock time in the given timezone. If the time was skipped because the clocks were moved forward, it happens that much later instead, and if it happens twice because the clocks were turned back, only the first occurrence counts. Returns a zero time if the schedule never happens.
+func (c *cronSchedule) Next(after time.Time, loc *time.Location) time.Time {
+	local := after.In(loc)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 0; i < cronSearchDays; i++ {
+		d := start.AddDate(0, 0, i)
+		if !c.matchDay(d) {
+			continue
+		}
+		for h := 0; h < 24; h++ {
+			if c.hours&(1<<uint(h)) == 0 {
+				continue
+			}
+			for m := 0; m < 60; m++ {
+				if c.minutes&(1<<uint(m)) == 0 {
+					continue
+				}
+				t := time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, loc)
+				if l := t.In(loc); l.Hour() != h || l.Minute() != m { // This time was skipped when the clocks were moved forward
+					_, before := t.Zone()
+					_, later := t.Add(3 * time.Hour).Zone()
+					t = t.Add(time.Duration(later-before) * time.Second)
+				}
+				if !t.After(after) {
+					continue
+				}
+				if l := t.In(loc); l.Day() == local.Day() && l.Hour() == local.Hour() && l.Minute() == local.Minute() && t.Sub(after) < 2*time.Hour {
+					continue // This is the repeated hour after the clocks were turned back
+				}
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Upcoming returns the next n occurrences of the schedule after the given time
+func (c *cronSchedule) Upcoming(after time.Time, loc *time.Location, n int) []time.Time {
+	r := make([]time.Time, 0, n)
+	for len(r) < n {
+		after = c.Next(after, loc)
+		if after.IsZero() {
+			break
+		}
+		r = append(r, after)
+	}
+	return r
+}
+
+var recurrenceTimeRegex = regexp.MustCompile(`^(?:at )?(noon|midnight|(\d{1,2})(?::(\d{2}))? ?(am|pm)?)$`)
+var recurrenceNthRegex = regexp.MustCompile(`^(first|second|third|fourth|fifth|last|1st|2nd|3rd|4th|5th) ([a-z]+?)s? of (?:the|every) month(?: (.+))?$`)
+var recurrenceEveryRegex = regexp.MustCompile(`^every ([a-z, ]+?)(?: (?:at )?(noon|midnight|\d{1,2}(?::\d{2})? ?(?:am|pm)?))?$`)
+var recurrenceMonthlyRegex = regexp.MustCompile(`^every month on the (\d{1,2})(?:st|nd|rd|th)?(?: (.+))?$`)
+
+// parseTimeOfDay turns a time of day like "8pm", "at 20:30" or "noon" into an hour and a minute
+func parseTimeOfDay(s string) (int, int, error) {
+	m := recurrenceTimeRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, errors.New(s + " is not a valid time of day")
+	}
+	switch m[1] {
+	case "noon":
+		return 12, 0, nil
+	case "midnight":
+		return 0, 0, nil
+	}
+	hour, _ := strconv.Atoi(m[2])
+	minute := 0
+	if len(m[3]) > 0 {
+		minute, _ = strconv.Atoi(m[3])
+	}
+	if len(m[4]) > 0 {
+		if hour < 1 || hour > 12 {
+			return 0, 0, errors.New(s + " is not a valid time of day")
+		}
+		hour %= 12
+		if m[4] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, errors.New(s + " is not a valid time of day")
+	}
+	return hour, minute, nil
+}
+
+// parseRecurrenceTime turns a time of day like "8pm" or "20:30" into the minute and hour fields of a cron expression
+func parseRecurrenceTime(s string) (string, error) {
+	if len(s) == 0 {
+		return "0 0", nil
+	}
+	hour, minute, err := parseTimeOfDay(s)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v %v", minute, hour), nil
+}
+
+var recurrenceDayNames = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// parseRecurrenceDay accepts a day of the week, its plural or any abbreviation of at least 3 letters
+func parseRecurrenceDay(s string) int {
+	s = strings.TrimSuffix(s, "s")
+	for i, v := range recurrenceDayNames {
+		if len(s) >= 3 && strings.HasPrefix(v, s) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseRecurrence accepts either a cron expression or a description like "every Friday 20:00", "every day at noon", "every weekday 9am", "every month on the 15th" or "first Monday of the month 6pm", and returns the parsed schedule. Times default to midnight.
+func parseRecurrence(s string) (*cronSchedule, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if strings.HasPrefix(s, "cron ") {
+		s = s[5:]
+	}
+	if m := recurrenceNthRegex.FindStringSubmatch(s); m != nil {
+		day := parseRecurrenceDay(m[2])
+		if day < 0 {
+			return nil, errors.New(m[2] + " is not a day of the week")
+		}
+		t, err := parseRecurrenceTime(m[3])
+		if err != nil {
+			return nil, err
+		}
+		n := "L"
+		switch m[1] {
+		case "first", "1st":
+			n = "#1"
+		case "second", "2nd":
+			n = "#2"
+		case "third", "3rd":
+			n = "#3"
+		case "fourth", "4th":
+			n = "#4"
+		case "fifth", "5th":
+			n = "#5"
+		}
+		return parseCron(fmt.Sprintf("%s * * %v%s", t, day, n))
+	}
+	if m := recurrenceMonthlyRegex.FindStringSubmatch(s); m != nil {
+		t, err := parseRecurrenceTime(m[2])
+		if err != nil {
+			return nil, err
+		}
+		return parseCron(fmt.Sprintf("%s %s * *", t, m[1]))
+	}
+	if m := recurrenceEveryRegex.FindStringSubmatch(s); m != nil {
+		t, err := parseRecurrenceTime(m[2])
+		if err != nil {
+			return nil, err
+		}
+		days := []string{}
+		for _, v := range strings.FieldsFunc(m[1], func(r rune) bool { return r == ',' || r == ' ' }) {
+			switch v {
+			case "and":
+			case "day":
+				days = append(days, "*")
+			case "hour":
+				return parseCron("0 * * * *")
+			case "weekday", "weekdays":
+				days = append(days, "1-5")
+			case "weekend", "weekends":
+				days = append(days, "0,6")
+			default:
+				day := parseRecurrenceDay(v)
+				if day < 0 {
+					return nil, errors.New(v + " is not a day of the week")
+				}
+				days = append(days, strconv.Itoa(day))
+			}
+		}
+		if len(days) == 0 {
+			return nil, errors.New(s + " does not say which days the event happens on")
+		}
+		return parseCron(fmt.Sprintf("%s * * %s", t, strings.Join(days, ",")))
+	}
+	return parseCron(s)
+}
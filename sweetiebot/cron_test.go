@@ -0,0 +1,154 @@
+package sweetiebot
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s is not available: %s", name, err.Error())
+	}
+	return loc
+}
+
+func TestParseCron(t *testing.T) {
+	cases := []struct {
+		expr string
+		ok   bool
+	}{
+		{"0 20 * * 5", true},
+		{"*/15 * * * *", true},
+		{"0 9 * * 1-5", true},
+		{"0 0 L * *", true},
+		{"0 18 * * 1#1", true},
+		{"0 18 * * 5L", true},
+		{"0 0 1 jan-mar mon", true},
+		{"0 0 * * 7", true},
+		{"0 0 * *", false},
+		{"0 0 * * * *", false},
+		{"60 * * * *", false},
+		{"0 24 * * *", false},
+		{"0 0 0 * *", false},
+		{"0 0 * 13 *", false},
+		{"5-1 * * * *", false},
+		{"*/0 * * * *", false},
+		{"0 0 * * 1#6", false},
+		{"0 0 * * 1X", false},
+		{"0 0 30 2 *", false}, // never happens
+	}
+	for _, c := range cases {
+		_, err := parseCron(c.expr)
+		if (err == nil) != c.ok {
+			t.Errorf("parseCron(%q) returned error %v, expected success to be %v", c.expr, err, c.ok)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	london := mustLoadLocation(t, "Europe/London")
+	utc := func(s string) time.Time {
+		r, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	cases := []struct {
+		name  string
+		expr  string
+		loc   *time.Location
+		after time.Time
+		want  time.Time
+	}{
+		{"weekly", "0 20 * * 5", time.UTC, utc("2024-09-02 12:00"), utc("2024-09-06 20:00")},
+		{"same minute is skipped", "0 20 * * 5", time.UTC, utc("2024-09-06 20:00"), utc("2024-09-13 20:00")},
+		{"31st skips short months", "0 0 31 * *", time.UTC, utc("2023-04-01 00:00"), utc("2023-05-31 00:00")},
+		{"last day in a leap year", "0 12 L * *", time.UTC, utc("2024-02-01 00:00"), utc("2024-02-29 12:00")},
+		{"last day in a common year", "0 12 L * *", time.UTC, utc("2023-02-01 00:00"), utc("2023-02-28 12:00")},
+		{"last day of a 30 day month", "0 12 L * *", time.UTC, utc("2024-04-30 12:00"), utc("2024-05-31 12:00")},
+		{"29th of february", "0 0 29 2 *", time.UTC, utc("2024-03-01 00:00"), utc("2028-02-29 00:00")},
+		{"year end", "30 23 31 12 *", time.UTC, utc("2023-12-31 23:30"), utc("2024-12-31 23:30")},
+		{"last friday", "0 18 * * 5L", time.UTC, utc("2024-05-01 00:00"), utc("2024-05-31 18:00")},
+		{"first monday", "0 18 * * 1#1", time.UTC, utc("2024-09-03 00:00"), utc("2024-10-07 18:00")},
+		{"fifth friday skips months without one", "0 0 * * 5#5", time.UTC, utc("2024-09-01 00:00"), utc("2024-11-29 00:00")},
+		{"day of month or day of week", "0 0 13 * 5", time.UTC, utc("2024-09-01 00:00"), utc("2024-09-06 00:00")},
+		{"sunday as 7", "0 0 * * 7", time.UTC, utc("2024-09-02 00:00"), utc("2024-09-08 00:00")},
+		{"timezone", "0 20 * * *", newYork, utc("2024-06-30 12:00"), utc("2024-07-01 00:00")},
+		{"skipped by spring forward", "30 2 * * *", newYork, utc("2024-03-10 05:00"), utc("2024-03-10 07:30")},
+		{"after spring forward", "30 2 * * *", newYork, utc("2024-03-10 07:30"), utc("2024-03-11 06:30")},
+		{"first time during fall back", "30 1 * * *", newYork, utc("2024-11-03 04:00"), utc("2024-11-03 05:30")},
+		{"repeated time during fall back", "30 1 * * *", newYork, utc("2024-11-03 05:30"), utc("2024-11-04 06:30")},
+		{"wall clock stays the same across dst", "0 20 * * *", newYork, utc("2024-11-03 12:00"), utc("2024-11-04 01:00")},
+		{"skipped by spring forward in london", "30 1 * * *", london, utc("2024-03-31 00:00"), utc("2024-03-31 01:30")},
+	}
+	for _, c := range cases {
+		s, err := parseCron(c.expr)
+		if err != nil {
+			t.Errorf("%s: parseCron(%q) failed: %s", c.name, c.expr, err.Error())
+			continue
+		}
+		if got := s.Next(c.after, c.loc); !got.Equal(c.want) {
+			t.Errorf("%s: Next(%s) of %q in %s = %s, want %s", c.name, c.after, c.expr, c.loc, got.UTC(), c.want)
+		}
+	}
+}
+
+func TestCronUpcoming(t *testing.T) {
+	c, err := parseCron("0 0 L * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	want := []time.Time{
+		time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC),
+	}
+	got := c.Upcoming(after, time.UTC, len(want))
+	if len(got) != len(want) {
+		t.Fatalf("Upcoming returned %v times, want %v", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("Upcoming()[%v] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParseRecurrence(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{"every Friday 8pm", "0 20 * * 5"},
+		{"every friday at 20:30", "30 20 * * 5"},
+		{"every day at noon", "0 12 * * *"},
+		{"every weekday 9am", "0 9 * * 1-5"},
+		{"every weekend", "0 0 * * 0,6"},
+		{"every monday and wednesday 7pm", "0 19 * * 1,3"},
+		{"every hour", "0 * * * *"},
+		{"every month on the 15th", "0 0 15 * *"},
+		{"first Monday of the month 6pm", "0 18 * * 1#1"},
+		{"last friday of every month", "0 0 * * 5L"},
+		{"cron 0 0 * * *", "0 0 * * *"},
+		{"*/10 * * * *", "*/10 * * * *"},
+		{"every blursday", ""},
+		{"every friday 13pm", ""},
+		{"second funday of the month", ""},
+	}
+	for _, c := range cases {
+		s, err := parseRecurrence(c.in)
+		switch {
+		case len(c.want) == 0 && err == nil:
+			t.Errorf("parseRecurrence(%q) = %q, expected an error", c.in, s.Expr)
+		case len(c.want) > 0 && err != nil:
+			t.Errorf("parseRecurrence(%q) failed: %s", c.in, err.Error())
+		case len(c.want) > 0 && s.Expr != c.want:
+			t.Errorf("parseRecurrence(%q) = %q, want %q", c.in, s.Expr, c.want)
+		}
+	}
+}
@@ -54,6 +54,7 @@ type BotDB struct {
 	sqlGetTableCounts         *sql.Stmt
 	sqlCountNewUsers          *sql.Stmt
 	sqlAudit                  *sql.Stmt
+	sqlRedactAudit            *sql.Stmt
 	sqlGetAuditRows           *sql.Stmt
 	sqlGetAuditRowsUser       *sql.Stmt
 	sqlGetAuditRowsString     *sql.Stmt
@@ -91,6 +92,86 @@ type BotDB struct {
 	sqlCheckOption            *sql.Stmt
 	sqlSentMessage            *sql.Stmt
 	sqlGetNewcomers           *sql.Stmt
+	sqlAddAppeal              *sql.Stmt
+	sqlGetAppeal              *sql.Stmt
+	sqlGetOpenAppeal          *sql.Stmt
+	sqlSetAppealMessage       *sql.Stmt
+	sqlSetAppealStatus        *sql.Stmt
+	sqlRemoveAppeal           *sql.Stmt
+	sqlGetChatlog             *sql.Stmt
+	sqlGetChatlogUser         *sql.Stmt
+	sqlGetChatMessage         *sql.Stmt
+	sqlGetMessageHistory      *sql.Stmt
+	sqlAddDeleted             *sql.Stmt
+	sqlRemoveMessage          *sql.Stmt
+	sqlGetDeleted             *sql.Stmt
+	sqlGetDeletedUser         *sql.Stmt
+	sqlPurgeChatlog           *sql.Stmt
+	sqlPurgeEditlog           *sql.Stmt
+	sqlPurgeDebuglog          *sql.Stmt
+	sqlPurgeUserChatlog       *sql.Stmt
+	sqlPurgeUserEditlog       *sql.Stmt
+	sqlPurgeUserDebuglog      *sql.Stmt
+	sqlGetAllAliases          *sql.Stmt
+	sqlGetUserMemberships     *sql.Stmt
+	sqlGetUserChatlog         *sql.Stmt
+	sqlGetUserEditlog         *sql.Stmt
+	sqlGetUserSchedule        *sql.Stmt
+	sqlGetUserVotes           *sql.Stmt
+	sqlGetUserAppeals         *sql.Stmt
+	sqlForgetChatlog          *sql.Stmt
+	sqlForgetEditlog          *sql.Stmt
+	sqlForgetDebuglog         *sql.Stmt
+	sqlForgetAliases          *sql.Stmt
+	sqlForgetMembers          *sql.Stmt
+	sqlForgetVotes            *sql.Stmt
+	sqlForgetAppeals          *sql.Stmt
+	sqlForgetSchedule         *sql.Stmt
+	sqlForgetUser             *sql.Stmt
+	sqlGetChatlogRange        *sql.Stmt
+	sqlGetOldestChatlog       *sql.Stmt
+	sqlGetActivity            *sql.Stmt
+	sqlGetTopPosters          *sql.Stmt
+	sqlGetTopChannels         *sql.Stmt
+	sqlGetJoinCounts          *sql.Stmt
+	sqlGetLeaveCount          *sql.Stmt
+	sqlGetFirstMessageDelays  *sql.Stmt
+	sqlGetUserDepartures      *sql.Stmt
+	sqlForgetDepartures       *sql.Stmt
+	sqlGetLastMessage         *sql.Stmt
+	sqlGetAuditMentions       *sql.Stmt
+	sqlGetNameHistory         *sql.Stmt
+	sqlGetUserNameLog         *sql.Stmt
+	sqlForgetNameLog          *sql.Stmt
+	sqlAddScheduleCron        *sql.Stmt
+	sqlRescheduleEvent        *sql.Stmt
+	sqlGetLegacyEvents        *sql.Stmt
+	sqlSetEventData           *sql.Stmt
+	sqlRetryEvent             *sql.Stmt
+	sqlFailEvent              *sql.Stmt
+	sqlResetEvent             *sql.Stmt
+	sqlRetryFailedEvents      *sql.Stmt
+	sqlGetFailedEvents        *sql.Stmt
+	sqlAddRSVP                *sql.Stmt
+	sqlRemoveRSVP             *sql.Stmt
+	sqlGetRSVPs               *sql.Stmt
+	sqlGetRSVPCount           *sql.Stmt
+	sqlClearRSVPs             *sql.Stmt
+	sqlGetUserRSVPs           *sql.Stmt
+	sqlForgetRSVPs            *sql.Stmt
+	sqlFindEventByPost        *sql.Stmt
+	sqlGetUnremindedEvents    *sql.Stmt
+	sqlRemoveBirthday         *sql.Stmt
+	sqlSetPollMessage         *sql.Stmt
+	sqlGetPollByMessage       *sql.Stmt
+	sqlGetPollMessage         *sql.Stmt
+	sqlGetVotes               *sql.Stmt
+	sqlRemoveVote             *sql.Stmt
+	sqlRemoveVotes            *sql.Stmt
+	sqlGetBallots             *sql.Stmt
+	sqlGetPollByID            *sql.Stmt
+	sqlSetPollSettings        *sql.Stmt
+	sqlRemoveScheduleByData   *sql.Stmt
 }
 
 func DB_Load(log logger, driver string, conn string) (*BotDB, error) {
@@ -162,7 +243,7 @@ func (db *BotDB) CheckStatus() bool {
 
 func (db *BotDB) LoadStatements() error {
 	var err error
-	db.sqlAddMessage, err = db.Prepare("CALL AddChat(?,?,?,?,?,?)")
+	db.sqlAddMessage, err = db.Prepare("CALL AddChat(?,?,?,?,?,?,?)")
 	db.sqlGetMessage, err = db.Prepare("SELECT Author, Message, Timestamp, Channel FROM chatlog WHERE ID = ?")
 	db.sqlAddUser, err = db.Prepare("CALL AddUser(?,?,?,?,?,?,?)")
 	db.sqlAddMember, err = db.Prepare("CALL AddMember(?,?,?,?)")
@@ -173,7 +254,7 @@ func (db *BotDB) LoadStatements() error {
 	db.sqlFindUsers, err = db.Prepare("SELECT U.ID FROM users U LEFT OUTER JOIN aliases A ON A.User = U.ID LEFT OUTER JOIN members M ON M.ID = U.ID WHERE U.Username LIKE ? OR M.Nickname LIKE ? OR A.Alias = ? GROUP BY U.ID LIMIT ? OFFSET ?")
 	db.sqlGetRecentMessages, err = db.Prepare("SELECT ID, Channel FROM chatlog WHERE Guild = ? AND Author = ? AND Timestamp >= DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND)")
 	db.sqlGetNewestUsers, err = db.Prepare("SELECT U.ID, U.Email, U.Username, U.Avatar, M.FirstSeen FROM members M INNER JOIN users U ON M.ID = U.ID WHERE M.Guild = ? ORDER BY M.FirstSeen DESC LIMIT ?")
-	db.sqlGetRecentUsers, err = db.Prepare("SELECT U.ID, U.Email, U.Username, U.Avatar FROM members M INNER JOIN users U ON M.ID = U.ID WHERE M.Guild = ? AND M.FirstSeen > ? ORDER BY M.FirstSeen DESC")
+	db.sqlGetRecentUsers, err = db.Prepare("SELECT U.ID, U.Email, U.Username, U.Avatar, M.FirstSeen FROM members M INNER JOIN users U ON M.ID = U.ID WHERE M.Guild = ? AND M.FirstSeen > ? ORDER BY M.FirstSeen DESC")
 	db.sqlGetAliases, err = db.Prepare("SELECT Alias FROM aliases WHERE User = ? ORDER BY Duration DESC LIMIT 10")
 	db.sqlAddTranscript, err = db.Prepare("INSERT INTO transcripts (Season, Episode, Line, Speaker, Text) VALUES (?,?,?,?,?)")
 	db.sqlGetTranscript, err = db.Prepare("SELECT Season, Episode, Line, Speaker, Text FROM transcripts WHERE Season = ? AND Episode = ? AND Line >= ? AND LINE <= ?")
@@ -197,6 +278,7 @@ func (db *BotDB) LoadStatements() error {
 	db.sqlGetTableCounts, err = db.Prepare("SELECT CONCAT('Chatlog: ', (SELECT COUNT(*) FROM chatlog), ' rows', '\nEditlog: ', (SELECT COUNT(*) FROM editlog), ' rows',  '\nAliases: ', (SELECT COUNT(*) FROM aliases), ' rows',  '\nDebuglog: ', (SELECT COUNT(*) FROM debuglog), ' rows',  '\nUsers: ', (SELECT COUNT(*) FROM users), ' rows',  '\nSchedule: ', (SELECT COUNT(*) FROM schedule), ' rows \nMembers: ', (SELECT COUNT(*) FROM members), ' rows');")
 	db.sqlCountNewUsers, err = db.Prepare("SELECT COUNT(*) FROM members WHERE FirstSeen > DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND) AND Guild = ?")
 	db.sqlAudit, err = db.Prepare("INSERT INTO debuglog (Type, User, Message, Timestamp, Guild) VALUE(?, ?, ?, UTC_TIMESTAMP(), ?)")
+	db.sqlRedactAudit, err = db.Prepare("UPDATE debuglog SET Message = ? WHERE Type = ? AND User = ? AND Guild = ? AND Message = ? ORDER BY ID DESC LIMIT 1")
 	db.sqlGetAuditRows, err = db.Prepare("SELECT U.Username, D.Message, D.Timestamp, U.ID FROM debuglog D INNER JOIN users U ON D.User = U.ID WHERE D.Type = ? AND D.Guild = ? ORDER BY D.Timestamp DESC LIMIT ? OFFSET ?")
 	db.sqlGetAuditRowsUser, err = db.Prepare("SELECT U.Username, D.Message, D.Timestamp, U.ID FROM debuglog D INNER JOIN users U ON D.User = U.ID WHERE D.Type = ? AND D.Guild = ? AND D.User = ? ORDER BY D.Timestamp DESC LIMIT ? OFFSET ?")
 	db.sqlGetAuditRowsString, err = db.Prepare("SELECT U.Username, D.Message, D.Timestamp, U.ID FROM debuglog D INNER JOIN users U ON D.User = U.ID WHERE D.Type = ? AND D.Guild = ? AND D.Message LIKE ? ORDER BY D.Timestamp DESC LIMIT ? OFFSET ?")
@@ -204,14 +286,14 @@ func (db *BotDB) LoadStatements() error {
 	db.sqlResetMarkov, err = db.Prepare("CALL ResetMarkov()")
 	db.sqlAddSchedule, err = db.Prepare("INSERT INTO schedule (Guild, Date, Type, Data) VALUES (?, ?, ?, ?)")
 	db.sqlAddScheduleRepeat, err = db.Prepare("INSERT INTO schedule (Guild, Date, `RepeatInterval`, `Repeat`, Type, Data) VALUES (?, ?, ?, ?, ?, ?)")
-	db.sqlGetSchedule, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? AND Date <= UTC_TIMESTAMP() ORDER BY Date ASC")
+	db.sqlGetSchedule, err = db.Prepare("SELECT ID, Date, Type, Data, COALESCE(Cron, ''), Status, Attempts FROM schedule WHERE Guild = ? AND Status != 2 AND COALESCE(Retry, Date) <= UTC_TIMESTAMP() ORDER BY Date ASC")
 	db.sqlRemoveSchedule, err = db.Prepare("CALL RemoveSchedule(?)")
 	db.sqlCountEvents, err = db.Prepare("SELECT COUNT(*) FROM schedule WHERE Guild = ?")
-	db.sqlGetEvent, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE ID = ?")
-	db.sqlGetEvents, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? AND Type != 0 AND Type != 4 AND Type != 6 ORDER BY Date ASC LIMIT ?")
-	db.sqlGetEventsByType, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? AND Type = ? ORDER BY Date ASC LIMIT ?")
+	db.sqlGetEvent, err = db.Prepare("SELECT ID, Date, Type, Data, COALESCE(Cron, '') FROM schedule WHERE ID = ?")
+	db.sqlGetEvents, err = db.Prepare("SELECT ID, Date, Type, Data, COALESCE(Cron, '') FROM schedule WHERE Guild = ? AND Type != 0 AND Type != 4 AND Type != 6 ORDER BY Date ASC LIMIT ?")
+	db.sqlGetEventsByType, err = db.Prepare("SELECT ID, Date, Type, Data, COALESCE(Cron, '') FROM schedule WHERE Guild = ? AND Type = ? ORDER BY Date ASC LIMIT ?")
 	db.sqlGetNextEvent, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? AND Type = ? ORDER BY Date ASC LIMIT 1")
-	db.sqlGetReminders, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ? AND Type = 6 AND Data LIKE ? ORDER BY Date ASC LIMIT ?")
+	db.sqlGetReminders, err = db.Prepare("SELECT ID, Date, Type, Data, COALESCE(Cron, '') FROM schedule WHERE Guild = ? AND Type = 6 AND Data LIKE ? ORDER BY Date ASC LIMIT ?")
 	db.sqlGetUnsilenceDate, err = db.Prepare("SELECT Date FROM schedule WHERE Guild = ? AND Type = 8 AND Data = ?")
 	db.sqlGetTimeZone, err = db.Prepare("SELECT Location FROM users WHERE ID = ?")
 	db.sqlFindTimeZone, err = db.Prepare("SELECT Location FROM timezones WHERE Location LIKE ?")
@@ -229,11 +311,91 @@ func (db *BotDB) LoadStatements() error {
 	db.sqlAddPoll, err = db.Prepare("INSERT INTO polls(Name, Description, Guild) VALUES (?, ?, ?)")
 	db.sqlAddOption, err = db.Prepare("INSERT INTO polloptions(Poll, `Index`, `Option`) VALUES (?, ?, ?)")
 	db.sqlAppendOption, err = db.Prepare("INSERT INTO polloptions(Poll, `Index`, `Option`) SELECT Poll, MAX(`index`)+1, ? FROM polloptions WHERE poll = ?")
-	db.sqlAddVote, err = db.Prepare("INSERT INTO votes (Poll, User, `Option`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE `Option` = ?")
+	db.sqlAddVote, err = db.Prepare("INSERT INTO votes (Poll, User, `Option`, `Rank`) VALUES (?, ?, ?, ?)")
 	db.sqlRemovePoll, err = db.Prepare("DELETE FROM polls WHERE Name = ? AND Guild = ?")
 	db.sqlCheckOption, err = db.Prepare("SELECT `Option` FROM polloptions WHERE poll = ? AND `Index` = ?")
 	db.sqlSentMessage, err = db.Prepare("UPDATE `members` SET `FirstMessage` = UTC_TIMESTAMP() WHERE ID = ? AND Guild = ? AND `FirstMessage` IS NULL")
 	db.sqlGetNewcomers, err = db.Prepare("SELECT ID FROM `members` WHERE `Guild` = ? AND `FirstMessage` > DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? SECOND)")
+	db.sqlAddAppeal, err = db.Prepare("INSERT INTO appeals (Guild, User, Type, Reason, Status, Timestamp) VALUES (?, ?, ?, ?, 0, UTC_TIMESTAMP())")
+	db.sqlGetAppeal, err = db.Prepare("SELECT ID, Guild, User, Type, Reason, Appeal, Status, Timestamp FROM appeals WHERE ID = ? AND Guild = ?")
+	db.sqlGetOpenAppeal, err = db.Prepare("SELECT ID, Guild, User, Type, Reason, Appeal, Status, Timestamp FROM appeals WHERE User = ? AND Status = 0 ORDER BY ID DESC LIMIT 1")
+	db.sqlSetAppealMessage, err = db.Prepare("UPDATE appeals SET Appeal = ?, Status = 1 WHERE ID = ? AND Status = 0")
+	db.sqlSetAppealStatus, err = db.Prepare("UPDATE appeals SET Status = ? WHERE ID = ?")
+	db.sqlRemoveAppeal, err = db.Prepare("DELETE FROM appeals WHERE ID = ?")
+	db.sqlGetChatlog, err = db.Prepare("SELECT ID, Author, Message, Channel FROM chatlog WHERE Guild = ? ORDER BY ID DESC LIMIT ?")
+	db.sqlGetChatlogUser, err = db.Prepare("SELECT ID, Author, Message, Channel FROM chatlog WHERE Guild = ? AND Author = ? ORDER BY ID DESC LIMIT ?")
+	db.sqlGetChatMessage, err = db.Prepare("SELECT C.Author, C.Message, C.Timestamp, C.Channel, D.Timestamp FROM chatlog C LEFT OUTER JOIN deletelog D ON C.ID = D.ID WHERE C.ID = ? AND C.Guild = ?")
+	db.sqlGetMessageHistory, err = db.Prepare("SELECT Message, Timestamp FROM editlog WHERE ID = ? AND Guild = ? ORDER BY Timestamp ASC")
+	db.sqlAddDeleted, err = db.Prepare("INSERT IGNORE INTO deletelog (ID, Timestamp) SELECT ID, UTC_TIMESTAMP() FROM chatlog WHERE ID = ?")
+	db.sqlRemoveMessage, err = db.Prepare("DELETE FROM chatlog WHERE ID = ?")
+	db.sqlGetDeleted, err = db.Prepare("SELECT C.ID, C.Author, C.Message, C.Timestamp, D.Timestamp FROM deletelog D INNER JOIN chatlog C ON D.ID = C.ID WHERE C.Guild = ? AND C.Channel = ? ORDER BY D.Timestamp DESC LIMIT ? OFFSET ?")
+	db.sqlGetDeletedUser, err = db.Prepare("SELECT C.ID, C.Author, C.Message, C.Timestamp, D.Timestamp FROM deletelog D INNER JOIN chatlog C ON D.ID = C.ID WHERE C.Guild = ? AND C.Channel = ? AND C.Author = ? ORDER BY D.Timestamp DESC LIMIT ? OFFSET ?")
+	db.sqlPurgeChatlog, err = db.Prepare("DELETE FROM chatlog WHERE Guild = ? AND Timestamp < DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? DAY)")
+	db.sqlPurgeEditlog, err = db.Prepare("DELETE FROM editlog WHERE Guild = ? AND Timestamp < DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? DAY)")
+	db.sqlPurgeDebuglog, err = db.Prepare("DELETE FROM debuglog WHERE Guild = ? AND Timestamp < DATE_SUB(UTC_TIMESTAMP(), INTERVAL ? DAY)")
+	db.sqlPurgeUserChatlog, err = db.Prepare("DELETE FROM chatlog WHERE Guild = ? AND Author = ?")
+	db.sqlPurgeUserEditlog, err = db.Prepare("DELETE FROM editlog WHERE Guild = ? AND Author = ?")
+	db.sqlPurgeUserDebuglog, err = db.Prepare("UPDATE debuglog SET User = NULL WHERE Guild = ? AND User = ?")
+	db.sqlGetAllAliases, err = db.Prepare("SELECT Alias FROM aliases WHERE User = ? ORDER BY Duration DESC")
+	db.sqlGetUserMemberships, err = db.Prepare("SELECT Guild, FirstSeen, Nickname, FirstMessage FROM members WHERE ID = ?")
+	db.sqlGetUserChatlog, err = db.Prepare("SELECT ID, Guild, Channel, Message, Timestamp FROM chatlog WHERE Author = ? ORDER BY ID ASC")
+	db.sqlGetUserEditlog, err = db.Prepare("SELECT ID, Guild, Channel, Message, Timestamp FROM editlog WHERE Author = ? ORDER BY ID ASC, Timestamp ASC")
+	db.sqlGetUserSchedule, err = db.Prepare("SELECT ID, Guild, Date, Type, Data FROM schedule WHERE Data LIKE ? ORDER BY Date ASC")
+	db.sqlGetUserVotes, err = db.Prepare("SELECT P.Guild, P.Name, O.Option FROM votes V INNER JOIN polls P ON V.Poll = P.ID INNER JOIN polloptions O ON V.Poll = O.Poll AND V.Option = O.`Index` WHERE V.User = ?")
+	db.sqlGetUserAppeals, err = db.Prepare("SELECT ID, Guild, User, Type, Reason, Appeal, Status, Timestamp FROM appeals WHERE User = ? ORDER BY ID ASC")
+	db.sqlForgetChatlog, err = db.Prepare("DELETE FROM chatlog WHERE Author = ?")
+	db.sqlForgetEditlog, err = db.Prepare("DELETE FROM editlog WHERE Author = ?")
+	db.sqlForgetDebuglog, err = db.Prepare("UPDATE debuglog SET User = NULL WHERE User = ?")
+	db.sqlForgetAliases, err = db.Prepare("DELETE FROM aliases WHERE User = ?")
+	db.sqlForgetMembers, err = db.Prepare("DELETE FROM members WHERE ID = ?")
+	db.sqlForgetVotes, err = db.Prepare("DELETE FROM votes WHERE User = ?")
+	db.sqlForgetAppeals, err = db.Prepare("DELETE FROM appeals WHERE User = ?")
+	db.sqlForgetSchedule, err = db.Prepare("DELETE FROM schedule WHERE (Type = 1 OR Type = 4 OR Type = 6) AND Data LIKE ?")
+	db.sqlForgetUser, err = db.Prepare("DELETE FROM users WHERE ID = ?")
+	db.sqlGetActivity, err = db.Prepare("SELECT TIMESTAMPDIFF(MINUTE, ?, Timestamp) DIV 15 AS Q, COUNT(*) FROM chatlog WHERE Guild = ? AND Timestamp >= ? AND (? = 0 OR Channel = ?) AND (? = 0 OR Author = ?) GROUP BY Q")
+	db.sqlGetTopPosters, err = db.Prepare("SELECT Author, COUNT(*) AS C FROM chatlog WHERE Guild = ? AND Timestamp >= ? AND (? = 0 OR Channel = ?) GROUP BY Author ORDER BY C DESC LIMIT ?")
+	db.sqlGetTopChannels, err = db.Prepare("SELECT Channel, COUNT(*) AS C FROM chatlog WHERE Guild = ? AND Timestamp >= ? AND (? = 0 OR Author = ?) GROUP BY Channel ORDER BY C DESC LIMIT ?")
+	db.sqlGetJoinCounts, err = db.Prepare("SELECT COUNT(*), COUNT(J.FirstMessage), COUNT(J.Departed) FROM (SELECT FirstMessage, NULL AS Departed FROM members WHERE Guild = ? AND FirstSeen >= ? UNION ALL SELECT FirstMessage, Timestamp AS Departed FROM departures WHERE Guild = ? AND FirstSeen >= ?) J")
+	db.sqlGetLeaveCount, err = db.Prepare("SELECT COUNT(*) FROM departures WHERE Guild = ? AND Timestamp >= ?")
+	db.sqlGetFirstMessageDelays, err = db.Prepare("SELECT TIMESTAMPDIFF(SECOND, FirstSeen, FirstMessage) AS D FROM members WHERE Guild = ? AND FirstSeen >= ? AND FirstMessage IS NOT NULL UNION ALL SELECT TIMESTAMPDIFF(SECOND, FirstSeen, FirstMessage) AS D FROM departures WHERE Guild = ? AND FirstSeen >= ? AND FirstMessage IS NOT NULL ORDER BY D ASC")
+	db.sqlGetUserDepartures, err = db.Prepare("SELECT Guild, FirstSeen, FirstMessage, Timestamp FROM departures WHERE ID = ? ORDER BY Timestamp ASC")
+	db.sqlForgetDepartures, err = db.Prepare("DELETE FROM departures WHERE ID = ?")
+	db.sqlGetLastMessage, err = db.Prepare("SELECT MAX(Timestamp) FROM chatlog WHERE Guild = ? AND Author = ?")
+	db.sqlGetAuditMentions, err = db.Prepare("SELECT U.Username, D.Message, D.Timestamp, U.ID FROM debuglog D INNER JOIN users U ON D.User = U.ID WHERE D.Type = ? AND D.Guild = ? AND (D.Message LIKE ? OR D.Message LIKE ?) ORDER BY D.Timestamp DESC LIMIT ?")
+	db.sqlGetNameHistory, err = db.Prepare("SELECT Guild, Type, Old, New, Timestamp FROM namelog WHERE User = ? AND (Guild = 0 OR Guild = ?) ORDER BY Timestamp DESC LIMIT ?")
+	db.sqlGetUserNameLog, err = db.Prepare("SELECT Guild, Type, Old, New, Timestamp FROM namelog WHERE User = ? ORDER BY Timestamp ASC")
+	db.sqlForgetNameLog, err = db.Prepare("DELETE FROM namelog WHERE User = ?")
+	db.sqlAddScheduleCron, err = db.Prepare("INSERT INTO schedule (Guild, Date, Cron, Type, Data) VALUES (?, ?, ?, ?, ?)")
+	db.sqlRescheduleEvent, err = db.Prepare("UPDATE schedule SET Date = ?, Status = 0, Attempts = 0, Retry = NULL, LastError = NULL WHERE ID = ?")
+	db.sqlGetLegacyEvents, err = db.Prepare("SELECT ID, Date, Type, Data FROM schedule WHERE Guild = ?")
+	db.sqlSetEventData, err = db.Prepare("UPDATE schedule SET Data = ? WHERE ID = ?")
+	db.sqlRetryEvent, err = db.Prepare("UPDATE schedule SET Status = 1, Attempts = ?, Retry = ?, LastError = ? WHERE ID = ?")
+	db.sqlFailEvent, err = db.Prepare("UPDATE schedule SET Status = 2, Attempts = ?, Retry = NULL, LastError = ? WHERE ID = ?")
+	db.sqlResetEvent, err = db.Prepare("UPDATE schedule SET Status = 0, Attempts = 0, Retry = NULL, LastError = NULL WHERE ID = ?")
+	db.sqlRetryFailedEvents, err = db.Prepare("UPDATE schedule SET Status = 1, Attempts = 0, Retry = UTC_TIMESTAMP() WHERE Guild = ? AND Status = 2 AND (? = 0 OR ID = ?)")
+	db.sqlGetFailedEvents, err = db.Prepare("SELECT ID, Date, Type, Data, COALESCE(Cron, ''), Status, Attempts, COALESCE(LastError, '') FROM schedule WHERE Guild = ? AND Status = 2 ORDER BY Date ASC LIMIT ?")
+	db.sqlAddRSVP, err = db.Prepare("INSERT IGNORE INTO rsvps (Event, User, Timestamp) VALUES (?, ?, UTC_TIMESTAMP())")
+	db.sqlRemoveRSVP, err = db.Prepare("DELETE FROM rsvps WHERE Event = ? AND User = ?")
+	db.sqlGetRSVPs, err = db.Prepare("SELECT User FROM rsvps WHERE Event = ? ORDER BY Timestamp ASC")
+	db.sqlGetRSVPCount, err = db.Prepare("SELECT COUNT(*) FROM rsvps WHERE Event = ?")
+	db.sqlClearRSVPs, err = db.Prepare("DELETE FROM rsvps WHERE Event = ?")
+	db.sqlGetUserRSVPs, err = db.Prepare("SELECT R.Event, S.Guild, S.Date, S.Data, R.Timestamp FROM rsvps R INNER JOIN schedule S ON R.Event = S.ID WHERE R.User = ? ORDER BY S.Date ASC")
+	db.sqlForgetRSVPs, err = db.Prepare("DELETE FROM rsvps WHERE User = ?")
+	db.sqlFindEventByPost, err = db.Prepare("SELECT ID, Date, Type, Data, COALESCE(Cron, '') FROM schedule WHERE Guild = ? AND Type = 5 AND Data LIKE ?")
+	db.sqlGetUnremindedEvents, err = db.Prepare("SELECT ID, Date, Type, Data, COALESCE(Cron, '') FROM schedule WHERE Guild = ? AND Type = 5 AND Status = 0 AND Date > UTC_TIMESTAMP() AND Date <= ? AND Data NOT LIKE '%\"reminded\":true%'")
+	db.sqlRemoveBirthday, err = db.Prepare("DELETE FROM schedule WHERE Guild = ? AND (Type = 1 OR Type = 4) AND Data LIKE ?")
+	db.sqlSetPollMessage, err = db.Prepare("UPDATE polls SET Channel = ?, Message = ? WHERE ID = ?")
+	db.sqlGetPollByMessage, err = db.Prepare("SELECT ID, Name, Description, Choices, Ranked, Role, Anonymous, Closed FROM polls WHERE Guild = ? AND Message = ?")
+	db.sqlGetPollMessage, err = db.Prepare("SELECT Channel, Message FROM polls WHERE ID = ?")
+	db.sqlGetVotes, err = db.Prepare("SELECT `Option` FROM votes WHERE Poll = ? AND User = ? ORDER BY `Rank` ASC")
+	db.sqlRemoveVote, err = db.Prepare("DELETE FROM votes WHERE Poll = ? AND User = ? AND `Option` = ?")
+	db.sqlRemoveVotes, err = db.Prepare("DELETE FROM votes WHERE Poll = ? AND User = ?")
+	db.sqlGetBallots, err = db.Prepare("SELECT User, `Option` FROM votes WHERE Poll = ? ORDER BY User ASC, `Rank` ASC")
+	db.sqlGetPollByID, err = db.Prepare("SELECT ID, Name, Description, Choices, Ranked, Role, Anonymous, Closed FROM polls WHERE ID = ?")
+	db.sqlSetPollSettings, err = db.Prepare("UPDATE polls SET Choices = ?, Ranked = ?, Role = ?, Anonymous = ?, Closed = ? WHERE ID = ?")
+	db.sqlRemoveScheduleByData, err = db.Prepare("DELETE FROM schedule WHERE Guild = ? AND Type = ? AND Data = ?")
+	db.sqlGetChatlogRange, err = db.Prepare("SELECT C.ID, C.Author, C.Message, C.Timestamp, C.Attachments, D.Timestamp FROM chatlog C LEFT OUTER JOIN deletelog D ON C.ID = D.ID WHERE C.Guild = ? AND C.Channel = ? AND C.ID >= ? AND C.ID <= ? ORDER BY C.ID ASC LIMIT ?")
+	db.sqlGetOldestChatlog, err = db.Prepare("SELECT COALESCE(MIN(ID), 0) FROM chatlog WHERE Guild = ? AND Channel = ?")
 	return err
 }
 
@@ -270,8 +432,11 @@ func (db *BotDB) CheckError(name string, err error) bool {
 	return false
 }
 
-func (db *BotDB) AddMessage(id uint64, author uint64, message string, channel uint64, everyone bool, guild uint64) {
-	_, err := db.sqlAddMessage.Exec(id, author, message, channel, everyone, guild)
+func (db *BotDB) AddMessage(id uint64, author uint64, message string, channel uint64, everyone bool, guild uint64, attachments int) {
+	if attachments > 255 {
+		attachments = 255
+	}
+	_, err := db.sqlAddMessage.Exec(id, author, message, channel, everyone, guild, attachments)
 	db.CheckError("AddMessage", err)
 }
 
@@ -441,16 +606,28 @@ func (db *BotDB) GetNewestUsers(maxresults int, guild uint64) []struct {
 	return r
 }
 
-func (db *BotDB) GetRecentUsers(since time.Time, guild uint64) []*discordgo.User {
+func (db *BotDB) GetRecentUsers(since time.Time, guild uint64) []struct {
+	User      *discordgo.User
+	FirstSeen time.Time
+} {
 	q, err := db.sqlGetRecentUsers.Query(guild, since)
 	if db.CheckError("GetRecentUsers", err) {
-		return []*discordgo.User{}
+		return []struct {
+			User      *discordgo.User
+			FirstSeen time.Time
+		}{}
 	}
 	defer q.Close()
-	r := make([]*discordgo.User, 0, 2)
+	r := make([]struct {
+		User      *discordgo.User
+		FirstSeen time.Time
+	}, 0, 2)
 	for q.Next() {
-		p := &discordgo.User{}
-		if err := q.Scan(&p.ID, &p.Email, &p.Username, &p.Avatar); err == nil {
+		p := struct {
+			User      *discordgo.User
+			FirstSeen time.Time
+		}{&discordgo.User{}, time.Now()}
+		if err := q.Scan(&p.User.ID, &p.User.Email, &p.User.Username, &p.User.Avatar, &p.FirstSeen); err == nil {
 			r = append(r, p)
 		}
 	}
@@ -479,6 +656,12 @@ func (db *BotDB) Audit(ty uint8, user *discordgo.User, message string, guild uin
 	}
 }
 
+// RedactAudit replaces the message of the most recent matching audit entry from a user
+func (db *BotDB) RedactAudit(ty uint8, user uint64, guild uint64, message string, redacted string) {
+	_, err := db.sqlRedactAudit.Exec(redacted, ty, user, guild, message)
+	db.CheckError("RedactAudit", err)
+}
+
 func (db *BotDB) GetAuditRows(start uint64, end uint64, user *uint64, search string, guild uint64) []PingContext {
 	var q *sql.Rows
 	var err error
@@ -695,12 +878,189 @@ func (db *BotDB) AddScheduleRepeat(guild uint64, date time.Time, repeatinterval
 	}
 	return false
 }
+func (db *BotDB) AddScheduleCron(guild uint64, date time.Time, cron string, ty uint8, data string) bool {
+	var i int
+	err := db.sqlCountEvents.QueryRow(guild).Scan(&i)
+	if !db.CheckError("CountEvents", err) && i < 5000 {
+		_, err := db.sqlAddScheduleCron.Exec(guild, date, cron, ty, data)
+		return !db.CheckError("AddScheduleCron", err)
+	}
+	return false
+}
+
+// RemoveScheduleByData removes all events of a type whose payload is exactly the given data
+func (db *BotDB) RemoveScheduleByData(guild uint64, ty uint8, data string) int64 {
+	return db.execRowCount("RemoveScheduleByData", db.sqlRemoveScheduleByData, guild, ty, data)
+}
+func (db *BotDB) RescheduleEvent(id uint64, date time.Time) {
+	_, err := db.sqlRescheduleEvent.Exec(date, id)
+	db.CheckError("RescheduleEvent", err)
+}
+func (db *BotDB) SetEventData(id uint64, data string) {
+	_, err := db.sqlSetEventData.Exec(data, id)
+	db.CheckError("SetEventData", err)
+}
+
+// GetLegacyEvents returns all events in a guild whose data hasn't been converted to JSON yet. Legacy data can look like anything, including JSON that isn't an object, so every event has to be checked.
+func (db *BotDB) GetLegacyEvents(guild uint64) []ScheduleEvent {
+	q, err := db.sqlGetLegacyEvents.Query(guild)
+	if db.CheckError("GetLegacyEvents", err) {
+		return []ScheduleEvent{}
+	}
+	defer q.Close()
+	r := make([]ScheduleEvent, 0, 2)
+	for q.Next() {
+		p := ScheduleEvent{}
+		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data); err == nil && !isSchedulePayload(p.Data) {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// RetryEvent records that an event failed and when it should be tried again
+func (db *BotDB) RetryEvent(id uint64, attempts int, retry time.Time, lasterror string) {
+	_, err := db.sqlRetryEvent.Exec(attempts, retry, truncateString(lasterror, 255), id)
+	db.CheckError("RetryEvent", err)
+}
+
+// FailEvent marks an event as failed, so it won't be processed again until a moderator retries it
+func (db *BotDB) FailEvent(id uint64, attempts int, lasterror string) {
+	_, err := db.sqlFailEvent.Exec(attempts, truncateString(lasterror, 255), id)
+	db.CheckError("FailEvent", err)
+}
+
+// ResetEvent clears the delivery status of an event after it was successfully processed
+func (db *BotDB) ResetEvent(id uint64) {
+	_, err := db.sqlResetEvent.Exec(id)
+	db.CheckError("ResetEvent", err)
+}
+
+// RetryFailedEvents schedules failed events to be tried again right away. If id is 0, all failed events in the guild are retried.
+func (db *BotDB) RetryFailedEvents(guild uint64, id uint64) int64 {
+	return db.execRowCount("RetryFailedEvents", db.sqlRetryFailedEvents, guild, id, id)
+}
+
+func (db *BotDB) GetFailedEvents(guild uint64, maxnum int) []ScheduleEvent {
+	q, err := db.sqlGetFailedEvents.Query(guild, maxnum)
+	if db.CheckError("GetFailedEvents", err) {
+		return []ScheduleEvent{}
+	}
+	defer q.Close()
+	r := make([]ScheduleEvent, 0, 2)
+	for q.Next() {
+		p := ScheduleEvent{}
+		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data, &p.Cron, &p.Status, &p.Attempts, &p.LastError); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// AddRSVP records that a user will attend an event, and returns false if they already did
+func (db *BotDB) AddRSVP(event uint64, user uint64) bool {
+	return db.execRowCount("AddRSVP", db.sqlAddRSVP, event, user) > 0
+}
+
+// RemoveRSVP removes a user from the attendees of an event, and returns false if they weren't attending
+func (db *BotDB) RemoveRSVP(event uint64, user uint64) bool {
+	return db.execRowCount("RemoveRSVP", db.sqlRemoveRSVP, event, user) > 0
+}
+
+func (db *BotDB) GetRSVPs(event uint64) []uint64 {
+	q, err := db.sqlGetRSVPs.Query(event)
+	if db.CheckError("GetRSVPs", err) {
+		return []uint64{}
+	}
+	defer q.Close()
+	r := make([]uint64, 0, 4)
+	for q.Next() {
+		var p uint64
+		if err := q.Scan(&p); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+func (db *BotDB) GetRSVPCount(event uint64) int {
+	var i int
+	err := db.sqlGetRSVPCount.QueryRow(event).Scan(&i)
+	db.CheckError("GetRSVPCount", err)
+	return i
+}
+
+func (db *BotDB) ClearRSVPs(event uint64) {
+	_, err := db.sqlClearRSVPs.Exec(event)
+	db.CheckError("ClearRSVPs", err)
+}
+
+type UserRSVP struct {
+	Event     uint64
+	Guild     uint64
+	Date      time.Time
+	Data      string
+	Timestamp time.Time
+}
+
+// GetUserRSVPs returns every event a user is attending
+func (db *BotDB) GetUserRSVPs(user uint64) []UserRSVP {
+	q, err := db.sqlGetUserRSVPs.Query(user)
+	if db.CheckError("GetUserRSVPs", err) {
+		return []UserRSVP{}
+	}
+	defer q.Close()
+	r := make([]UserRSVP, 0, 2)
+	for q.Next() {
+		p := UserRSVP{}
+		if err := q.Scan(&p.Event, &p.Guild, &p.Date, &p.Data, &p.Timestamp); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// FindEventByPost returns the community event whose announcement is the given message
+func (db *BotDB) FindEventByPost(guild uint64, post string) *ScheduleEvent {
+	e := &ScheduleEvent{}
+	err := db.sqlFindEventByPost.QueryRow(guild, "%\"post\":\""+post+"\"%").Scan(&e.ID, &e.Date, &e.Type, &e.Data, &e.Cron)
+	if err == sql.ErrNoRows || db.CheckError("FindEventByPost", err) {
+		return nil
+	}
+	return e
+}
+
+// GetUnremindedEvents returns community events that start before the given time and haven't sent reminders to their attendees yet
+func (db *BotDB) GetUnremindedEvents(guild uint64, before time.Time) []ScheduleEvent {
+	q, err := db.sqlGetUnremindedEvents.Query(guild, before)
+	if db.CheckError("GetUnremindedEvents", err) {
+		return []ScheduleEvent{}
+	}
+	defer q.Close()
+	r := make([]ScheduleEvent, 0, 2)
+	for q.Next() {
+		p := ScheduleEvent{}
+		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data, &p.Cron); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// RemoveBirthday deletes the birthday of a user on a guild, along with the event that removes their birthday role
+func (db *BotDB) RemoveBirthday(guild uint64, user string) int64 {
+	return db.execRowCount("RemoveBirthday", db.sqlRemoveBirthday, guild, scheduleUserPattern(user))
+}
 
 type ScheduleEvent struct {
-	ID   uint64
-	Date time.Time
-	Type uint8
-	Data string
+	ID        uint64
+	Date      time.Time
+	Type      uint8
+	Data      string
+	Cron      string
+	Status    uint8
+	Attempts  int
+	LastError string
 }
 
 func (db *BotDB) GetSchedule(guild uint64) []ScheduleEvent {
@@ -712,7 +1072,7 @@ func (db *BotDB) GetSchedule(guild uint64) []ScheduleEvent {
 	r := make([]ScheduleEvent, 0, 2)
 	for q.Next() {
 		p := ScheduleEvent{}
-		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data); err == nil {
+		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data, &p.Cron, &p.Status, &p.Attempts); err == nil {
 			r = append(r, p)
 		}
 	}
@@ -721,7 +1081,7 @@ func (db *BotDB) GetSchedule(guild uint64) []ScheduleEvent {
 
 func (db *BotDB) GetEvent(id uint64) *ScheduleEvent {
 	e := &ScheduleEvent{}
-	err := db.sqlGetEvent.QueryRow(id).Scan(&e.ID, &e.Date, &e.Type, &e.Data)
+	err := db.sqlGetEvent.QueryRow(id).Scan(&e.ID, &e.Date, &e.Type, &e.Data, &e.Cron)
 	if err == sql.ErrNoRows || db.CheckError("GetEvent", err) {
 		return nil
 	}
@@ -737,7 +1097,7 @@ func (db *BotDB) GetEvents(guild uint64, maxnum int) []ScheduleEvent {
 	r := make([]ScheduleEvent, 0, 2)
 	for q.Next() {
 		p := ScheduleEvent{}
-		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data); err == nil {
+		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data, &p.Cron); err == nil {
 			r = append(r, p)
 		}
 	}
@@ -753,7 +1113,7 @@ func (db *BotDB) GetEventsByType(guild uint64, ty uint8, maxnum int) []ScheduleE
 	r := make([]ScheduleEvent, 0, 2)
 	for q.Next() {
 		p := ScheduleEvent{}
-		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data); err == nil {
+		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data, &p.Cron); err == nil {
 			r = append(r, p)
 		}
 	}
@@ -764,13 +1124,13 @@ func (db *BotDB) GetNextEvent(guild uint64, ty uint8) ScheduleEvent {
 	p := ScheduleEvent{}
 	err := db.sqlGetNextEvent.QueryRow(guild, ty).Scan(&p.ID, &p.Date, &p.Type, &p.Data)
 	if err == sql.ErrNoRows || db.CheckError("GetNextEvent", err) {
-		return ScheduleEvent{0, time.Now().UTC(), 0, ""}
+		return ScheduleEvent{Date: time.Now().UTC()}
 	}
 	return p
 }
 
 func (db *BotDB) GetReminders(guild uint64, id string, maxnum int) []ScheduleEvent {
-	q, err := db.sqlGetReminders.Query(guild, id+"|%", maxnum)
+	q, err := db.sqlGetReminders.Query(guild, scheduleUserPattern(id), maxnum)
 	if db.CheckError("GetReminders", err) {
 		return []ScheduleEvent{}
 	}
@@ -778,7 +1138,7 @@ func (db *BotDB) GetReminders(guild uint64, id string, maxnum int) []ScheduleEve
 	r := make([]ScheduleEvent, 0, 2)
 	for q.Next() {
 		p := ScheduleEvent{}
-		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data); err == nil {
+		if err := q.Scan(&p.ID, &p.Date, &p.Type, &p.Data, &p.Cron); err == nil {
 			r = append(r, p)
 		}
 	}
@@ -787,7 +1147,7 @@ func (db *BotDB) GetReminders(guild uint64, id string, maxnum int) []ScheduleEve
 
 func (db *BotDB) GetUnsilenceDate(guild uint64, id uint64) *time.Time {
 	var timestamp time.Time
-	err := db.sqlGetUnsilenceDate.QueryRow(guild, id).Scan(&timestamp)
+	err := db.sqlGetUnsilenceDate.QueryRow(guild, EncodeSchedulePayload(ScheduleUserPayload{SBitoa(id)})).Scan(&timestamp)
 	if err == sql.ErrNoRows || db.CheckError("GetUnsilenceDate", err) {
 		return nil
 	}
@@ -874,7 +1234,7 @@ func (db *BotDB) GetUserGuilds(user uint64) []uint64 {
 
 func (db *BotDB) FindEvent(user string, guild uint64, ty uint8) *uint64 {
 	var id uint64
-	err := db.sqlFindEvent.QueryRow(ty, user, guild).Scan(&id)
+	err := db.sqlFindEvent.QueryRow(ty, EncodeSchedulePayload(ScheduleUserPayload{user}), guild).Scan(&id)
 	if err == sql.ErrNoRows || db.CheckError("FindEvent", err) {
 		return nil
 	}
@@ -994,9 +1354,26 @@ func (db *BotDB) AppendOption(poll uint64, option string) error {
 	return err
 }
 
-func (db *BotDB) AddVote(user uint64, poll uint64, option uint64) error {
-	_, err := db.sqlAddVote.Exec(poll, user, option, option)
-	db.CheckError("AddVote", err)
+// SetVotes replaces a user's vote in a poll with votes for the given options, ranked in the order they are given. The old vote is only replaced if every new option could be added.
+func (db *BotDB) SetVotes(user uint64, poll uint64, options []uint64) error {
+	tx, err := db.db.Begin()
+	if db.CheckError("SetVotes", err) {
+		return err
+	}
+	_, err = tx.Stmt(db.sqlRemoveVotes).Exec(poll, user)
+	if db.CheckError("RemoveVotes", err) {
+		tx.Rollback()
+		return err
+	}
+	for i, v := range options {
+		_, err = tx.Stmt(db.sqlAddVote).Exec(poll, user, v, i+1)
+		if db.CheckError("AddVote", err) {
+			tx.Rollback()
+			return err
+		}
+	}
+	err = tx.Commit()
+	db.CheckError("SetVotes", err)
 	return err
 }
 
@@ -1015,6 +1392,110 @@ func (db *BotDB) CheckOption(poll uint64, option uint64) bool {
 	return true
 }
 
+// SetPollMessage remembers the message a poll was posted in, so reactions to it count as votes
+func (db *BotDB) SetPollMessage(poll uint64, channel uint64, message uint64) error {
+	_, err := db.sqlSetPollMessage.Exec(channel, message, poll)
+	db.CheckError("SetPollMessage", err)
+	return err
+}
+
+// PollStruct is a poll along with the settings that decide how votes are cast and counted. Choices is the number of options a member may pick, or 0 if they may pick any number of them. Ranked polls are tallied by instant runoff, and the voters of anonymous polls are hidden from everyone.
+type PollStruct struct {
+	ID          uint64
+	Name        string
+	Description string
+	Choices     int
+	Ranked      bool
+	Role        uint64
+	Anonymous   bool
+	Closed      bool
+}
+
+func (db *BotDB) parsePoll(q *sql.Row, fn string) *PollStruct {
+	p := &PollStruct{}
+	err := q.Scan(&p.ID, &p.Name, &p.Description, &p.Choices, &p.Ranked, &p.Role, &p.Anonymous, &p.Closed)
+	if err == sql.ErrNoRows || db.CheckError(fn, err) {
+		return nil
+	}
+	return p
+}
+
+// GetPollByID returns a poll, or nil if it doesn't exist
+func (db *BotDB) GetPollByID(poll uint64) *PollStruct {
+	return db.parsePoll(db.sqlGetPollByID.QueryRow(poll), "GetPollByID")
+}
+
+// GetPollByMessage returns the poll that was posted in the given message, or nil if there isn't one
+func (db *BotDB) GetPollByMessage(guild uint64, message uint64) *PollStruct {
+	return db.parsePoll(db.sqlGetPollByMessage.QueryRow(guild, message), "GetPollByMessage")
+}
+
+// SetPollSettings saves the settings of a poll
+func (db *BotDB) SetPollSettings(p *PollStruct) error {
+	_, err := db.sqlSetPollSettings.Exec(p.Choices, p.Ranked, p.Role, p.Anonymous, p.Closed, p.ID)
+	db.CheckError("SetPollSettings", err)
+	return err
+}
+
+// GetPollMessage returns the channel and message a poll was posted in, or 0 if it was never posted
+func (db *BotDB) GetPollMessage(poll uint64) (uint64, uint64) {
+	var channel, message uint64
+	err := db.sqlGetPollMessage.QueryRow(poll).Scan(&channel, &message)
+	if err == sql.ErrNoRows || db.CheckError("GetPollMessage", err) {
+		return 0, 0
+	}
+	return channel, message
+}
+
+// GetVotes returns the options a user voted for in a poll, from highest to lowest rank
+func (db *BotDB) GetVotes(user uint64, poll uint64) []uint64 {
+	q, err := db.sqlGetVotes.Query(poll, user)
+	if db.CheckError("GetVotes", err) {
+		return []uint64{}
+	}
+	defer q.Close()
+	r := make([]uint64, 0, 2)
+	for q.Next() {
+		var option uint64
+		if err := q.Scan(&option); err == nil {
+			r = append(r, option)
+		}
+	}
+	return r
+}
+
+// PollBallot is everything a single user voted for in a poll, from highest to lowest rank
+type PollBallot struct {
+	User    uint64
+	Options []uint64
+}
+
+// GetBallots returns the votes of every user who voted in a poll
+func (db *BotDB) GetBallots(poll uint64) []PollBallot {
+	q, err := db.sqlGetBallots.Query(poll)
+	if db.CheckError("GetBallots", err) {
+		return []PollBallot{}
+	}
+	defer q.Close()
+	r := make([]PollBallot, 0, 2)
+	for q.Next() {
+		var user, option uint64
+		if err := q.Scan(&user, &option); err != nil {
+			continue
+		}
+		if len(r) == 0 || r[len(r)-1].User != user {
+			r = append(r, PollBallot{User: user})
+		}
+		r[len(r)-1].Options = append(r[len(r)-1].Options, option)
+	}
+	return r
+}
+
+// RemoveVote removes a user's vote from a poll, but only if they voted for the given option
+func (db *BotDB) RemoveVote(user uint64, poll uint64, option uint64) int64 {
+	return db.execRowCount("RemoveVote", db.sqlRemoveVote, poll, user, option)
+}
+
 func (db *BotDB) SentMessage(user uint64, guild uint64) error {
 	_, err := db.sqlSentMessage.Exec(user, guild)
 	db.CheckError("SentMessage", err)
@@ -1036,3 +1517,618 @@ func (db *BotDB) GetNewcomers(lookback int, guild uint64) []uint64 {
 	}
 	return r
 }
+
+const (
+	APPEAL_TYPE_SILENCE = iota
+	APPEAL_TYPE_BAN     = iota
+)
+
+const (
+	APPEAL_STATUS_OPEN     = iota // Waiting for the user to send their appeal
+	APPEAL_STATUS_PENDING  = iota // Waiting for a moderator to accept or deny the appeal
+	APPEAL_STATUS_ACCEPTED = iota
+	APPEAL_STATUS_DENIED   = iota
+)
+
+type AppealCase struct {
+	ID        uint64
+	Guild     uint64
+	User      uint64
+	Type      uint8
+	Reason    string
+	Appeal    string
+	Status    uint8
+	Timestamp time.Time
+}
+
+func (db *BotDB) AddAppeal(guild uint64, user uint64, ty uint8, reason string) uint64 {
+	res, err := db.sqlAddAppeal.Exec(guild, user, ty, reason)
+	if db.CheckError("AddAppeal", err) {
+		return 0
+	}
+	id, err := res.LastInsertId()
+	if db.CheckError("AddAppeal", err) {
+		return 0
+	}
+	return uint64(id)
+}
+
+func (db *BotDB) parseAppeal(row *sql.Row, name string) *AppealCase {
+	p := &AppealCase{}
+	var appeal sql.NullString
+	err := row.Scan(&p.ID, &p.Guild, &p.User, &p.Type, &p.Reason, &appeal, &p.Status, &p.Timestamp)
+	if err == sql.ErrNoRows || db.CheckError(name, err) {
+		return nil
+	}
+	p.Appeal = appeal.String
+	return p
+}
+
+func (db *BotDB) GetAppeal(id uint64, guild uint64) *AppealCase {
+	return db.parseAppeal(db.sqlGetAppeal.QueryRow(id, guild), "GetAppeal")
+}
+
+func (db *BotDB) GetOpenAppeal(user uint64) *AppealCase {
+	return db.parseAppeal(db.sqlGetOpenAppeal.QueryRow(user), "GetOpenAppeal")
+}
+
+func (db *BotDB) SetAppealMessage(id uint64, appeal string) error {
+	_, err := db.sqlSetAppealMessage.Exec(appeal, id)
+	db.CheckError("SetAppealMessage", err)
+	return err
+}
+
+func (db *BotDB) SetAppealStatus(id uint64, status uint8) error {
+	_, err := db.sqlSetAppealStatus.Exec(status, id)
+	db.CheckError("SetAppealStatus", err)
+	return err
+}
+
+func (db *BotDB) RemoveAppeal(id uint64) error {
+	_, err := db.sqlRemoveAppeal.Exec(id)
+	db.CheckError("RemoveAppeal", err)
+	return err
+}
+
+type ChatMessage struct {
+	ID      uint64
+	Author  uint64
+	Message string
+	Channel uint64
+}
+
+// GetChatlog returns the most recent messages in a guild, optionally restricted to a single user, in chronological order
+func (db *BotDB) GetChatlog(guild uint64, user *uint64, maxnum int) []ChatMessage {
+	var q *sql.Rows
+	var err error
+	if user == nil {
+		q, err = db.sqlGetChatlog.Query(guild, maxnum)
+	} else {
+		q, err = db.sqlGetChatlogUser.Query(guild, *user, maxnum)
+	}
+	if db.CheckError("GetChatlog", err) {
+		return []ChatMessage{}
+	}
+	defer q.Close()
+	r := make([]ChatMessage, 0, maxnum)
+	for q.Next() {
+		p := ChatMessage{}
+		if err := q.Scan(&p.ID, &p.Author, &p.Message, &p.Channel); err == nil {
+			r = append(r, p)
+		}
+	}
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return r
+}
+
+// MessageVersion is a single version of a message in the chatlog, and when it was posted or deleted
+type MessageVersion struct {
+	ID          uint64
+	Author      uint64
+	Channel     uint64
+	Message     string
+	Timestamp   time.Time
+	Deleted     *time.Time
+	Attachments int
+}
+
+// GetChatMessage returns the current version of a message in the chatlog, including when it was deleted, or nil if it doesn't exist
+func (db *BotDB) GetChatMessage(id uint64, guild uint64) *MessageVersion {
+	m := &MessageVersion{ID: id}
+	err := db.sqlGetChatMessage.QueryRow(id, guild).Scan(&m.Author, &m.Message, &m.Timestamp, &m.Channel, &m.Deleted)
+	if err == sql.ErrNoRows || db.CheckError("GetChatMessage", err) {
+		return nil
+	}
+	return m
+}
+
+// GetMessageHistory returns all previous versions of a message from the editlog, oldest first
+func (db *BotDB) GetMessageHistory(id uint64, guild uint64) []MessageVersion {
+	q, err := db.sqlGetMessageHistory.Query(id, guild)
+	if db.CheckError("GetMessageHistory", err) {
+		return []MessageVersion{}
+	}
+	defer q.Close()
+	r := make([]MessageVersion, 0, 2)
+	for q.Next() {
+		p := MessageVersion{ID: id}
+		if err := q.Scan(&p.Message, &p.Timestamp); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// AddDeleted records that a message in the chatlog was deleted
+func (db *BotDB) AddDeleted(id uint64) {
+	_, err := db.sqlAddDeleted.Exec(id)
+	db.CheckError("AddDeleted", err)
+}
+
+// RemoveMessage deletes a message from the chatlog, along with its edits and deletion
+func (db *BotDB) RemoveMessage(id uint64) {
+	_, err := db.sqlRemoveMessage.Exec(id)
+	db.CheckError("RemoveMessage", err)
+}
+
+// GetDeleted returns deleted messages in a channel, optionally restricted to a single user, most recently deleted first
+func (db *BotDB) GetDeleted(guild uint64, channel uint64, user *uint64, maxnum int, offset int) []MessageVersion {
+	var q *sql.Rows
+	var err error
+	if user == nil {
+		q, err = db.sqlGetDeleted.Query(guild, channel, maxnum, offset)
+	} else {
+		q, err = db.sqlGetDeletedUser.Query(guild, channel, *user, maxnum, offset)
+	}
+	if db.CheckError("GetDeleted", err) {
+		return []MessageVersion{}
+	}
+	defer q.Close()
+	r := make([]MessageVersion, 0, maxnum)
+	for q.Next() {
+		p := MessageVersion{Channel: channel}
+		var deleted time.Time
+		if err := q.Scan(&p.ID, &p.Author, &p.Message, &p.Timestamp, &deleted); err == nil {
+			p.Deleted = &deleted
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetChatlogRange returns every message in a channel whose ID lies between the two given IDs, oldest first, including when they were deleted
+func (db *BotDB) GetChatlogRange(guild uint64, channel uint64, from uint64, to uint64, maxnum int) []MessageVersion {
+	q, err := db.sqlGetChatlogRange.Query(guild, channel, from, to, maxnum)
+	if db.CheckError("GetChatlogRange", err) {
+		return []MessageVersion{}
+	}
+	defer q.Close()
+	r := make([]MessageVersion, 0, 50)
+	for q.Next() {
+		p := MessageVersion{Channel: channel}
+		if err := q.Scan(&p.ID, &p.Author, &p.Message, &p.Timestamp, &p.Attachments, &p.Deleted); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetOldestChatlog returns the ID of the oldest message in a channel that is still in the chat log, or 0 if there are none
+func (db *BotDB) GetOldestChatlog(guild uint64, channel uint64) uint64 {
+	var i uint64
+	err := db.sqlGetOldestChatlog.QueryRow(guild, channel).Scan(&i)
+	db.CheckError("GetOldestChatlog", err)
+	return i
+}
+
+// ActivityBucket is the number of messages sent during a 15 minute window starting at Start
+type ActivityBucket struct {
+	Start time.Time
+	Count uint64
+}
+
+// StatsCount pairs a user or channel ID with the number of messages associated with it
+type StatsCount struct {
+	ID    uint64
+	Count uint64
+}
+
+// GetActivity returns how many messages were sent in each 15 minute window since the given time, optionally restricted to a single channel or user. Windows without any messages are omitted.
+func (db *BotDB) GetActivity(guild uint64, channel uint64, user uint64, since time.Time) []ActivityBucket {
+	q, err := db.sqlGetActivity.Query(since, guild, since, channel, channel, user, user)
+	if db.CheckError("GetActivity", err) {
+		return []ActivityBucket{}
+	}
+	defer q.Close()
+	r := make([]ActivityBucket, 0, 96)
+	for q.Next() {
+		var window int64
+		p := ActivityBucket{}
+		if err := q.Scan(&window, &p.Count); err == nil {
+			p.Start = since.Add(time.Duration(window) * 15 * time.Minute)
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+func (db *BotDB) parseStatsCounts(q *sql.Rows) []StatsCount {
+	r := make([]StatsCount, 0, 5)
+	for q.Next() {
+		p := StatsCount{}
+		if err := q.Scan(&p.ID, &p.Count); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetTopPosters returns the users that sent the most messages since the given time, optionally restricted to a single channel
+func (db *BotDB) GetTopPosters(guild uint64, channel uint64, since time.Time, maxnum int) []StatsCount {
+	q, err := db.sqlGetTopPosters.Query(guild, since, channel, channel, maxnum)
+	if db.CheckError("GetTopPosters", err) {
+		return []StatsCount{}
+	}
+	defer q.Close()
+	return db.parseStatsCounts(q)
+}
+
+// GetTopChannels returns the channels with the most messages since the given time, optionally restricted to a single user
+func (db *BotDB) GetTopChannels(guild uint64, user uint64, since time.Time, maxnum int) []StatsCount {
+	q, err := db.sqlGetTopChannels.Query(guild, since, user, user, maxnum)
+	if db.CheckError("GetTopChannels", err) {
+		return []StatsCount{}
+	}
+	defer q.Close()
+	return db.parseStatsCounts(q)
+}
+
+// GetJoinCounts returns how many members joined a guild since the given time, how many of them sent at least one message, and how many of them have left again
+func (db *BotDB) GetJoinCounts(guild uint64, since time.Time) (uint64, uint64, uint64) {
+	var joins, posted, left uint64
+	err := db.sqlGetJoinCounts.QueryRow(guild, since, guild, since).Scan(&joins, &posted, &left)
+	if err == sql.ErrNoRows || db.CheckError("GetJoinCounts", err) {
+		return 0, 0, 0
+	}
+	return joins, posted, left
+}
+
+// GetLeaveCount returns how many members left a guild since the given time
+func (db *BotDB) GetLeaveCount(guild uint64, since time.Time) uint64 {
+	var count uint64
+	err := db.sqlGetLeaveCount.QueryRow(guild, since).Scan(&count)
+	if err == sql.ErrNoRows || db.CheckError("GetLeaveCount", err) {
+		return 0
+	}
+	return count
+}
+
+// GetFirstMessageDelays returns how long each member that joined since the given time took to send their first message, shortest first
+func (db *BotDB) GetFirstMessageDelays(guild uint64, since time.Time) []time.Duration {
+	q, err := db.sqlGetFirstMessageDelays.Query(guild, since, guild, since)
+	if db.CheckError("GetFirstMessageDelays", err) {
+		return []time.Duration{}
+	}
+	defer q.Close()
+	r := make([]time.Duration, 0, 10)
+	for q.Next() {
+		var seconds int64
+		if err := q.Scan(&seconds); err == nil {
+			r = append(r, time.Duration(seconds)*time.Second)
+		}
+	}
+	return r
+}
+
+// GetLastMessage returns when a user last sent a message in a guild that is still in the chatlog, or nil if there isn't one
+func (db *BotDB) GetLastMessage(user uint64, guild uint64) *time.Time {
+	var last *time.Time
+	err := db.sqlGetLastMessage.QueryRow(guild, user).Scan(&last)
+	if err == sql.ErrNoRows || db.CheckError("GetLastMessage", err) {
+		return nil
+	}
+	return last
+}
+
+// GetAuditMentions returns the most recent commands in the audit log that mention a user, either by pinging them or by name
+func (db *BotDB) GetAuditMentions(user uint64, username string, guild uint64, maxnum int) []PingContext {
+	q, err := db.sqlGetAuditMentions.Query(AUDIT_TYPE_COMMAND, guild, "%"+SBitoa(user)+"%", "%"+escapeLike(username)+"%", maxnum)
+	if db.CheckError("GetAuditMentions", err) {
+		return []PingContext{}
+	}
+	defer q.Close()
+	r := make([]PingContext, 0, maxnum)
+	for q.Next() {
+		p := PingContext{}
+		var id uint64
+		if err := q.Scan(&p.Author, &p.Message, &p.Timestamp, &id); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+const (
+	NAMELOG_USERNAME = 0
+	NAMELOG_NICKNAME = 1
+	NAMELOG_AVATAR   = 2
+)
+
+// NameChange is a single username, nickname or avatar change from the namelog
+type NameChange struct {
+	Guild     uint64
+	Type      uint8
+	Old       string
+	New       string
+	Timestamp time.Time
+}
+
+func (db *BotDB) parseNameChanges(q *sql.Rows) []NameChange {
+	r := make([]NameChange, 0, 5)
+	for q.Next() {
+		p := NameChange{}
+		if err := q.Scan(&p.Guild, &p.Type, &p.Old, &p.New, &p.Timestamp); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetNameHistory returns the most recent username and avatar changes of a user, along with their nickname changes in the given guild, newest first
+func (db *BotDB) GetNameHistory(user uint64, guild uint64, maxnum int) []NameChange {
+	q, err := db.sqlGetNameHistory.Query(user, guild, maxnum)
+	if db.CheckError("GetNameHistory", err) {
+		return []NameChange{}
+	}
+	defer q.Close()
+	return db.parseNameChanges(q)
+}
+
+// GetUserNameLog returns every username, nickname and avatar change of a user across all guilds, oldest first
+func (db *BotDB) GetUserNameLog(user uint64) []NameChange {
+	q, err := db.sqlGetUserNameLog.Query(user)
+	if db.CheckError("GetUserNameLog", err) {
+		return []NameChange{}
+	}
+	defer q.Close()
+	return db.parseNameChanges(q)
+}
+
+func (db *BotDB) execRowCount(name string, stmt *sql.Stmt, args ...interface{}) int64 {
+	r, err := stmt.Exec(args...)
+	if db.CheckError(name, err) {
+		return 0
+	}
+	n, err := r.RowsAffected()
+	if db.CheckError(name, err) {
+		return 0
+	}
+	return n
+}
+
+// PurgeChatlog deletes all chatlog entries in a guild older than the given number of days, along with their edits, and returns how many were deleted
+func (db *BotDB) PurgeChatlog(guild uint64, days int) int64 {
+	return db.execRowCount("PurgeChatlog", db.sqlPurgeChatlog, guild, days)
+}
+
+// PurgeEditlog deletes all editlog entries in a guild older than the given number of days and returns how many were deleted
+func (db *BotDB) PurgeEditlog(guild uint64, days int) int64 {
+	return db.execRowCount("PurgeEditlog", db.sqlPurgeEditlog, guild, days)
+}
+
+// PurgeDebuglog deletes all debuglog entries in a guild older than the given number of days and returns how many were deleted
+func (db *BotDB) PurgeDebuglog(guild uint64, days int) int64 {
+	return db.execRowCount("PurgeDebuglog", db.sqlPurgeDebuglog, guild, days)
+}
+
+// PurgeUserLogs deletes every chatlog and editlog entry from a user in a guild and anonymizes their debuglog entries, which are kept as an audit trail of moderator actions. Returns how many rows were changed in each
+func (db *BotDB) PurgeUserLogs(user uint64, guild uint64) (int64, int64, int64) {
+	edits := db.execRowCount("PurgeUserEditlog", db.sqlPurgeUserEditlog, guild, user)
+	chat := db.execRowCount("PurgeUserChatlog", db.sqlPurgeUserChatlog, guild, user)
+	debug := db.execRowCount("PurgeUserDebuglog", db.sqlPurgeUserDebuglog, guild, user)
+	return chat, edits, debug
+}
+
+// UserMembership is a row from the members table, used when exporting a user's data
+type UserMembership struct {
+	Guild        uint64
+	FirstSeen    time.Time
+	Nickname     string
+	FirstMessage *time.Time
+}
+
+// UserDeparture records when a user left a guild, used when exporting a user's data
+type UserDeparture struct {
+	Guild        uint64
+	FirstSeen    time.Time
+	FirstMessage *time.Time
+	Left         time.Time
+}
+
+// UserMessage is a chatlog or editlog entry written by a user, used when exporting a user's data
+type UserMessage struct {
+	ID        uint64
+	Guild     uint64
+	Channel   uint64
+	Message   string
+	Timestamp time.Time
+}
+
+// UserEvent is a scheduled event belonging to a user, along with the guild it belongs to
+type UserEvent struct {
+	ScheduleEvent
+	Guild uint64
+}
+
+// UserVote is a vote a user has cast in a poll
+type UserVote struct {
+	Guild  uint64
+	Poll   string
+	Option string
+}
+
+// GetAllAliases returns every alias a user has ever had, unlike GetAliases, which only returns the 10 most used ones
+func (db *BotDB) GetAllAliases(user uint64) []string {
+	q, err := db.sqlGetAllAliases.Query(user)
+	if db.CheckError("GetAllAliases", err) {
+		return []string{}
+	}
+	defer q.Close()
+	return db.ParseStringResults(q)
+}
+
+// GetUserMemberships returns the member information stored for a user in every guild they have been seen in
+func (db *BotDB) GetUserMemberships(user uint64) []UserMembership {
+	q, err := db.sqlGetUserMemberships.Query(user)
+	if db.CheckError("GetUserMemberships", err) {
+		return []UserMembership{}
+	}
+	defer q.Close()
+	r := make([]UserMembership, 0, 2)
+	for q.Next() {
+		p := UserMembership{}
+		if err := q.Scan(&p.Guild, &p.FirstSeen, &p.Nickname, &p.FirstMessage); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetUserDepartures returns every time a user left a guild
+func (db *BotDB) GetUserDepartures(user uint64) []UserDeparture {
+	q, err := db.sqlGetUserDepartures.Query(user)
+	if db.CheckError("GetUserDepartures", err) {
+		return []UserDeparture{}
+	}
+	defer q.Close()
+	r := make([]UserDeparture, 0, 1)
+	for q.Next() {
+		p := UserDeparture{}
+		if err := q.Scan(&p.Guild, &p.FirstSeen, &p.FirstMessage, &p.Left); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+func (db *BotDB) parseUserMessages(q *sql.Rows) []UserMessage {
+	r := make([]UserMessage, 0, 10)
+	for q.Next() {
+		p := UserMessage{}
+		if err := q.Scan(&p.ID, &p.Guild, &p.Channel, &p.Message, &p.Timestamp); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetUserChatlog returns every message in the chatlog written by a user, across all guilds
+func (db *BotDB) GetUserChatlog(user uint64) []UserMessage {
+	q, err := db.sqlGetUserChatlog.Query(user)
+	if db.CheckError("GetUserChatlog", err) {
+		return []UserMessage{}
+	}
+	defer q.Close()
+	return db.parseUserMessages(q)
+}
+
+// GetUserEditlog returns every previous version of a message written by a user, across all guilds
+func (db *BotDB) GetUserEditlog(user uint64) []UserMessage {
+	q, err := db.sqlGetUserEditlog.Query(user)
+	if db.CheckError("GetUserEditlog", err) {
+		return []UserMessage{}
+	}
+	defer q.Close()
+	return db.parseUserMessages(q)
+}
+
+// GetUserSchedule returns every scheduled event that refers to a user, including their reminders, across all guilds
+func (db *BotDB) GetUserSchedule(user uint64) []UserEvent {
+	q, err := db.sqlGetUserSchedule.Query(scheduleUserPattern(SBitoa(user)))
+	if db.CheckError("GetUserSchedule", err) {
+		return []UserEvent{}
+	}
+	defer q.Close()
+	r := make([]UserEvent, 0, 2)
+	for q.Next() {
+		p := UserEvent{}
+		if err := q.Scan(&p.ID, &p.Guild, &p.Date, &p.Type, &p.Data); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetUserVotes returns every vote a user has cast
+func (db *BotDB) GetUserVotes(user uint64) []UserVote {
+	q, err := db.sqlGetUserVotes.Query(user)
+	if db.CheckError("GetUserVotes", err) {
+		return []UserVote{}
+	}
+	defer q.Close()
+	r := make([]UserVote, 0, 2)
+	for q.Next() {
+		p := UserVote{}
+		if err := q.Scan(&p.Guild, &p.Poll, &p.Option); err == nil {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// GetUserAppeals returns every appeal case involving a user, across all guilds
+func (db *BotDB) GetUserAppeals(user uint64) []AppealCase {
+	q, err := db.sqlGetUserAppeals.Query(user)
+	if db.CheckError("GetUserAppeals", err) {
+		return []AppealCase{}
+	}
+	defer q.Close()
+	r := make([]AppealCase, 0, 1)
+	for q.Next() {
+		p := AppealCase{}
+		var appeal sql.NullString
+		if err := q.Scan(&p.ID, &p.Guild, &p.User, &p.Type, &p.Reason, &appeal, &p.Status, &p.Timestamp); err == nil {
+			p.Appeal = appeal.String
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
+// ForgottenRows counts how many rows ForgetUser removed or anonymized in each table
+type ForgottenRows struct {
+	Chatlog  int64
+	Editlog  int64
+	Debuglog int64
+	Aliases  int64
+	Names    int64
+	Members  int64
+	Departed int64
+	Votes    int64
+	Appeals  int64
+	Schedule int64
+	RSVPs    int64
+	User     int64
+}
+
+// ForgetUser deletes everything stored about a user across all guilds. Debuglog entries are kept for auditing, but no longer refer to the user.
+// Pending unbans and unsilences are left in the schedule, because removing them would make a temporary punishment permanent.
+func (db *BotDB) ForgetUser(user uint64) ForgottenRows {
+	id := SBitoa(user)
+	r := ForgottenRows{}
+	r.Editlog = db.execRowCount("ForgetEditlog", db.sqlForgetEditlog, user)
+	r.Chatlog = db.execRowCount("ForgetChatlog", db.sqlForgetChatlog, user)
+	r.Debuglog = db.execRowCount("ForgetDebuglog", db.sqlForgetDebuglog, user)
+	r.Aliases = db.execRowCount("ForgetAliases", db.sqlForgetAliases, user)
+	r.Names = db.execRowCount("ForgetNameLog", db.sqlForgetNameLog, user)
+	r.Members = db.execRowCount("ForgetMembers", db.sqlForgetMembers, user)
+	r.Departed = db.execRowCount("ForgetDepartures", db.sqlForgetDepartures, user) // deleting the members above also records them as departures, so this has to happen afterwards
+	r.Votes = db.execRowCount("ForgetVotes", db.sqlForgetVotes, user)
+	r.Appeals = db.execRowCount("ForgetAppeals", db.sqlForgetAppeals, user)
+	r.Schedule = db.execRowCount("ForgetSchedule", db.sqlForgetSchedule, scheduleUserPattern(id))
+	r.RSVPs = db.execRowCount("ForgetRSVPs", db.sqlForgetRSVPs, user)
+	r.User = db.execRowCount("ForgetUser", db.sqlForgetUser, user)
+	return r
+}
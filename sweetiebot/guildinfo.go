@@ -25,16 +25,16 @@ type GuildInfo struct {
 	Name         string // Cache the name to reduce locking
 	OwnerID      string
 	lastlogerr   int64
+	lastpurge    int64
 	commandLock  sync.RWMutex
 	commandLast  map[string]map[string]int64
 	commandlimit *SaturationLimit
 	config       BotConfig
+	configLock   sync.RWMutex // Only needed where the config is accessed outside of discord events, like the calendar server
 	emotemodule  *EmoteModule
 	hooks        moduleHooks
 	modules      []Module
 	commands     map[string]Command
-	lockdown     discordgo.VerificationLevel // if -1 no lockdown was initiated, otherwise remembers the previous lockdown setting
-	lastlockdown time.Time
 }
 
 // AddCommand adds a command to the guild
@@ -66,6 +66,8 @@ func deleteFromMapReflect(f reflect.Value, k string) string {
 
 // SetConfig sets the given config option with the given value along with any extra parameters
 func (info *GuildInfo) SetConfig(name string, value string, extra ...string) (string, bool) {
+	info.configLock.Lock()
+	defer info.configLock.Unlock()
 	names := strings.SplitN(strings.ToLower(name), ".", 3)
 	t := reflect.ValueOf(&info.config).Elem()
 	for i := 0; i < t.NumField(); i++ {
@@ -76,7 +78,7 @@ func (info *GuildInfo) SetConfig(name string, value string, extra ...string) (st
 			switch t.Field(i).Kind() {
 			case reflect.Struct:
 				for j := 0; j < t.Field(i).NumField(); j++ {
-					if strings.ToLower(t.Field(i).Type().Field(j).Name) == names[1] {
+					if strings.ToLower(t.Field(i).Type().Field(j).Name) == names[1] && !configHidden(t.Field(i).Type().Field(j)) {
 						f := t.Field(i).Field(j)
 						switch f.Interface().(type) {
 						case string:
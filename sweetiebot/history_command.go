@@ -0,0 +1,176 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/blackhole12/discordgo"
+)
+
+// canViewLoggedChannel returns false if messages from the given channel shouldn't be shown in the channel the command was used in, following the same rules as the search command.
+func canViewLoggedChannel(info *GuildInfo, channel uint64, msg *discordgo.Message) bool {
+	cid := SBatoi(msg.ChannelID)
+	if channel == info.config.Basic.ModChannel && cid != channel {
+		return false
+	}
+	for _, v := range info.config.Spoiler.Channels {
+		if v == channel && cid != channel {
+			return false
+		}
+	}
+	return true
+}
+
+// parseMessageLink accepts either a raw message ID or a message link and returns the message ID
+func parseMessageLink(s string) uint64 {
+	s = strings.Trim(s, "<>/")
+	if i := strings.LastIndex(s, "/"); i >= 0 {
+		s = s[i+1:]
+	}
+	return SBatoi(s)
+}
+
+func formatLoggedMessage(message string) string {
+	message = strings.Replace(message, "http://", "http\u200B://", -1)
+	message = strings.Replace(message, "https://", "https\u200B://", -1)
+	return ReplaceAllMentions(message)
+}
+
+type historyCommand struct {
+}
+
+func (c *historyCommand) Name() string {
+	return "History"
+}
+func (c *historyCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must provide a message ID or a link to a message.```", false, nil
+	}
+	id := parseMessageLink(args[0])
+	if id == 0 {
+		return "```Error: " + args[0] + " is not a message ID or a link to a message.```", false, nil
+	}
+	m := sb.db.GetChatMessage(id, SBatoi(info.ID))
+	if m == nil || !canViewLoggedChannel(info, m.Channel, msg) {
+		return "```Error: That message isn't in the chat log.```", false, nil
+	}
+
+	// The chat log overwrites the timestamp of a message whenever it is edited, so the time it was posted has to come from its ID instead
+	posted := snowflakeTime(id)
+	versions := sb.db.GetMessageHistory(id, SBatoi(info.ID))
+	lines := []string{fmt.Sprintf("**History of message %v by %s in <#%v>, posted %s:**", id, getUserName(m.Author, info), m.Channel, ApplyTimezone(posted, info, msg.Author).Format("1/2 3:04:05PM"))}
+	for i, v := range versions {
+		label := "Edit " + strconv.Itoa(i)
+		t := v.Timestamp
+		if i == 0 {
+			label = "Original"
+			t = posted
+		}
+		lines = append(lines, fmt.Sprintf("[%s] %s: %s", ApplyTimezone(t, info, msg.Author).Format("1/2 3:04:05PM"), label, formatLoggedMessage(v.Message)))
+	}
+	label := "Current, edited"
+	t := m.Timestamp
+	if len(versions) == 0 {
+		label = "Never edited"
+		t = posted
+	} else if m.Deleted != nil {
+		label = "Last version, edited"
+	}
+	lines = append(lines, fmt.Sprintf("[%s] %s: %s", ApplyTimezone(t, info, msg.Author).Format("1/2 3:04:05PM"), label, formatLoggedMessage(m.Message)))
+	if m.Deleted != nil {
+		lines = append(lines, fmt.Sprintf("[%s] Deleted", ApplyTimezone(*m.Deleted, info, msg.Author).Format("1/2 3:04:05PM")))
+	}
+	return strings.Join(lines, "\n"), len(lines) > 6, nil
+}
+func (c *historyCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows every version of a message in the chat log, when it was edited, and when it was deleted, if it was.",
+		Params: []CommandUsageParam{
+			{Name: "message", Desc: "Either the ID of the message, or a link to it.", Optional: false},
+		},
+	}
+}
+func (c *historyCommand) UsageShort() string { return "Shows the edit history of a message." }
+
+type deletedCommand struct {
+}
+
+func (c *deletedCommand) Name() string {
+	return "Deleted"
+}
+func (c *deletedCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 || !channelregex.MatchString(args[0]) {
+		return "```You must specify a channel, which should be an actual ping in the format #channel.```", false, nil
+	}
+	channel := SBatoi(args[0][2 : len(args[0])-1])
+	if !canViewLoggedChannel(info, channel, msg) {
+		return "```Error: You can only view deleted messages from that channel inside it.```", false, nil
+	}
+
+	rangebegin := 0
+	rangeend := 5
+	rest := args[1:]
+	if len(rest) > 0 && len(rest[len(rest)-1]) > 1 && rest[len(rest)-1][0] == '*' {
+		s := strings.Split(rest[len(rest)-1][1:], "-")
+		if len(s) > 1 {
+			rangebegin, _ = strconv.Atoi(s[0])
+			rangeend, _ = strconv.Atoi(s[1])
+		} else {
+			rangeend, _ = strconv.Atoi(s[0])
+		}
+		rest = rest[:len(rest)-1]
+	}
+
+	var user *uint64
+	if len(rest) > 0 {
+		arg := strings.TrimPrefix(strings.Join(rest, " "), "@")
+		IDs := FindUsername(arg, info)
+		if len(IDs) == 0 { // no matches!
+			return "```Error: Could not find any usernames or aliases matching " + arg + "!```", false, nil
+		}
+		if len(IDs) > 1 {
+			return "```Could be any of the following users or their aliases:\n" + strings.Join(IDsToUsernames(IDs, info, true), "\n") + "```", len(IDs) > 5, nil
+		}
+		user = &IDs[0]
+	}
+
+	offset := 0
+	if rangebegin > 0 { // rangebegin starts at 1, not 0
+		offset = rangebegin - 1
+		rangeend -= offset
+	}
+	if rangeend > info.config.Search.MaxResults {
+		rangeend = info.config.Search.MaxResults
+	}
+	if rangeend <= 0 {
+		return "```Error: Invalid range.```", false, nil
+	}
+
+	r := sb.db.GetDeleted(SBatoi(info.ID), channel, user, rangeend, offset)
+	if len(r) == 0 {
+		return "```No deleted messages found.```", false, nil
+	}
+	lines := []string{fmt.Sprintf("**Deleted messages in <#%v>:**", channel)}
+	for _, v := range r {
+		lines = append(lines, fmt.Sprintf("[%s, deleted %s] %s: %s", ApplyTimezone(snowflakeTime(v.ID), info, msg.Author).Format("1/2 3:04:05PM"), ApplyTimezone(*v.Deleted, info, msg.Author).Format("1/2 3:04:05PM"), getUserName(v.Author, info), formatLoggedMessage(v.Message)))
+	}
+	return strings.Join(lines, "\n"), len(r) > 5, nil
+}
+func (c *deletedCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Lists messages that were deleted from a channel, most recently deleted first, along with when they were posted and deleted.",
+		Params: []CommandUsageParam{
+			{Name: "#channel", Desc: "The channel to list deleted messages from. Must be an actual channel ping.", Optional: false},
+			{Name: "user", Desc: "Only list messages from this user. A ping works best, but a raw username will be searched for in the alias table.", Optional: true},
+			{Name: "*[result-range]", Desc: "Specifies what results should be returned, just like in `" + info.config.Basic.CommandPrefix + "search`, but must come last. '*10' returns the 10 most recently deleted messages, while '*5-10' returns the 5th to the 10th.", Optional: true},
+		},
+	}
+}
+func (c *deletedCommand) UsageShort() string { return "Lists deleted messages from a channel." }
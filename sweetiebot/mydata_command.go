@@ -0,0 +1,192 @@
+package sweetiebot
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blackhole12/discordgo"
+)
+
+type userDataExport struct {
+	ID            uint64              `json:"id"`
+	Username      string              `json:"username"`
+	Discriminator string              `json:"discriminator"`
+	Email         string              `json:"email"`
+	Avatar        string              `json:"avatar"`
+	LastSeen      time.Time           `json:"lastseen"`
+	Timezone      string              `json:"timezone"`
+	DefaultServer *uint64             `json:"defaultserver"`
+	Aliases       []string            `json:"aliases"`
+	NameChanges   []NameChange        `json:"namechanges"`
+	Memberships   []UserMembership    `json:"memberships"`
+	Departures    []UserDeparture     `json:"departures"`
+	Messages      []UserMessage       `json:"messages"`
+	Edits         []UserMessage       `json:"edits"`
+	Events        []UserEvent         `json:"events"`
+	RSVPs         []UserRSVP          `json:"rsvps"`
+	Votes         []UserVote          `json:"votes"`
+	Appeals       []AppealCase        `json:"appeals"`
+	Quotes        map[uint64][]string `json:"quotes"`
+}
+
+// ExportUserData gathers everything stored about a user in the database and in every guild config, and returns it as a zip file containing a single JSON document
+func ExportUserData(user uint64) (*bytes.Buffer, error) {
+	export := userDataExport{ID: user, Quotes: make(map[uint64][]string)}
+	u, lastseen, loc, defaultserver := sb.db.GetUser(user)
+	if u != nil {
+		export.Username = u.Username
+		export.Discriminator = u.Discriminator
+		export.Email = u.Email
+		export.Avatar = u.Avatar
+		export.LastSeen = lastseen
+		export.DefaultServer = defaultserver
+		if loc != nil {
+			export.Timezone = loc.String()
+		}
+	}
+	export.Aliases = sb.db.GetAllAliases(user)
+	export.NameChanges = sb.db.GetUserNameLog(user)
+	export.Memberships = sb.db.GetUserMemberships(user)
+	export.Departures = sb.db.GetUserDepartures(user)
+	export.Messages = sb.db.GetUserChatlog(user)
+	export.Edits = sb.db.GetUserEditlog(user)
+	export.Events = sb.db.GetUserSchedule(user)
+	export.RSVPs = sb.db.GetUserRSVPs(user)
+	export.Votes = sb.db.GetUserVotes(user)
+	export.Appeals = sb.db.GetUserAppeals(user)
+
+	sb.guildsLock.RLock()
+	for _, info := range sb.guilds {
+		info.configLock.RLock()
+		if q, ok := info.config.Quote.Quotes[user]; ok && len(q) > 0 {
+			export.Quotes[SBatoi(info.ID)] = append([]string{}, q...)
+		}
+		info.configLock.RUnlock()
+	}
+	sb.guildsLock.RUnlock()
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	f, err := w.Create(fmt.Sprintf("user-%v.json", user))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = f.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+type myDataCommand struct {
+}
+
+func (c *myDataCommand) Name() string {
+	return "MyData"
+}
+func (c *myDataCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	user := SBatoi(msg.Author.ID)
+	buf, err := ExportUserData(user)
+	if err != nil {
+		return "```Error exporting your data: " + err.Error() + "```", false, nil
+	}
+	ch, err := sb.dg.UserChannelCreate(msg.Author.ID)
+	if err != nil {
+		return "```Error opening a private channel: " + err.Error() + "```", false, nil
+	}
+	if _, err = sb.dg.ChannelFileSend(ch.ID, fmt.Sprintf("user-%v.zip", user), buf); err != nil {
+		return "```Error sending your data: " + err.Error() + "```", false, nil
+	}
+	if ch.ID == msg.ChannelID {
+		return "", false, nil
+	}
+	return "```I've sent you a private message with everything I have stored about you.```", false, nil
+}
+func (c *myDataCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Sends you a private message containing a ZIP file with a JSON export of everything the bot has stored about you on every server: your user information, aliases, nicknames, logged messages and edits, reminders and other scheduled events, RSVPs, poll votes, appeals and quotes. To have this data deleted, contact the owner of the bot.",
+	}
+}
+func (c *myDataCommand) UsageShort() string { return "Sends you all data stored about you." }
+
+type forgetUserCommand struct {
+}
+
+func (c *forgetUserCommand) Name() string {
+	return "ForgetUser"
+}
+func (c *forgetUserCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	_, isOwner := sb.Owners[SBatoi(msg.Author.ID)]
+	if !isOwner {
+		return "```Only the owner of the bot itself can call this!```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must PING the user, or provide their user ID.```", false, nil
+	}
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	user := SBatoi(StripPing(args[0]))
+	if user == 0 {
+		return "```Error: " + args[0] + " is not a ping or a user ID.```", false, nil
+	}
+
+	r := sb.db.ForgetUser(user)
+	quotes := 0
+	sb.guildsLock.RLock()
+	for _, g := range sb.guilds {
+		g.configLock.Lock()
+		q, ok := g.config.Quote.Quotes[user]
+		if ok {
+			quotes += len(q)
+			delete(g.config.Quote.Quotes, user)
+		}
+		g.configLock.Unlock()
+		if ok {
+			g.SaveConfig()
+		}
+	}
+	sb.guildsLock.RUnlock()
+
+	removed := []string{
+		Pluralize(r.Chatlog, " message"),
+		Pluralize(r.Editlog, " edit"),
+		fmt.Sprintf("%v aliases", r.Aliases),
+		Pluralize(r.Names, " name change"),
+		Pluralize(r.Members, " server membership"),
+		Pluralize(r.Departed, " departure"),
+		Pluralize(r.Votes, " vote"),
+		Pluralize(r.Appeals, " appeal"),
+		Pluralize(r.Schedule, " scheduled event"),
+		Pluralize(r.RSVPs, " RSVP"),
+		Pluralize(int64(quotes), " quote"),
+	}
+	result := fmt.Sprintf("Forgot user %v: deleted %s, and anonymized %s.", user, strings.Join(removed, ", "), Pluralize(r.Debuglog, " debug log row"))
+	if r.User == 0 {
+		result += " There was no user entry for this ID."
+	}
+	info.Log(msg.Author.Username + " used forgetuser. " + result)
+	return "```" + result + " If they are still on a server with me, basic user information will be recorded again the next time they are seen.```", false, nil
+}
+func (c *forgetUserCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Restricted command that deletes everything stored about a user on every server, including messages, edits, aliases, nicknames, votes, appeals, reminders, RSVPs, birthdays and quotes. Debug log entries are kept but no longer refer to the user, and pending unbans or unsilences are left alone.",
+		Params: []CommandUsageParam{
+			{Name: "user", Desc: "A ping to a specific user in the format @User, or their user ID.", Optional: false},
+		},
+	}
+}
+func (c *forgetUserCommand) UsageShort() string { return "[RESTRICTED] Deletes all data about a user." }
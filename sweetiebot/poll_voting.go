@@ -0,0 +1,419 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackhole12/discordgo"
+)
+
+// instantRunoff tallies ranked ballots. Every round, each ballot counts for its highest ranked option that is still running, and the option with the fewest votes is eliminated, until one option has a majority or all remaining options are tied. Returns the tally of each round.
+func instantRunoff(options []uint64, ballots [][]uint64) []map[uint64]uint64 {
+	running := make(map[uint64]bool)
+	for _, v := range options {
+		running[v] = true
+	}
+	rounds := []map[uint64]uint64{}
+	for len(running) > 0 {
+		tally := make(map[uint64]uint64)
+		for k := range running {
+			tally[k] = 0
+		}
+		total := uint64(0)
+		for _, b := range ballots {
+			for _, v := range b {
+				if running[v] {
+					tally[v]++
+					total++
+					break
+				}
+			}
+		}
+		rounds = append(rounds, tally)
+
+		min, max := ^uint64(0), uint64(0)
+		for _, n := range tally {
+			if n < min {
+				min = n
+			}
+			if n > max {
+				max = n
+			}
+		}
+		if max*2 > total || min == max {
+			break
+		}
+		last := []uint64{}
+		next := ^uint64(0)
+		for k, n := range tally {
+			if n == min {
+				last = append(last, k)
+			} else if n < next {
+				next = n
+			}
+		}
+		// Options tied for fewest votes can only be eliminated together if all their votes combined couldn't catch up with the next option
+		if min*uint64(len(last)) < next {
+			for _, k := range last {
+				delete(running, k)
+			}
+		} else {
+			delete(running, instantRunoffLoser(last, rounds))
+		}
+	}
+	return rounds
+}
+
+// instantRunoffLoser breaks a tie for fewest votes by picking the option that had fewer votes in the latest earlier round where the tied options differed, or the highest option if they were tied in every round
+func instantRunoffLoser(tied []uint64, rounds []map[uint64]uint64) uint64 {
+	for i := len(rounds) - 2; i >= 0 && len(tied) > 1; i-- {
+		min := ^uint64(0)
+		for _, k := range tied {
+			if rounds[i][k] < min {
+				min = rounds[i][k]
+			}
+		}
+		fewest := []uint64{}
+		for _, k := range tied {
+			if rounds[i][k] == min {
+				fewest = append(fewest, k)
+			}
+		}
+		tied = fewest
+	}
+	loser := tied[0]
+	for _, k := range tied {
+		if k > loser {
+			loser = k
+		}
+	}
+	return loser
+}
+
+// rankedResults shows each round of the instant runoff of a ranked poll, or only the last one if short is true
+func rankedResults(poll *PollStruct, options []PollOptionStruct, short bool) []string {
+	indices := make([]uint64, 0, len(options))
+	names := make(map[uint64]string)
+	for _, v := range options {
+		indices = append(indices, v.index)
+		names[v.index] = v.option
+	}
+	ballots := sb.db.GetBallots(poll.ID)
+	b := make([][]uint64, 0, len(ballots))
+	for _, v := range ballots {
+		b = append(b, v.Options)
+	}
+	rounds := instantRunoff(indices, b)
+	if len(rounds) == 0 {
+		return []string{}
+	}
+
+	str := []string{}
+	for i, tally := range rounds {
+		if short && i+1 < len(rounds) {
+			continue
+		}
+		max := uint64(0)
+		for _, n := range tally {
+			if n > max {
+				max = n
+			}
+		}
+		if len(rounds) > 1 {
+			str = append(str, fmt.Sprintf("**Round %v**", i+1))
+		}
+		for _, v := range options {
+			if n, ok := tally[v.index]; ok {
+				str = append(str, pollOptionLine(v, n, max, len(options) > 9, short))
+			}
+		}
+		if i+1 < len(rounds) {
+			eliminated := []string{}
+			for _, v := range options {
+				if _, ok := tally[v.index]; ok {
+					if _, ok = rounds[i+1][v.index]; !ok {
+						eliminated = append(eliminated, names[v.index])
+					}
+				}
+			}
+			str = append(str, "Eliminated: "+strings.Join(eliminated, ", "))
+		}
+	}
+
+	last := rounds[len(rounds)-1]
+	max := uint64(0)
+	for _, n := range last {
+		if n > max {
+			max = n
+		}
+	}
+	winners := []string{}
+	for _, v := range options {
+		if n, ok := last[v.index]; ok && n == max {
+			winners = append(winners, names[v.index])
+		}
+	}
+	switch {
+	case max == 0:
+		str = append(str, "Nobody has voted yet.")
+	case len(winners) == 1:
+		str = append(str, fmt.Sprintf("Winner after %s: **%s** (%s)", Pluralize(int64(len(rounds)), " round"), winners[0], Pluralize(int64(len(ballots)), " ballot")))
+	default:
+		str = append(str, fmt.Sprintf("Tied after %s: **%s** (%s)", Pluralize(int64(len(rounds)), " round"), strings.Join(winners, "**, **"), Pluralize(int64(len(ballots)), " ballot")))
+	}
+	return str
+}
+
+// closePollEvent closes a poll and announces its results in the channel it was posted in, or the default channel if it was never posted
+func closePollEvent(info *GuildInfo, e *ScheduleEvent, channel string) error {
+	p := SchedulePollPayload{}
+	if err := e.Payload(&p); err != nil {
+		return err
+	}
+	poll := sb.db.GetPollByID(p.Poll)
+	if poll == nil {
+		return nil // The poll was deleted
+	}
+	poll.Closed = true
+	if err := sb.db.SetPollSettings(poll); err != nil {
+		return err
+	}
+	if ch, message := sb.db.GetPollMessage(poll.ID); message != 0 {
+		channel = SBitoa(ch)
+		sb.dg.ChannelMessageEditEmbed(channel, SBitoa(message), pollEmbed(info, poll))
+	}
+	return sendScheduledMessage(info, channel, "The **"+poll.Name+"** poll is now closed! Final results:\n"+strings.Join(pollResults(poll, true), "\n"))
+}
+
+// pollCloseTime returns when a poll is scheduled to close, if it is
+func pollCloseTime(info *GuildInfo, poll *PollStruct) (time.Time, bool) {
+	data := EncodeSchedulePayload(SchedulePollPayload{poll.ID})
+	for _, v := range sb.db.GetEventsByType(SBatoi(info.ID), SCHEDULE_POLL, 5000) {
+		if v.Data == data {
+			return v.Date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func pollRoleName(info *GuildInfo, role uint64) string {
+	r, err := sb.dg.State.Role(info.ID, SBitoa(role))
+	if err != nil {
+		return SBitoa(role)
+	}
+	return r.Name
+}
+
+// pollSettingsText describes how members vote in a poll and whether it is still open
+func pollSettingsText(info *GuildInfo, poll *PollStruct, user *discordgo.User) string {
+	verb := "Pick"
+	if poll.Ranked {
+		verb = "Rank"
+	}
+	var s []string
+	switch poll.Choices {
+	case 0:
+		s = append(s, verb+" any number of options")
+	case 1:
+		s = append(s, verb+" one option")
+	default:
+		s = append(s, fmt.Sprintf("%s up to %v options", verb, poll.Choices))
+	}
+	if poll.Ranked {
+		s = append(s, "counted by instant runoff")
+	}
+	if poll.Role != 0 {
+		s = append(s, "only "+pollRoleName(info, poll.Role)+" can vote")
+	}
+	if poll.Anonymous {
+		s = append(s, "anonymous")
+	}
+	if poll.Closed {
+		s = append(s, "closed")
+	} else if t, ok := pollCloseTime(info, poll); ok {
+		s = append(s, "closes "+formatEventTime(t, info, user))
+	}
+	return strings.Join(s, ", ")
+}
+
+// pollToggle parses the value of a setting that can be turned on or off
+func pollToggle(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "on", "true", "yes":
+		return true, true
+	case "off", "false", "no":
+		return false, true
+	}
+	return false, false
+}
+
+type pollSettingsCommand struct {
+}
+
+func (c *pollSettingsCommand) Name() string {
+	return "PollSettings"
+}
+func (c *pollSettingsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You have to give me a poll name!```", false, nil
+	}
+	gID := SBatoi(info.ID)
+	id, _ := sb.db.GetPoll(strings.ToLower(args[0]), gID)
+	poll := sb.db.GetPollByID(id)
+	if poll == nil {
+		return "```That poll doesn't exist! Use \"" + info.config.Basic.CommandPrefix + "poll\" to list active polls.```", false, nil
+	}
+	if len(args) < 2 {
+		return "```" + poll.Name + ": " + pollSettingsText(info, poll, msg.Author) + "```", false, nil
+	}
+	if len(args) < 3 {
+		return "```You have to give me a value for the " + args[1] + " setting!```", false, nil
+	}
+	value := msg.Content[indices[2]:]
+	voted := len(sb.db.GetBallots(poll.ID)) > 0
+	toggle, ok := pollToggle(value)
+
+	switch strings.ToLower(args[1]) {
+	case "close":
+		data := EncodeSchedulePayload(SchedulePollPayload{poll.ID})
+		if strings.ToLower(value) == "never" {
+			sb.db.RemoveScheduleByData(gID, SCHEDULE_POLL, data)
+		} else {
+			t, err := parseCommonTime(value, info, msg.Author)
+			if err != nil {
+				return "```Error: Could not parse time! " + err.Error() + "```", false, nil
+			}
+			sb.db.RemoveScheduleByData(gID, SCHEDULE_POLL, data)
+			if !sb.db.AddSchedule(gID, t.UTC(), SCHEDULE_POLL, data) {
+				return "```Error: servers can't have more than 5000 events!```", false, nil
+			}
+		}
+		poll.Closed = false
+	case "pick":
+		if voted {
+			return "```You can't change how many options members can pick after they started voting.```", false, nil
+		}
+		n := 0
+		if strings.ToLower(value) != "any" {
+			i, err := strconv.Atoi(value)
+			if err != nil || i < 1 || i > 255 {
+				return "```The number of options must be between 1 and 255, or \"any\".```", false, nil
+			}
+			n = i
+		}
+		poll.Choices = n
+	case "ranked":
+		if !ok {
+			return "```The ranked setting must be on or off.```", false, nil
+		}
+		if voted {
+			return "```You can't change how votes are counted after members started voting.```", false, nil
+		}
+		poll.Ranked = toggle
+		if poll.Ranked && poll.Choices == 1 {
+			poll.Choices = 0
+		}
+	case "role":
+		if strings.ToLower(value) == "none" {
+			poll.Role = 0
+		} else if mentionregex.MatchString(value) {
+			poll.Role = SBatoi(StripPing(value))
+		} else if r, _ := GetRoleByName(value, info); r != nil {
+			poll.Role = SBatoi(r.ID)
+		} else {
+			return "```That's not a role on this server!```", false, nil
+		}
+	case "anonymous":
+		if !ok {
+			return "```The anonymous setting must be on or off.```", false, nil
+		}
+		if !toggle && poll.Anonymous && voted {
+			return "```You can't reveal the voters of an anonymous poll after members started voting.```", false, nil
+		}
+		if toggle && !poll.Anonymous {
+			if channel, message := sb.db.GetPollMessage(poll.ID); message != 0 { // Existing reactions show who voted for what, so they are replaced with fresh ones
+				sb.dg.MessageReactionsRemoveAll(SBitoa(channel), SBitoa(message))
+				addPollReactions(SBitoa(channel), SBitoa(message), sb.db.GetOptions(poll.ID))
+			}
+		}
+		poll.Anonymous = toggle
+	default:
+		return "```" + args[1] + " is not a poll setting. Use close, pick, ranked, role or anonymous.```", false, nil
+	}
+
+	if err := sb.db.SetPollSettings(poll); err != nil {
+		return "```Error saving poll settings: " + err.Error() + "```", false, nil
+	}
+	updatePollMessage(info, poll)
+	return "```" + poll.Name + ": " + pollSettingsText(info, poll, msg.Author) + "```", false, nil
+}
+func (c *pollSettingsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows or changes how members vote in a poll. How many options members can pick and whether they are ranked can only be changed before anyone voted.\n\n`close <time>` closes the poll at the given time, like `in 3 days` or `Jan 5 6pm`, and announces the results. `close never` reopens it.\n`pick <number|any>` sets how many options each member can pick.\n`ranked <on|off>` has members rank options from best to worst, and counts the votes by instant runoff.\n`role <role|none>` only lets members with the given role vote.\n`anonymous <on|off>` hides who voted for what, even from the moderators. Reactions to anonymous polls are removed as soon as they are counted, and reacting again takes back the vote.",
+		Params: []CommandUsageParam{
+			{Name: "poll", Desc: "Name of the poll.", Optional: false},
+			{Name: "setting", Desc: "One of `close`, `pick`, `ranked`, `role` or `anonymous`. If omitted, shows the current settings.", Optional: true},
+			{Name: "value", Desc: "The new value of the setting.", Optional: true},
+		},
+	}
+}
+func (c *pollSettingsCommand) UsageShort() string { return "Changes how members vote in a poll." }
+
+type votersCommand struct {
+}
+
+func (c *votersCommand) Name() string {
+	return "Voters"
+}
+func (c *votersCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You have to give me a poll name!```", false, nil
+	}
+	id, _ := sb.db.GetPoll(strings.ToLower(msg.Content[indices[0]:]), SBatoi(info.ID))
+	poll := sb.db.GetPollByID(id)
+	if poll == nil {
+		return "```That poll doesn't exist! Use \"" + info.config.Basic.CommandPrefix + "poll\" to list active polls.```", false, nil
+	}
+	if poll.Anonymous {
+		return "```That poll is anonymous, so nobody can see who voted for what.```", false, nil
+	}
+	ballots := sb.db.GetBallots(poll.ID)
+	if len(ballots) == 0 {
+		return "```Nobody has voted in that poll yet.```", false, nil
+	}
+	names := make(map[uint64]string)
+	for _, v := range sb.db.GetOptions(poll.ID) {
+		names[v.index] = v.option
+	}
+	sep := ", "
+	if poll.Ranked {
+		sep = " > "
+	}
+	lines := make([]string, 0, len(ballots)+1)
+	lines = append(lines, "Votes in the "+poll.Name+" poll:")
+	for _, b := range ballots {
+		options := make([]string, 0, len(b.Options))
+		for _, v := range b.Options {
+			options = append(options, names[v])
+		}
+		lines = append(lines, "**"+getUserName(b.User, info)+"**: "+strings.Join(options, sep))
+	}
+	return ReplaceAllMentions(strings.Join(lines, "\n")), len(lines) > 11, nil
+}
+func (c *votersCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Lists who voted for what in a poll, unless the poll is anonymous.",
+		Params: []CommandUsageParam{
+			{Name: "poll", Desc: "Name of the poll.", Optional: false},
+		},
+	}
+}
+func (c *votersCommand) UsageShort() string { return "Lists who voted in a poll." }
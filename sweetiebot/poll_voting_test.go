@@ -0,0 +1,83 @@
+package sweetiebot
+
+import (
+	"testing"
+)
+
+func TestInstantRunoff(t *testing.T) {
+	cases := []struct {
+		name    string
+		options []uint64
+		ballots [][]uint64
+		rounds  []map[uint64]uint64
+	}{
+		{"no options", []uint64{}, [][]uint64{{1}}, []map[uint64]uint64{}},
+		{"no votes", []uint64{1, 2, 3}, [][]uint64{}, []map[uint64]uint64{{1: 0, 2: 0, 3: 0}}},
+		{"empty ballots", []uint64{1, 2}, [][]uint64{{}, {}}, []map[uint64]uint64{{1: 0, 2: 0}}},
+		{"majority in the first round", []uint64{1, 2, 3}, [][]uint64{{1}, {1}, {2}}, []map[uint64]uint64{{1: 2, 2: 1, 3: 0}}},
+		{"tie in the first round", []uint64{1, 2}, [][]uint64{{1}, {2}}, []map[uint64]uint64{{1: 1, 2: 1}}},
+		{"unknown options are skipped", []uint64{1, 2}, [][]uint64{{9, 1}, {1}, {2}}, []map[uint64]uint64{{1: 2, 2: 1}}},
+		{
+			"votes transfer to the next choice",
+			[]uint64{1, 2, 3},
+			[][]uint64{{1, 2}, {1, 2}, {2, 1}, {3, 2}, {3, 2}},
+			[]map[uint64]uint64{{1: 2, 2: 1, 3: 2}, {1: 3, 3: 2}},
+		},
+		{
+			"options tied for fewest votes are eliminated together if they can't catch up",
+			[]uint64{1, 2, 3, 4},
+			[][]uint64{{1}, {1}, {1}, {2}, {2}, {2}, {3, 1}, {4, 2}},
+			[]map[uint64]uint64{{1: 3, 2: 3, 3: 1, 4: 1}, {1: 4, 2: 4}},
+		},
+		{
+			"only one option tied for fewest votes is eliminated if they could catch up",
+			[]uint64{1, 2, 3},
+			[][]uint64{{1}, {1}, {1}, {2, 3}, {2, 3}, {3, 2}, {3, 2}},
+			[]map[uint64]uint64{{1: 3, 2: 2, 3: 2}, {1: 3, 2: 4}},
+		},
+		{
+			"ties for fewest votes are broken by earlier rounds",
+			[]uint64{1, 2, 3, 4},
+			[][]uint64{{1}, {1}, {1}, {1}, {3}, {3}, {3}, {2}, {2}, {4, 2}},
+			[]map[uint64]uint64{{1: 4, 2: 2, 3: 3, 4: 1}, {1: 4, 2: 3, 3: 3}, {1: 4, 3: 3}},
+		},
+		{
+			"exhausted ballots don't count towards the majority",
+			[]uint64{1, 2, 3},
+			[][]uint64{{1}, {1}, {2}, {2}, {3}},
+			[]map[uint64]uint64{{1: 2, 2: 2, 3: 1}, {1: 2, 2: 2}},
+		},
+		{
+			"exhausted ballots let the leader win",
+			[]uint64{1, 2, 3},
+			[][]uint64{{1}, {1}, {1}, {2}, {2}, {3}, {3}},
+			[]map[uint64]uint64{{1: 3, 2: 2, 3: 2}, {1: 3, 2: 2}},
+		},
+		{
+			"zero vote options are eliminated first",
+			[]uint64{1, 2, 3},
+			[][]uint64{{1, 2}, {2, 1}},
+			[]map[uint64]uint64{{1: 1, 2: 1, 3: 0}, {1: 1, 2: 1}},
+		},
+	}
+	for _, c := range cases {
+		rounds := instantRunoff(c.options, c.ballots)
+		if len(rounds) != len(c.rounds) {
+			t.Errorf("%s: got %v rounds, want %v: %v", c.name, len(rounds), len(c.rounds), rounds)
+			continue
+		}
+		for i, tally := range rounds {
+			want := c.rounds[i]
+			if len(tally) != len(want) {
+				t.Errorf("%s: round %v = %v, want %v", c.name, i+1, tally, want)
+				continue
+			}
+			for k, n := range want {
+				if v, ok := tally[k]; !ok || v != n {
+					t.Errorf("%s: round %v = %v, want %v", c.name, i+1, tally, want)
+					break
+				}
+			}
+		}
+	}
+}
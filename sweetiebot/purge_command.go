@@ -0,0 +1,69 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blackhole12/discordgo"
+)
+
+// PurgeLogs deletes everything in the chatlog, editlog and debuglog that is older than the guild's retention settings, and logs how many rows were removed
+func PurgeLogs(info *GuildInfo) {
+	gID := SBatoi(info.ID)
+	removed := []string{}
+	if info.config.Log.EditlogRetention > 0 {
+		if n := sb.db.PurgeEditlog(gID, info.config.Log.EditlogRetention); n > 0 {
+			removed = append(removed, Pluralize(n, " editlog row"))
+		}
+	}
+	if info.config.Log.ChatlogRetention > 0 {
+		if n := sb.db.PurgeChatlog(gID, info.config.Log.ChatlogRetention); n > 0 {
+			removed = append(removed, Pluralize(n, " chatlog row"))
+		}
+	}
+	if info.config.Log.DebuglogRetention > 0 {
+		if n := sb.db.PurgeDebuglog(gID, info.config.Log.DebuglogRetention); n > 0 {
+			removed = append(removed, Pluralize(n, " debuglog row"))
+		}
+	}
+	if len(removed) > 0 {
+		info.Log("Log retention purge removed " + strings.Join(removed, ", ") + ".")
+	}
+}
+
+type purgeLogCommand struct {
+}
+
+func (c *purgeLogCommand) Name() string {
+	return "PurgeLog"
+}
+func (c *purgeLogCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	if len(args) < 1 {
+		return "```You must specify whose logs should be purged.```", false, nil
+	}
+	arg := msg.Content[indices[0]:]
+	IDs := FindUsername(arg, info)
+	if len(IDs) == 0 { // no matches!
+		return "```Error: Could not find any usernames or aliases matching " + arg + "!```", false, nil
+	}
+	if len(IDs) > 1 {
+		return "```Could be any of the following users or their aliases:\n" + strings.Join(IDsToUsernames(IDs, info, true), "\n") + "```", len(IDs) > 5, nil
+	}
+
+	name := IDsToUsernames(IDs, info, true)[0]
+	chat, edits, debug := sb.db.PurgeUserLogs(IDs[0], SBatoi(info.ID))
+	info.Log(fmt.Sprintf("%s purged all logs of %s: %s, %s, %s.", msg.Author.Username, name, Pluralize(chat, " chatlog row"), Pluralize(edits, " editlog row"), Pluralize(debug, " anonymized debuglog row")))
+	return fmt.Sprintf("```Removed %s and %s belonging to %s, and anonymized %s.```", Pluralize(chat, " chatlog row"), Pluralize(edits, " editlog row"), name, Pluralize(debug, " debuglog row")), false, nil
+}
+func (c *purgeLogCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Permanently deletes every message and edit from the given user in this server, for example to honor a request to remove their data. Their debug log entries are kept as an audit trail, but no longer say who they belong to. Reports how many rows were changed.",
+		Params: []CommandUsageParam{
+			{Name: "user", Desc: "A ping of the user, or simply their name.", Optional: false},
+		},
+	}
+}
+func (c *purgeLogCommand) UsageShort() string { return "Deletes all logged data of a user." }
@@ -0,0 +1,439 @@
+package sweetiebot
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackhole12/discordgo"
+)
+
+// Types of scheduled events, as stored in the Type column of the schedule table
+const (
+	SCHEDULE_UNBAN         = 0
+	SCHEDULE_BIRTHDAY      = 1
+	SCHEDULE_MESSAGE       = 2
+	SCHEDULE_EPISODE       = 3
+	SCHEDULE_BIRTHDAY_END  = 4
+	SCHEDULE_EVENT         = 5
+	SCHEDULE_REMINDER      = 6
+	SCHEDULE_ROLE          = 7
+	SCHEDULE_UNSILENCE     = 8
+	SCHEDULE_ATTENDEE_ROLE = 9
+	SCHEDULE_POLL          = 10
+)
+
+// Delivery status of a scheduled event, as stored in the Status column of the schedule table
+const (
+	EVENT_PENDING  = 0
+	EVENT_RETRYING = 1
+	EVENT_FAILED   = 2
+)
+
+// How often we try to process an event before giving up on it. The delay between attempts starts at a minute and doubles every time.
+const scheduleMaxAttempts = 6
+
+// permanentError marks an error that will happen again no matter how often an event is retried
+type permanentError struct {
+	error
+}
+
+// isPermanentError returns true if retrying an event can't fix the error. Discord only fails temporarily when it is rate limiting us or has problems of its own, so any other error it returns, like a member who left or missing permissions, is permanent. Errors that don't come from discord are usually network errors, except for payloads that can't be decoded.
+func isPermanentError(err error) bool {
+	switch e := err.(type) {
+	case permanentError, *json.SyntaxError, *json.UnmarshalTypeError:
+		return true
+	case *discordgo.RESTError:
+		return e.Response != nil && e.Response.StatusCode != 429 && e.Response.StatusCode < 500
+	}
+	return false
+}
+
+// wrapScheduleError adds context to an error without changing whether it is permanent
+func wrapScheduleError(prefix string, err error) error {
+	if isPermanentError(err) {
+		return permanentError{errors.New(prefix + err.Error())}
+	}
+	return errors.New(prefix + err.Error())
+}
+
+// ScheduleUserPayload is the payload of events that concern a single user, like unbans and birthdays. Every payload that refers to a user should store it in a "user" field, so the database can find all events belonging to that user.
+type ScheduleUserPayload struct {
+	User string `json:"user"`
+}
+
+// ScheduleBirthdayPayload is the payload of a birthday. Year is 0 if the member didn't share it, and HideYear keeps their age out of announcements and listings.
+type ScheduleBirthdayPayload struct {
+	User     string `json:"user"`
+	Year     int    `json:"year,omitempty"`
+	HideYear bool   `json:"hideyear,omitempty"`
+}
+
+// Age returns how old the member turns on the given date, or 0 if their age isn't public
+func (p *ScheduleBirthdayPayload) Age(t time.Time) int {
+	if p.Year == 0 || p.HideYear {
+		return 0
+	}
+	return t.Year() - p.Year
+}
+
+// ScheduleMessagePayload is the payload of messages and episodes
+type ScheduleMessagePayload struct {
+	Message string `json:"message"`
+}
+
+// ScheduleEventPayload is the payload of a community event. Post is the announcement that members can react to in order to RSVP, Channel is the channel it was posted in, and Reminded is set once the attendees were reminded of the next occurrence.
+type ScheduleEventPayload struct {
+	Message  string `json:"message"`
+	Post     string `json:"post,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+	Reminded bool   `json:"reminded,omitempty"`
+}
+
+// ScheduleAttendeePayload is the payload of the event that removes the attendee role from everyone who attended an event
+type ScheduleAttendeePayload struct {
+	Role  string   `json:"role"`
+	Users []string `json:"users"`
+}
+
+// ScheduleReminderPayload is the payload of a reminder that is sent to a user. If Channel is set, the reminder is posted there along with a link to the message that created it, instead of being sent privately.
+type ScheduleReminderPayload struct {
+	User    string `json:"user"`
+	Message string `json:"message"`
+	Channel string `json:"channel,omitempty"`
+	Link    string `json:"link,omitempty"`
+}
+
+// ScheduleRolePayload is the payload of a message that pings a role
+type ScheduleRolePayload struct {
+	Role    string `json:"role"`
+	Message string `json:"message"`
+}
+
+// SchedulePollPayload is the payload of the event that closes a poll
+type SchedulePollPayload struct {
+	Poll uint64 `json:"poll"`
+}
+
+// EncodeSchedulePayload turns the payload of an event into the JSON stored in the Data column of the schedule table
+func EncodeSchedulePayload(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// scheduleUserPattern returns a LIKE pattern that matches the payload of any event belonging to the given user
+func scheduleUserPattern(user string) string {
+	return "%\"user\":\"" + user + "\"%"
+}
+
+// legacySchedulePayload converts the data of events created before payloads were stored as JSON, where reminders and role messages were stored as "user|message" and "role|message"
+func legacySchedulePayload(ty uint8, data string) string {
+	switch ty {
+	case SCHEDULE_UNBAN, SCHEDULE_BIRTHDAY, SCHEDULE_BIRTHDAY_END, SCHEDULE_UNSILENCE:
+		return EncodeSchedulePayload(ScheduleUserPayload{data})
+	case SCHEDULE_REMINDER:
+		dat := strings.SplitN(data, "|", 2)
+		if len(dat) < 2 {
+			dat = append(dat, "")
+		}
+		return EncodeSchedulePayload(ScheduleReminderPayload{User: dat[0], Message: dat[1]})
+	case SCHEDULE_ROLE:
+		dat := strings.SplitN(data, "|", 2)
+		if len(dat) < 2 {
+			dat = append(dat, "")
+		}
+		return EncodeSchedulePayload(ScheduleRolePayload{dat[0], dat[1]})
+	}
+	return EncodeSchedulePayload(ScheduleMessagePayload{data})
+}
+
+// isSchedulePayload returns true if the data of an event is a JSON object, and false for legacy data, even if it happens to start with a brace
+func isSchedulePayload(data string) bool {
+	var m map[string]json.RawMessage
+	return json.Unmarshal([]byte(data), &m) == nil && m != nil // "null" unmarshals without an error, but leaves the map nil
+}
+
+// Payload decodes the JSON payload of an event into the given struct
+func (e *ScheduleEvent) Payload(v interface{}) error {
+	data := e.Data
+	if !isSchedulePayload(data) {
+		data = legacySchedulePayload(e.Type, data)
+	}
+	return json.Unmarshal([]byte(data), v)
+}
+
+// migrateScheduleData rewrites the data of every legacy event in a guild as JSON
+func migrateScheduleData(guild uint64) {
+	for _, v := range sb.db.GetLegacyEvents(guild) {
+		sb.db.SetEventData(v.ID, legacySchedulePayload(v.Type, v.Data))
+	}
+}
+
+// scheduleKind implements ScheduleEventKind using functions, which is how the scheduler defines its own event types
+type scheduleKind struct {
+	ty        uint8
+	name      string
+	private   bool
+	skippable bool
+	process   func(*GuildInfo, *ScheduleEvent, string) error
+	describe  func(*GuildInfo, *ScheduleEvent, *discordgo.Message) (string, string)
+}
+
+func (k *scheduleKind) Type() uint8     { return k.ty }
+func (k *scheduleKind) Name() string    { return k.name }
+func (k *scheduleKind) Private() bool   { return k.private }
+func (k *scheduleKind) Skippable() bool { return k.skippable }
+func (k *scheduleKind) Process(info *GuildInfo, e *ScheduleEvent, channel string) error {
+	return k.process(info, e, channel)
+}
+func (k *scheduleKind) Describe(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+	return k.describe(info, e, msg)
+}
+
+// getScheduleKind finds a registered kind of scheduled event by its name, which can also be plural
+func (info *GuildInfo) getScheduleKind(s string) ScheduleEventKind {
+	s = strings.TrimSuffix(strings.ToLower(s), "s")
+	for _, k := range info.hooks.ScheduleEvents {
+		if k.Name() == s {
+			return k
+		}
+	}
+	return nil
+}
+
+func describeUserEvent(tag string) func(*GuildInfo, *ScheduleEvent, *discordgo.Message) (string, string) {
+	return func(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+		p := ScheduleUserPayload{}
+		e.Payload(&p)
+		return tag, "<@" + p.User + ">"
+	}
+}
+
+func describeMessageEvent(tag string) func(*GuildInfo, *ScheduleEvent, *discordgo.Message) (string, string) {
+	return func(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+		p := ScheduleMessagePayload{}
+		e.Payload(&p)
+		return tag, p.Message
+	}
+}
+
+func setBirthdayRole(info *GuildInfo, e *ScheduleEvent, add bool) {
+	p := ScheduleUserPayload{}
+	e.Payload(&p)
+	if info.config.Schedule.BirthdayRole == 0 {
+		info.Log("No birthday role set!")
+	} else if add {
+		err := sb.dg.GuildMemberRoleAdd(info.ID, p.User, SBitoa(info.config.Schedule.BirthdayRole))
+		info.LogError("Failed to set birthday role: ", err)
+	} else {
+		err := sb.dg.GuildMemberRoleRemove(info.ID, p.User, SBitoa(info.config.Schedule.BirthdayRole))
+		info.LogError("Failed to remove birthday role: ", err)
+	}
+}
+
+// birthdayMessage fills in the Schedule.BirthdayMessage template for a birthday on the given date. If the template mentions {age} but the member's age isn't known, the default message is used instead, because there's no telling what the text around {age} would look like without it.
+func birthdayMessage(info *GuildInfo, p *ScheduleBirthdayPayload, t time.Time) string {
+	message := info.config.Schedule.BirthdayMessage
+	age := ""
+	if n := p.Age(ApplyTimezone(t, info, nil)); n > 0 {
+		age = strconv.Itoa(n)
+	}
+	if len(message) == 0 || (len(age) == 0 && strings.Contains(message, "{age}")) {
+		message = "Happy Birthday {user}!"
+	}
+	return strings.NewReplacer("{user}", "<@"+p.User+">", "{age}", age).Replace(message)
+}
+
+// builtinScheduleKinds returns the kinds of events the scheduler itself knows how to process
+func builtinScheduleKinds() []ScheduleEventKind {
+	return []ScheduleEventKind{
+		&scheduleKind{SCHEDULE_UNBAN, "ban", true, false, func(info *GuildInfo, e *ScheduleEvent, channel string) error {
+			p := ScheduleUserPayload{}
+			if err := e.Payload(&p); err != nil {
+				return err
+			}
+			err := sb.dg.GuildBanDelete(info.ID, p.User)
+			if err != nil {
+				return wrapScheduleError("Error unbanning <@"+p.User+">: ", err)
+			}
+			info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Unbanned <@"+p.User+">")
+			return nil
+		}, describeUserEvent("UNBAN")},
+		&scheduleKind{SCHEDULE_BIRTHDAY, "birthday", false, true, func(info *GuildInfo, e *ScheduleEvent, channel string) error {
+			setBirthdayRole(info, e, true)
+			p := ScheduleBirthdayPayload{}
+			e.Payload(&p)
+			if info.config.Schedule.BirthdayChannel != 0 {
+				channel = SBitoa(info.config.Schedule.BirthdayChannel)
+			}
+			return sendScheduledMessage(info, channel, birthdayMessage(info, &p, e.Date))
+		}, describeUserEvent("BIRTHDAY")},
+		&scheduleKind{SCHEDULE_MESSAGE, "message", false, true, func(info *GuildInfo, e *ScheduleEvent, channel string) error {
+			p := ScheduleMessagePayload{}
+			if err := e.Payload(&p); err != nil {
+				return err
+			}
+			return sendScheduledMessage(info, channel, p.Message)
+		}, describeMessageEvent("MESSAGE")},
+		&scheduleKind{SCHEDULE_EPISODE, "episode", false, true, processStartingEvent, func(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+			if len(info.config.Spoiler.Channels) > 0 && !FindIntSlice(SBatoi(msg.ChannelID), info.config.Spoiler.Channels) {
+				return "EPISODE", "(title removed)"
+			}
+			return describeMessageEvent("EPISODE")(info, e, msg)
+		}},
+		&scheduleKind{SCHEDULE_BIRTHDAY_END, "birthdayend", true, false, func(info *GuildInfo, e *ScheduleEvent, channel string) error {
+			setBirthdayRole(info, e, false)
+			return nil
+		}, describeUserEvent("BIRTHDAY END")},
+		&scheduleKind{SCHEDULE_EVENT, "event", false, true, processCommunityEvent, func(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+			p := ScheduleEventPayload{}
+			e.Payload(&p)
+			if n := sb.db.GetRSVPCount(e.ID); n > 0 {
+				return "EVENT", p.Message + " (" + strconv.Itoa(n) + " attending)"
+			}
+			return "EVENT", p.Message
+		}},
+		&scheduleKind{SCHEDULE_REMINDER, "reminder", false, false, func(info *GuildInfo, e *ScheduleEvent, channel string) error {
+			p := ScheduleReminderPayload{}
+			if err := e.Payload(&p); err != nil {
+				return err
+			}
+			if len(p.Channel) > 0 {
+				return sendScheduledMessage(info, p.Channel, "<@"+p.User+"> "+ReplaceAllMentions(p.Message)+"\n"+p.Link)
+			}
+			ch, err := sb.dg.UserChannelCreate(p.User)
+			if err != nil {
+				return wrapScheduleError("Error opening private channel: ", err)
+			}
+			return sendScheduledMessage(info, ch.ID, p.Message)
+		}, func(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+			p := ScheduleReminderPayload{}
+			e.Payload(&p)
+			return "REMINDER", p.Message
+		}},
+		&scheduleKind{SCHEDULE_ROLE, "role", false, true, func(info *GuildInfo, e *ScheduleEvent, channel string) error {
+			p := ScheduleRolePayload{}
+			if err := e.Payload(&p); err != nil {
+				return err
+			}
+			return sendScheduledMessage(info, channel, p.Role+" "+p.Message)
+		}, func(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+			p := ScheduleRolePayload{}
+			e.Payload(&p)
+			return "ROLE:" + ReplaceAllRolePings(p.Role, info), p.Message
+		}},
+		&scheduleKind{SCHEDULE_UNSILENCE, "silence", true, false, func(info *GuildInfo, e *ScheduleEvent, channel string) error {
+			p := ScheduleUserPayload{}
+			if err := e.Payload(&p); err != nil {
+				return err
+			}
+			err := UnsilenceMember(SBatoi(p.User), info)
+			if err != nil {
+				return wrapScheduleError("Error unsilencing <@"+p.User+">: ", err)
+			}
+			info.SendMessage(SBitoa(info.config.Basic.ModChannel), "Unsilenced <@"+p.User+">")
+			return nil
+		}, describeUserEvent("UNSILENCE")},
+		&scheduleKind{SCHEDULE_ATTENDEE_ROLE, "attendeerole", true, false, func(info *GuildInfo, e *ScheduleEvent, channel string) error {
+			p := ScheduleAttendeePayload{}
+			if err := e.Payload(&p); err != nil {
+				return err
+			}
+			for _, u := range p.Users {
+				err := sb.dg.GuildMemberRoleRemove(info.ID, u, p.Role)
+				info.LogError("Failed to remove attendee role from "+u+": ", err)
+			}
+			return nil
+		}, func(info *GuildInfo, e *ScheduleEvent, msg *discordgo.Message) (string, string) {
+			p := ScheduleAttendeePayload{}
+			e.Payload(&p)
+			return "ATTENDEE ROLE", "<@&" + p.Role + "> for " + Pluralize(int64(len(p.Users)), " attendee")
+		}},
+	}
+}
+
+func processStartingEvent(info *GuildInfo, e *ScheduleEvent, channel string) error {
+	p := ScheduleMessagePayload{}
+	if err := e.Payload(&p); err != nil {
+		return err
+	}
+	return sendScheduledMessage(info, channel, p.Message+" is starting now!")
+}
+
+// processCommunityEvent announces that an event is starting and gives everyone who RSVPed the attendee role until the event is over. The RSVPs are cleared afterwards, so members have to RSVP again for the next occurrence of a repeating event.
+func processCommunityEvent(info *GuildInfo, e *ScheduleEvent, channel string) error {
+	p := ScheduleEventPayload{}
+	if err := e.Payload(&p); err != nil {
+		return err
+	}
+	if err := sendScheduledMessage(info, channel, p.Message+" is starting now!"); err != nil {
+		return err
+	}
+	if info.config.Schedule.AttendeeRole != 0 {
+		role := SBitoa(info.config.Schedule.AttendeeRole)
+		users := []string{}
+		for _, u := range sb.db.GetRSVPs(e.ID) {
+			err := sb.dg.GuildMemberRoleAdd(info.ID, SBitoa(u), role)
+			if err != nil {
+				info.LogError("Failed to give attendee role to "+SBitoa(u)+": ", err)
+			} else {
+				users = append(users, SBitoa(u))
+			}
+		}
+		if len(users) > 0 {
+			end := time.Now().UTC().Add(time.Duration(info.config.Schedule.EventDuration) * time.Second)
+			sb.db.AddSchedule(SBatoi(info.ID), end, SCHEDULE_ATTENDEE_ROLE, EncodeSchedulePayload(ScheduleAttendeePayload{role, users}))
+		}
+	}
+	sb.db.ClearRSVPs(e.ID)
+	if p.Reminded {
+		p.Reminded = false
+		sb.db.SetEventData(e.ID, EncodeSchedulePayload(p))
+	}
+	return nil
+}
+
+// remindAttendees sends a private message to everyone who RSVPed to an event that starts within Schedule.RSVPReminder seconds
+func remindAttendees(info *GuildInfo) {
+	if info.config.Schedule.RSVPReminder <= 0 {
+		return
+	}
+	now := time.Now().UTC()
+	for _, v := range sb.db.GetUnremindedEvents(SBatoi(info.ID), now.Add(time.Duration(info.config.Schedule.RSVPReminder)*time.Second)) {
+		p := ScheduleEventPayload{}
+		if err := v.Payload(&p); err != nil {
+			continue
+		}
+		for _, u := range sb.db.GetRSVPs(v.ID) {
+			ch, err := sb.dg.UserChannelCreate(SBitoa(u))
+			if err != nil {
+				info.LogError("Error opening private channel: ", err)
+				continue
+			}
+			info.SendMessage(ch.ID, "Reminder: **"+p.Message+"** starts in "+TimeDiff(v.Date.Sub(now))+".")
+		}
+		p.Reminded = true
+		sb.db.SetEventData(v.ID, EncodeSchedulePayload(p))
+	}
+}
+
+// sendScheduledMessage sends a message and waits for discord to accept it, so that the event can be retried if it fails. Messages that are too long to send at once are handed to SendMessage instead.
+func sendScheduledMessage(info *GuildInfo, channelID string, message string) error {
+	if len(message) > 1999 {
+		if !info.SendMessage(channelID, message) {
+			return errors.New("Can't send messages to channel " + channelID)
+		}
+		return nil
+	}
+	ch, private := channelIsPrivate(channelID)
+	if !private && ch.GuildID != info.ID {
+		return permanentError{errors.New("Channel " + channelID + " isn't on this server")}
+	}
+	_, err := sb.dg.ChannelMessageSend(channelID, info.sanitizeOutput(message))
+	return err
+}
@@ -1,17 +1,26 @@
 package sweetiebot
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blackhole12/discordgo"
 )
 
+const searchRegexTimeout = time.Duration(5) * time.Second // Maximum amount of time a regex: search can take before it gives up
+
+// searchFilters lists every filter of the form name:value that the search command understands
+var searchFilters = map[string]bool{"after": true, "before": true, "during": true, "has": true, "mentions": true, "edited": true, "regex": true}
+
 type searchCommand struct {
 	emotes     *EmoteModule
-	lock       AtomicFlag
+	lock       sync.RWMutex // protects statements, since searches can run concurrently
 	statements map[string][]*sql.Stmt
 }
 
@@ -26,26 +35,205 @@ func MsgHighlightMatch(msg string, match string) string {
 	msg = strings.Replace(msg, "**"+match, match, -1)      // helps prevent ** from exploding everywhere because discord is bad at isolation.
 	return strings.Replace(msg, match, "**"+match+"**", -1)
 }
+
+// escapeLike escapes all wildcard characters in a string used in a LIKE clause
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// sanitizeFulltextTerm strips out characters that have a special meaning in a boolean fulltext query. If the term has spaces in it, it's turned into a phrase.
+func sanitizeFulltextTerm(s string) string {
+	wildcard := strings.HasSuffix(s, "*")
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`+-<>()~*"@`, r) {
+			return ' '
+		}
+		return r
+	}, s)
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) == 0 {
+		return ""
+	}
+	if strings.Contains(s, " ") {
+		return "\"" + s + "\""
+	}
+	if wildcard {
+		return s + "*"
+	}
+	return s
+}
+
+// buildFulltextQuery converts search terms into a MySQL boolean mode fulltext query, along with the list of terms that should be highlighted in the results.
+// Terms are ANDed together by default, OR separates groups of terms, NOT or a leading - excludes the next term, and any term with spaces in it (because it was quoted) is searched for as a phrase.
+// A fulltext query can't consist only of excluded terms, so if nothing else is being searched for, the excluded terms are returned separately instead.
+func buildFulltextQuery(terms []string) (string, []string, []string, error) {
+	groups := []string{}
+	group := []string{}
+	highlights := []string{}
+	excluded := []string{}
+	required := 0
+	negate := false
+	endGroup := func() error {
+		if len(group) == 0 {
+			return errors.New("OR must be used between two search terms")
+		}
+		if required == 0 {
+			return errors.New("NOT must be used alongside at least one term to search for")
+		}
+		groups = append(groups, strings.Join(group, " "))
+		group = []string{}
+		required = 0
+		return nil
+	}
+
+	for _, v := range terms {
+		switch v {
+		case "AND":
+			continue
+		case "NOT":
+			negate = true
+			continue
+		case "OR":
+			if negate {
+				return "", nil, nil, errors.New("NOT must be followed by a search term")
+			}
+			if err := endGroup(); err != nil {
+				return "", nil, nil, err
+			}
+			continue
+		}
+		if len(v) > 1 && v[0] == '-' {
+			negate = true
+			v = v[1:]
+		}
+		term := sanitizeFulltextTerm(v)
+		if len(term) > 0 {
+			if negate {
+				group = append(group, "-"+term)
+				excluded = append(excluded, strings.Trim(term, "\"*"))
+			} else {
+				group = append(group, "+"+term)
+				highlights = append(highlights, strings.Trim(term, "\"*"))
+				required++
+			}
+		}
+		negate = false
+	}
+	if negate {
+		return "", nil, nil, errors.New("NOT must be followed by a search term")
+	}
+	if len(groups) == 0 && len(group) == 0 {
+		return "", highlights, nil, nil
+	}
+	if len(groups) == 0 && required == 0 {
+		return "", highlights, excluded, nil
+	}
+	if err := endGroup(); err != nil {
+		return "", nil, nil, err
+	}
+	if len(groups) == 1 {
+		return groups[0], highlights, nil, nil
+	}
+	return "(" + strings.Join(groups, ") (") + ")", highlights, nil, nil
+}
+
+// resolveSearchUsers converts a list of pings or @usernames into user IDs
+func resolveSearchUsers(users []string, info *GuildInfo) ([]uint64, error) {
+	userIDs := make([]uint64, 0, len(users))
+	for _, v := range users {
+		v = strings.TrimSpace(v)
+		if userregex.MatchString(v) {
+			userIDs = append(userIDs, SBatoi(v[2:len(v)-1]))
+		} else {
+			v = strings.TrimPrefix(v, "@")
+			IDs := FindUsername(v, info)
+			if len(IDs) == 0 { // we failed to resolve this username, so return an error.
+				return nil, errors.New("Could not find any usernames or aliases matching " + v + "!")
+			}
+			userIDs = append(userIDs, IDs...)
+		}
+	}
+	return userIDs, nil
+}
+
 func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
 	if !sb.db.CheckStatus() {
 		return "```A temporary database outage is preventing this command from being executed.```", false, nil
 	}
-	if c.lock.test_and_set() {
-		return "```Sorry, I'm busy processing another request right now. Please try again later!```", false, nil
-	}
-	defer c.lock.clear()
 	rangebegin := 0
 	rangeend := 5
 	users := make([]string, 0, 0)
-	userIDs := make([]uint64, 0, 0)
 	channels := make([]uint64, 0, 0)
+	mentions := make([]string, 0, 0)
 	t := time.Now().UTC().AddDate(0, 0, 1)
+	var after time.Time
+	haslink := false
+	hasattachment := false
+	edited := -1 // -1 means we don't care, 0 means never edited, 1 means edited
+	var regex *regexp.Regexp
 	messages := make([]string, 0, 0)
 
 	// Fill in parameters from args
-	for _, v := range args {
+	for i := 0; i < len(args); i++ {
+		v := args[i]
 		if len(v) > 0 {
+			// Filters are of the form name:value, but if the value was quoted, it ends up in the next argument
+			filter := ""
+			value := ""
+			if n := strings.Index(v, ":"); n > 0 && searchFilters[strings.ToLower(v[:n])] {
+				filter = strings.ToLower(v[:n])
+				value = v[n+1:]
+				if len(value) == 0 && i+1 < len(args) {
+					i++
+					value = args[i]
+				}
+				if len(value) == 0 {
+					return "```Error: " + filter + ": must be followed by a value.```", false, nil
+				}
+			}
+
 			switch {
+			case filter == "after" || filter == "before" || filter == "during":
+				tm, err := parseCommonTime(value, info, msg.Author)
+				if err != nil {
+					return "```Error: " + err.Error() + "```", false, nil
+				}
+				tm = tm.UTC()
+				switch filter {
+				case "after":
+					after = tm
+				case "before":
+					t = tm
+				case "during":
+					after = tm
+					t = tm.AddDate(0, 0, 1)
+				}
+			case filter == "has":
+				switch strings.ToLower(value) {
+				case "link", "links":
+					haslink = true
+				case "attachment", "attachments", "file", "files":
+					hasattachment = true
+				default:
+					return "```Error: has: must be either link or attachment.```", false, nil
+				}
+			case filter == "mentions":
+				mentions = append(mentions, strings.Split(value, "|")...)
+			case filter == "edited":
+				switch strings.ToLower(value) {
+				case "true", "yes":
+					edited = 1
+				case "false", "no":
+					edited = 0
+				default:
+					return "```Error: edited: must be either true or false.```", false, nil
+				}
+			case filter == "regex":
+				var err error
+				regex, err = regexp.Compile(value)
+				if err != nil {
+					return "```Error: Invalid regex: " + err.Error() + "```", false, nil
+				}
 			case v[0] == '*':
 				if len(v) < 2 {
 					rangeend = -1
@@ -90,22 +278,23 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 	}
 
 	// Resolve usernames that aren't IDs to IDs
-	for _, v := range users {
-		v = strings.TrimSpace(v)
-		if userregex.MatchString(v) {
-			userIDs = append(userIDs, SBatoi(v[2:len(v)-1]))
-		} else {
-			IDs := FindUsername(v[1:], info)
-			if len(IDs) == 0 { // we failed to resolve this username, so return an error.
-				return "```Error: Could not find any usernames or aliases matching " + v[1:] + "!```", false, nil
-			}
-			userIDs = append(userIDs, IDs...)
-		}
+	userIDs, err := resolveSearchUsers(users, info)
+	if err != nil {
+		return "```Error: " + err.Error() + "```", false, nil
+	}
+	mentionIDs, err := resolveSearchUsers(mentions, info)
+	if err != nil {
+		return "```Error: " + err.Error() + "```", false, nil
+	}
+
+	fulltext, highlights, excluded, err := buildFulltextQuery(messages)
+	if err != nil {
+		return "```Error: " + err.Error() + "```", false, nil
 	}
 
 	// If we have no searchable arguments, fail
-	if len(messages)+len(userIDs)+len(channels) == 0 {
-		return "```Error: no searchable terms specified! You must have either a message, a user, or a channel.```", false, nil
+	if len(fulltext)+len(excluded)+len(userIDs)+len(channels)+len(mentionIDs) == 0 && !haslink && !hasattachment && edited < 0 && regex == nil && after.IsZero() {
+		return "```Error: no searchable terms specified! You must have either a message, a user, a channel, or a filter.```", false, nil
 	}
 
 	// Assemble query string and parameter list
@@ -131,10 +320,49 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 		query += "(" + strings.Join(temp, " OR ") + ") AND "
 	}
 
-	message := strings.Join(messages, " ")
-	if len(messages) > 0 {
-		query += "C.Message LIKE ? AND "
-		params = append(params, "%"+message+"%")
+	if len(mentionIDs) > 0 { // Mentions are stored in the chatlog as @username
+		temp := make([]string, 0, len(mentionIDs))
+		for _, v := range mentionIDs {
+			u, _, _, _ := sb.db.GetUser(v)
+			if u != nil {
+				temp = append(temp, "C.Message LIKE ?")
+				params = append(params, "%@"+escapeLike(u.Username)+"%")
+			}
+		}
+		if len(temp) == 0 {
+			return "```No results found.```", false, nil
+		}
+		query += "(" + strings.Join(temp, " OR ") + ") AND "
+	}
+
+	if len(fulltext) > 0 {
+		query += "MATCH (C.Message) AGAINST (? IN BOOLEAN MODE) AND "
+		params = append(params, fulltext)
+	}
+
+	for _, v := range excluded {
+		query += "C.Message NOT LIKE ? AND "
+		params = append(params, "%"+escapeLike(v)+"%")
+	}
+
+	if haslink {
+		query += "(C.Message LIKE '%http://%' OR C.Message LIKE '%https://%') AND "
+	}
+
+	if hasattachment {
+		query += "C.Attachments > 0 AND "
+	}
+
+	switch edited {
+	case 0:
+		query += "NOT EXISTS (SELECT 1 FROM editlog E WHERE E.ID = C.ID) AND "
+	case 1:
+		query += "EXISTS (SELECT 1 FROM editlog E WHERE E.ID = C.ID) AND "
+	}
+
+	if !after.IsZero() {
+		query += "C.Timestamp >= ? AND "
+		params = append(params, after)
 	}
 
 	if t.Before(time.Now().UTC()) {
@@ -150,13 +378,20 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 		}
 	}
 
-	query += "C.ID != ? AND C.Author != ? AND C.Channel != ? AND C.Message NOT LIKE '" + info.config.Basic.CommandPrefix + "search %' ORDER BY C.Timestamp DESC" // Always exclude the message corresponding to the command and all sweetie bot messages (which also prevents trailing ANDs)
+	query += "C.ID != ? AND C.Author != ? AND C.Channel != ? AND C.Message NOT LIKE '" + info.config.Basic.CommandPrefix + "search %'" // Always exclude the message corresponding to the command and all sweetie bot messages (which also prevents trailing ANDs)
 	params = append(params, SBatoi(msg.ID))
 	params = append(params, SBatoi(sb.SelfID))
 	params = append(params, info.config.Basic.ModChannel)
+	countparams := params
 
 	querylimit := query
-	if rangeend >= 0 {
+	if len(fulltext) > 0 { // Order results by relevance if we're searching for text, then by time.
+		querylimit += " ORDER BY MATCH (C.Message) AGAINST (? IN BOOLEAN MODE) DESC, C.Timestamp DESC"
+		params = append(params, fulltext)
+	} else {
+		querylimit += " ORDER BY C.Timestamp DESC"
+	}
+	if rangeend >= 0 && regex == nil { // Regex searches have to check every message themselves, so they can't use LIMIT
 		querylimit += " LIMIT ?"
 		if rangebegin > 0 {
 			querylimit += " OFFSET ?"
@@ -164,7 +399,9 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 	}
 
 	// if not cached, prepare the statement and store it in a map.
+	c.lock.RLock()
 	stmt, ok := c.statements[querylimit]
+	c.lock.RUnlock()
 	if !ok {
 		stmt1, err := sb.db.Prepare("SELECT COUNT(*) FROM chatlog C WHERE C.Guild = ? AND " + query)
 		stmt2, err2 := sb.db.Prepare("SELECT U.Username, C.Message, C.Timestamp, U.ID FROM chatlog C INNER JOIN users U ON C.Author = U.ID WHERE C.Guild = ? AND " + querylimit)
@@ -176,17 +413,66 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 			return "```Error: Failed to prepare statement!```", false, nil
 		}
 		stmt = []*sql.Stmt{stmt1, stmt2}
+		c.lock.Lock()
 		c.statements[querylimit] = stmt
+		c.lock.Unlock()
+	}
+
+	// Figure out which results were requested. rangebegin starts at 1, not 0
+	offset := 0
+	limit := rangeend
+	if rangebegin > 0 {
+		if rangeend-rangebegin > info.config.Search.MaxResults {
+			rangeend = rangebegin + info.config.Search.MaxResults
+		}
+		if rangeend-rangebegin < 0 {
+			rangeend = rangebegin
+		}
+		limit = rangeend - rangebegin + 1
+		offset = rangebegin - 1 // adjust this so the beginning starts at 1 instead of 0
+	} else if limit > info.config.Search.MaxResults {
+		limit = info.config.Search.MaxResults
 	}
 
-	// Execute the statement as a count if appropriate, otherwise retrieve a list of messages and construct a return message from them.
 	count := 0
-	err := stmt[0].QueryRow(params...).Scan(&count)
-	if err == sql.ErrNoRows {
-		return "```Error: Expected 1 row, but got no rows!```", false, nil
+	timedout := false
+	r := make([]PingContext, 0, 5)
+	if regex != nil {
+		// Regex searches retrieve every message matching the other parameters and check them one by one, until they run out of time.
+		ctx, cancel := context.WithTimeout(context.Background(), searchRegexTimeout)
+		defer cancel()
+		q, err := stmt[1].QueryContext(ctx, params...)
+		if err != nil && ctx.Err() != nil {
+			return "```Error: The regex search timed out. Try narrowing it down with a user, a channel, or a time range.```", false, nil
+		}
+		if sb.db.CheckError("Search Command", err) {
+			return "```Error getting search results.```", false, nil
+		}
+		defer q.Close()
+		for q.Next() {
+			p := PingContext{}
+			var uid uint64
+			if err := q.Scan(&p.Author, &p.Message, &p.Timestamp, &uid); err == nil && regex.MatchString(p.Message) {
+				if count >= offset && len(r) < limit {
+					p.Author = getUserName(uid, info)
+					r = append(r, p)
+				}
+				count++
+			}
+		}
+		timedout = ctx.Err() != nil
+	} else {
+		// Execute the statement as a count if appropriate, otherwise retrieve a list of messages and construct a return message from them.
+		err = stmt[0].QueryRow(countparams...).Scan(&count)
+		if err == sql.ErrNoRows {
+			return "```Error: Expected 1 row, but got no rows!```", false, nil
+		}
 	}
 
 	if count == 0 {
+		if timedout {
+			return "```No results found before the regex search timed out. Try narrowing it down with a user, a channel, or a time range.```", false, nil
+		}
 		return "```No results found.```", false, nil
 	}
 
@@ -195,41 +481,32 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 		strmatch = " match"
 	} // I hate plural forms
 	ret := "```Search results: " + strconv.Itoa(count) + strmatch + ".```\n"
+	if timedout {
+		ret = "```Search results: at least " + strconv.Itoa(count) + strmatch + " (the regex search timed out before it could check every message).```\n"
+	}
 
 	if rangebegin < 0 || rangeend < 0 {
 		return ret, false, nil
 	}
 
-	if rangeend >= 0 {
-		if rangebegin > 0 { // rangebegin starts at 1, not 0
-			if rangeend-rangebegin > info.config.Search.MaxResults {
-				rangeend = rangebegin + info.config.Search.MaxResults
-			}
-			if rangeend-rangebegin < 0 {
-				rangeend = rangebegin
-			}
-			params = append(params, rangeend-rangebegin+1)
-			params = append(params, rangebegin-1) // adjust this so the beginning starts at 1 instead of 0
-		} else {
-			if rangeend > info.config.Search.MaxResults {
-				rangeend = info.config.Search.MaxResults
-			}
-			params = append(params, rangeend)
+	if regex == nil {
+		params = append(params, limit)
+		if rangebegin > 0 {
+			params = append(params, offset)
 		}
-	}
 
-	q, err := stmt[1].Query(params...)
-	if sb.db.CheckError("Search Command", err) {
-		return "```Error getting search results.```", false, nil
-	}
-	defer q.Close()
-	r := make([]PingContext, 0, 5)
-	for q.Next() {
-		p := PingContext{}
-		var uid uint64
-		if err := q.Scan(&p.Author, &p.Message, &p.Timestamp, &uid); err == nil {
-			p.Author = getUserName(uid, info)
-			r = append(r, p)
+		q, err := stmt[1].Query(params...)
+		if sb.db.CheckError("Search Command", err) {
+			return "```Error getting search results.```", false, nil
+		}
+		defer q.Close()
+		for q.Next() {
+			p := PingContext{}
+			var uid uint64
+			if err := q.Scan(&p.Author, &p.Message, &p.Timestamp, &uid); err == nil {
+				p.Author = getUserName(uid, info)
+				r = append(r, p)
+			}
 		}
 	}
 
@@ -238,7 +515,15 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 	}
 
 	for _, v := range r {
-		ret += "[" + ApplyTimezone(v.Timestamp, info, msg.Author).Format("1/2 3:04:05PM") + "] " + v.Author + ": " + MsgHighlightMatch(v.Message, message) + "\n"
+		for _, h := range highlights {
+			v.Message = MsgHighlightMatch(v.Message, h)
+		}
+		if regex != nil {
+			for _, h := range regex.FindAllString(v.Message, 5) {
+				v.Message = MsgHighlightMatch(v.Message, h)
+			}
+		}
+		ret += "[" + ApplyTimezone(v.Timestamp, info, msg.Author).Format("1/2 3:04:05PM") + "] " + v.Author + ": " + v.Message + "\n"
 	}
 
 	ret = strings.Replace(ret, "http://", "http\u200B://", -1)
@@ -248,13 +533,20 @@ func (c *searchCommand) Process(args []string, msg *discordgo.Message, indices [
 }
 func (c *searchCommand) Usage(info *GuildInfo) *CommandUsage {
 	return &CommandUsage{
-		Desc: "This is an arbitrary search command run on sweetiebot's 7 day chat log. All parameters are optional and can be input in any order, and will all be combined into a single search as appropriate, but if no searchable parameters are given, the operation will fail.  Remember that if a username has spaces in it, you have to put the entire username parameter in quotes, not just the username itself! \n\n Example: `" + info.config.Basic.CommandPrefix + "search #manechat @cloud|@JamesNotABot *4 \"~Sep 8 12:00pm\"`\n This will return the most recent 4 messages said by any user with \"cloud\" in the name, or the user JamesNotABot, in the #manechat channel, before Sept 8 12:00pm.",
+		Desc: "This is an arbitrary search command run on sweetiebot's chat log, which keeps messages for `Log.ChatlogRetention` days. All parameters are optional and can be input in any order, and will all be combined into a single search as appropriate, but if no searchable parameters are given, the operation will fail. If you search for a message, the most relevant results are returned first, otherwise the most recent results are returned first.  Remember that if a username has spaces in it, you have to put the entire username parameter in quotes, not just the username itself! \n\n Example: `" + info.config.Basic.CommandPrefix + "search #manechat @cloud|@JamesNotABot *4 \"~Sep 8 12:00pm\"`\n This will return the most recent 4 messages said by any user with \"cloud\" in the name, or the user JamesNotABot, in the #manechat channel, before Sept 8 12:00pm.\n\n Example: `" + info.config.Basic.CommandPrefix + "search has:link after: \"Sep 8\" mentions:@cloud -spoilers`\n This will return messages containing a link that mention cloud and don't contain the word \"spoilers\", sent after Sept 8.",
 		Params: []CommandUsageParam{
 			{Name: "*[result-range]", Desc: "Specifies what results should be returned. Specifying '*10' will return the first 10 results, while '*5-10' will return the 5th to the 10th result (inclusive). If you ONLY specify a single * character, it will only return a count of the total number of results.", Optional: true},
 			{Name: "@user[|@user2|...]", Desc: "Specifies a target user name to search for. An actual ping will be more effective, as it can directly use the user ID, but a raw username will be searched for in the alias table. Multiple users can be searched for by separating them with `|`, but each user must still be prefixed with `@` even if it's not a ping", Optional: true},
 			{Name: "#channel[|#channel2|...]", Desc: "Must be an actual channel recognized by discord, which means it should be an actual ping in the format `#channel`, which will filter results to that channel. Multiple channels can be specified using `|`, the same way users can.", Optional: true},
-			{Name: "~timestamp", Desc: "Tells the search to only return messages that appeared before the given timestamp. This parameter MUST BE IN QUOTES or it will not be parsed correctly.", Optional: true},
-			{Name: "message", Desc: "Will be constructed from all the remaining unrecognized parameters, so you don't need quotes around the message you're looking for.", Optional: true},
+			{Name: "~timestamp", Desc: "Tells the search to only return messages that appeared before the given timestamp. Relative times like `~\"3 hours ago\"` or `~yesterday` also work. This parameter MUST BE IN QUOTES if it has spaces or it will not be parsed correctly.", Optional: true},
+			{Name: "before: timestamp", Desc: "Same as `~timestamp`. Put the timestamp in quotes if it has spaces: `before: \"Sep 8 12:00pm\"`", Optional: true},
+			{Name: "after: timestamp", Desc: "Only returns messages that appeared after the given timestamp. Can be combined with `before:` to search a range of time.", Optional: true},
+			{Name: "during: date", Desc: "Only returns messages that appeared during the 24 hours starting at the given date, like `during: \"Sep 8\"`.", Optional: true},
+			{Name: "has:link|attachment", Desc: "Only returns messages that contain a link, or messages that had an attachment.", Optional: true},
+			{Name: "mentions:@user[|@user2|...]", Desc: "Only returns messages that mention the given user. Multiple users can be specified using `|`, the same way as `@user`.", Optional: true},
+			{Name: "edited:true|false", Desc: "Only returns messages that were edited, or messages that were never edited.", Optional: true},
+			{Name: "regex:expression", Desc: "Only returns messages that match the given regular expression. Put the expression in quotes if it has spaces, and start it with `(?i)` to make it case-insensitive. Regex searches have to check every single message, so they give up after " + TimeDiff(searchRegexTimeout) + " - narrow them down with other parameters to make them faster.", Optional: true},
+			{Name: "message", Desc: "Will be constructed from all the remaining unrecognized parameters, and only matches messages that contain all of the given words. Put quotes around words to search for an exact \"phrase\", put `OR` between words to match either of them (`apple banana OR cherry` matches messages with both apple and banana, or with cherry), put `NOT` or `-` in front of a word to exclude messages containing it, and end a word with `*` to match any word starting with it. Very short or very common words are ignored.", Optional: true},
 		},
 	}
 }
@@ -0,0 +1,87 @@
+package sweetiebot
+
+import (
+	"strings"
+	"testing"
+)
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBuildFulltextQuery(t *testing.T) {
+	cases := []struct {
+		terms      []string
+		query      string
+		highlights []string
+		excluded   []string
+	}{
+		{[]string{}, "", nil, nil},
+		{[]string{"cats"}, "+cats", []string{"cats"}, nil},
+		{[]string{"cats", "dogs"}, "+cats +dogs", []string{"cats", "dogs"}, nil},
+		{[]string{"cats", "AND", "dogs"}, "+cats +dogs", []string{"cats", "dogs"}, nil},
+		{[]string{"cats", "OR", "dogs"}, "(+cats) (+dogs)", []string{"cats", "dogs"}, nil},
+		{[]string{"cats", "birds", "OR", "dogs"}, "(+cats +birds) (+dogs)", []string{"cats", "birds", "dogs"}, nil},
+		{[]string{"cats", "-dogs"}, "+cats -dogs", []string{"cats"}, nil},
+		{[]string{"cats", "NOT", "dogs"}, "+cats -dogs", []string{"cats"}, nil},
+		{[]string{"-dogs"}, "", nil, []string{"dogs"}},
+		{[]string{"NOT", "dogs", "-cat*"}, "", nil, []string{"dogs", "cat"}},
+		{[]string{"big cats"}, "+\"big cats\"", []string{"big cats"}, nil},
+		{[]string{"cat*"}, "+cat*", []string{"cat"}, nil},
+		{[]string{"-"}, "", nil, nil},
+
+		// Operators inside terms must not reach the fulltext query
+		{[]string{"+cats"}, "+cats", []string{"cats"}, nil},
+		{[]string{"(cats)"}, "+cats", []string{"cats"}, nil},
+		{[]string{"\"cats\""}, "+cats", []string{"cats"}, nil},
+		{[]string{"~cats>"}, "+cats", []string{"cats"}, nil},
+		{[]string{"c@ts"}, "+\"c ts\"", []string{"c ts"}, nil},
+		{[]string{"a<b>c"}, "+\"a b c\"", []string{"a b c"}, nil},
+		{[]string{"c*t"}, "+\"c t\"", []string{"c t"}, nil},
+		{[]string{"big \"cats\" -now"}, "+\"big cats now\"", []string{"big cats now"}, nil},
+		{[]string{"--cats"}, "", nil, []string{"cats"}},
+		{[]string{"~", "*", "()"}, "", nil, nil},
+		{[]string{"cats", "+-\"\""}, "+cats", []string{"cats"}, nil},
+	}
+	for _, c := range cases {
+		query, highlights, excluded, err := buildFulltextQuery(c.terms)
+		if err != nil {
+			t.Errorf("buildFulltextQuery(%q) failed: %s", c.terms, err.Error())
+			continue
+		}
+		if query != c.query || !equalStrings(highlights, c.highlights) || !equalStrings(excluded, c.excluded) {
+			t.Errorf("buildFulltextQuery(%q) = %q, %q, %q, want %q, %q, %q", c.terms, query, highlights, excluded, c.query, c.highlights, c.excluded)
+		}
+	}
+}
+
+func TestBuildFulltextQueryErrors(t *testing.T) {
+	cases := []struct {
+		terms []string
+		err   string
+	}{
+		{[]string{"OR", "cats"}, "OR must be used between two search terms"},
+		{[]string{"cats", "OR"}, "OR must be used between two search terms"},
+		{[]string{"cats", "OR", "OR", "dogs"}, "OR must be used between two search terms"},
+		{[]string{"cats", "NOT"}, "NOT must be followed by a search term"},
+		{[]string{"NOT", "OR", "cats"}, "NOT must be followed by a search term"},
+		{[]string{"cats", "OR", "-dogs"}, "NOT must be used alongside at least one term to search for"},
+		{[]string{"-dogs", "OR", "cats"}, "NOT must be used alongside at least one term to search for"},
+	}
+	for _, c := range cases {
+		query, _, _, err := buildFulltextQuery(c.terms)
+		if err == nil {
+			t.Errorf("buildFulltextQuery(%q) = %q, expected an error", c.terms, query)
+		} else if !strings.Contains(err.Error(), c.err) {
+			t.Errorf("buildFulltextQuery(%q) failed with %q, want %q", c.terms, err.Error(), c.err)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackhole12/discordgo"
+)
+
+const statsTopCount = 5
+
+// parseStatsPeriod looks for a period like "30 days" or "week" at the end of the arguments, and returns how many days it covers along with the remaining arguments. If there is no period, it defaults to 7 days.
+func parseStatsPeriod(args []string) (int, []string) {
+	n := len(args)
+	unitDays := func(s string) int {
+		switch parseRepeatInterval(s) {
+		case 4:
+			return 1
+		case 5:
+			return 7
+		case 6:
+			return 30
+		case 7:
+			return 91
+		case 8:
+			return 365
+		}
+		return 0
+	}
+	if n >= 2 {
+		if amount, err := strconv.Atoi(args[n-2]); err == nil && amount > 0 {
+			if days := unitDays(args[n-1]); days > 0 {
+				return amount * days, args[:n-2]
+			}
+		}
+	}
+	if n >= 1 {
+		if days := unitDays(args[n-1]); days > 0 {
+			return days, args[:n-1]
+		}
+	}
+	return 7, args
+}
+
+// statsStart returns the start of the first local day of a period that ends today
+func statsStart(days int, tz *time.Location) time.Time {
+	local := time.Now().In(tz)
+	return time.Date(local.Year(), local.Month(), local.Day()-days+1, 0, 0, 0, 0, tz)
+}
+
+// statsDailyGraph groups activity buckets by local day, or by week if the period is longer than a month, and renders them as bar graphs
+func statsDailyGraph(buckets []ActivityBucket, start time.Time, days int, tz *time.Location) []string {
+	step := 1
+	format := "Mon 01/02"
+	if days > 31 {
+		step = 7
+		format = "Wk. 01/02"
+	}
+	counts := make([]uint64, (days+step-1)/step)
+	for _, v := range buckets {
+		local := v.Start.In(tz)
+		day := int(time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC).Sub(time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24)
+		if day >= 0 && day/step < len(counts) {
+			counts[day/step] += v.Count
+		}
+	}
+	max := uint64(0)
+	for _, v := range counts {
+		if v > max {
+			max = v
+		}
+	}
+	lines := make([]string, 0, len(counts))
+	for i, v := range counts {
+		lines = append(lines, fmt.Sprintf("`%s `%s %v", start.AddDate(0, 0, i*step).Format(format), barGraph(v, max), v))
+	}
+	return lines
+}
+
+// statsHourlyGraph groups activity buckets by the local hour of the day and renders them as bar graphs
+func statsHourlyGraph(buckets []ActivityBucket, tz *time.Location) []string {
+	counts := make([]uint64, 24)
+	for _, v := range buckets {
+		counts[v.Start.In(tz).Hour()] += v.Count
+	}
+	max := uint64(0)
+	for _, v := range counts {
+		if v > max {
+			max = v
+		}
+	}
+	lines := make([]string, 0, 24)
+	for i, v := range counts {
+		lines = append(lines, fmt.Sprintf("`%02d:00 `%s %v", i, barGraph(v, max), v))
+	}
+	return lines
+}
+
+func statsTopGraph(counts []StatsCount, name func(uint64) string) []string {
+	max := uint64(0)
+	if len(counts) > 0 {
+		max = counts[0].Count
+	}
+	lines := make([]string, 0, len(counts))
+	for i, v := range counts {
+		lines = append(lines, fmt.Sprintf("`%v. `%s %s (%v messages)", i+1, barGraph(v.Count, max), name(v.ID), v.Count))
+	}
+	return lines
+}
+
+type statsCommand struct {
+}
+
+func (c *statsCommand) Name() string {
+	return "Stats"
+}
+func (c *statsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	days, rest := parseStatsPeriod(args)
+	if days > 365 {
+		days = 365
+	}
+	var channel, user uint64
+	subject := "this server"
+	if len(rest) > 0 {
+		if channelregex.MatchString(rest[0]) && len(rest) == 1 {
+			channel = SBatoi(rest[0][2 : len(rest[0])-1])
+			if !canViewLoggedChannel(info, channel, msg) {
+				return "```Error: You can only view statistics for that channel inside it.```", false, nil
+			}
+			subject = "<#" + SBitoa(channel) + ">"
+		} else {
+			arg := strings.Join(rest, " ")
+			IDs := FindUsername(arg, info)
+			if len(IDs) == 0 { // no matches!
+				return "```Error: Could not find any usernames or aliases matching " + arg + "!```", false, nil
+			}
+			if len(IDs) > 1 {
+				return "```Could be any of the following users or their aliases:\n" + strings.Join(IDsToUsernames(IDs, info, true), "\n") + "```", len(IDs) > 5, nil
+			}
+			user = IDs[0]
+			subject = getUserName(user, info)
+		}
+	}
+
+	gID := SBatoi(info.ID)
+	tz := getTimezone(info, nil)
+	start := statsStart(days, tz)
+	buckets := sb.db.GetActivity(gID, channel, user, start.UTC())
+	total := uint64(0)
+	for _, v := range buckets {
+		total += v.Count
+	}
+	if total == 0 {
+		return "```No messages were logged for " + strings.Replace(subject, "<#", "#", 1) + " over the past " + Pluralize(int64(days), " day") + ".```", false, nil
+	}
+
+	lines := []string{fmt.Sprintf("**Activity of %s over the past %s:** %s", subject, Pluralize(int64(days), " day"), Pluralize(int64(total), " message"))}
+	lines = append(lines, "**Messages per day:**")
+	lines = append(lines, statsDailyGraph(buckets, start, days, tz)...)
+	lines = append(lines, "**Busiest hours ("+tz.String()+"):**")
+	lines = append(lines, statsHourlyGraph(buckets, tz)...)
+	if user == 0 {
+		lines = append(lines, "**Top posters:**")
+		lines = append(lines, statsTopGraph(sb.db.GetTopPosters(gID, channel, start.UTC(), statsTopCount), func(id uint64) string { return getUserName(id, info) })...)
+	}
+	if channel == 0 {
+		channels := []StatsCount{}
+		for _, v := range sb.db.GetTopChannels(gID, user, start.UTC(), statsTopCount+2) {
+			if canViewLoggedChannel(info, v.ID, msg) && len(channels) < statsTopCount {
+				channels = append(channels, v)
+			}
+		}
+		lines = append(lines, "**Top channels:**")
+		lines = append(lines, statsTopGraph(channels, func(id uint64) string { return "<#" + SBitoa(id) + ">" })...)
+	}
+	return strings.Join(lines, "\n"), false, nil
+}
+func (c *statsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Shows how many messages were sent per day, the busiest hours of the day in the server's timezone, and the top posters and channels, using the chat log. Can be restricted to a single channel or a single user. Only covers the last `Log.ChatlogRetention` days, because older messages are removed from the chat log.",
+		Params: []CommandUsageParam{
+			{Name: "#channel|user", Desc: "Either a channel ping, to show statistics for only that channel, or a user, to show when and where they post. A ping works best, but a raw username will be searched for in the alias table.", Optional: true},
+			{Name: "period", Desc: "How far back to look, like `30 days`, `2 weeks` or `month`. Defaults to 7 days. Periods longer than a month are shown per week.", Optional: true},
+		},
+	}
+}
+func (c *statsCommand) UsageShort() string { return "Shows message activity statistics." }
+
+type joinStatsCommand struct {
+}
+
+func (c *joinStatsCommand) Name() string {
+	return "JoinStats"
+}
+func (c *joinStatsCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if !sb.db.CheckStatus() {
+		return "```A temporary database outage is preventing this command from being executed.```", false, nil
+	}
+	days, rest := parseStatsPeriod(args)
+	if len(rest) > 0 {
+		return "```Error: " + strings.Join(rest, " ") + " is not a valid period. Try something like '30 days' or 'month'.```", false, nil
+	}
+	gID := SBatoi(info.ID)
+	start := statsStart(days, getTimezone(info, nil)).UTC()
+	joins, posted, departed := sb.db.GetJoinCounts(gID, start)
+	leaves := sb.db.GetLeaveCount(gID, start)
+	percent := func(n uint64) string {
+		if joins == 0 {
+			return "0%"
+		}
+		return strconv.FormatUint(n*100/joins, 10) + "%"
+	}
+
+	growth := int64(joins) - int64(leaves)
+	sign := ""
+	if growth > 0 {
+		sign = "+"
+	}
+	lines := []string{
+		"Member activity over the past " + Pluralize(int64(days), " day") + ":",
+		fmt.Sprintf("Joined: %v", joins),
+		fmt.Sprintf("Left: %v", leaves),
+		fmt.Sprintf("Net growth: %s%v", sign, growth),
+		fmt.Sprintf("Newcomers who sent a message: %v (%s)", posted, percent(posted)),
+		fmt.Sprintf("Newcomers who left again: %v (%s)", departed, percent(departed)),
+	}
+	delays := sb.db.GetFirstMessageDelays(gID, start)
+	if len(delays) > 0 {
+		median := delays[len(delays)/2]
+		if len(delays)%2 == 0 {
+			median = (delays[len(delays)/2-1] + delays[len(delays)/2]) / 2
+		}
+		lines = append(lines, "Median time to first message: "+TimeDiff(median))
+	}
+	return "```\n" + strings.Join(lines, "\n") + "```", false, nil
+}
+func (c *joinStatsCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Reports how many members joined and left the server, the net growth, how many of the newcomers sent at least one message or left again, and the median time newcomers took to send their first message. Members that left before this was tracked are not counted.",
+		Params: []CommandUsageParam{
+			{Name: "period", Desc: "How far back to look, like `30 days`, `2 weeks` or `month`. Defaults to 7 days.", Optional: true},
+		},
+	}
+}
+func (c *joinStatsCommand) UsageShort() string { return "Shows join and retention statistics." }
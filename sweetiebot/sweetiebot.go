@@ -46,23 +46,35 @@ type BotConfig struct {
 		CommandMaxDuration int64                      `json:"commandmaxduration"`
 	} `json:"modules"`
 	Spam struct {
-		ImagePressure      float32            `json:"imagepressure"`
-		PingPressure       float32            `json:"pingpressure"`
-		LengthPressure     float32            `json:"lengthpressure"`
-		RepeatPressure     float32            `json:"repeatpressure"`
-		LinePressure       float32            `json:"linepressure"`
-		BasePressure       float32            `json:"basepressure"`
-		PressureDecay      float32            `json:"pressuredecay"`
-		MaxPressure        float32            `json:"maxpressure"`
-		MaxChannelPressure map[uint64]float32 `json:"maxchannelpressure"`
-		MaxRemoveLookback  int                `json:"MaxSpamRemoveLookback"`
-		SilentRole         uint64             `json:"silentrole"`
-		IgnoreRole         uint64             `json:"ignorerole"`
-		RaidTime           int64              `json:"maxraidtime"`
-		RaidSize           int                `json:"raidsize"`
-		SilenceMessage     string             `json:"silencemessage"`
-		AutoSilence        int                `json:"autosilence"`
-		LockdownDuration   int                `json:"lockdownduration"`
+		ImagePressure      float32                    `json:"imagepressure"`
+		PingPressure       float32                    `json:"pingpressure"`
+		LengthPressure     float32                    `json:"lengthpressure"`
+		RepeatPressure     float32                    `json:"repeatpressure"`
+		LinePressure       float32                    `json:"linepressure"`
+		BasePressure       float32                    `json:"basepressure"`
+		PressureDecay      float32                    `json:"pressuredecay"`
+		MaxPressure        float32                    `json:"maxpressure"`
+		MaxChannelPressure map[uint64]float32         `json:"maxchannelpressure"`
+		MaxRemoveLookback  int                        `json:"MaxSpamRemoveLookback"`
+		SilentRole         uint64                     `json:"silentrole"`
+		IgnoreRole         uint64                     `json:"ignorerole"`
+		RaidTime           int64                      `json:"maxraidtime"`
+		RaidSize           int                        `json:"raidsize"`
+		RaidAccountAge     int64                      `json:"raidaccountage"`
+		RaidBurstTime      int64                      `json:"raidbursttime"`
+		RaidMinScore       float32                    `json:"raidminscore"`
+		SilenceMessage     string                     `json:"silencemessage"`
+		AutoSilence        int                        `json:"autosilence"`
+		LockdownDuration   int                        `json:"lockdownduration"`
+		RaidLockdown       string                     `json:"raidlockdown"`
+		LockdownLevels     map[string]map[string]bool `json:"lockdownlevels"`
+		LockdownChannels   map[string]bool            `json:"lockdownchannels"`
+		SlowmodeChannels   map[string]bool            `json:"slowmodechannels"`
+		LockdownSlowmode   int                        `json:"lockdownslowmode"`
+		DuplicateTime      int64                      `json:"duplicatetime"`
+		DuplicateUsers     int                        `json:"duplicateusers"`
+		DuplicatePressure  float32                    `json:"duplicatepressure"`
+		ActiveLockdown     *lockdownState             `json:"activelockdown,omitempty" config:"hidden"` // Kept in the config so a lockdown can still be undone after a restart
 	} `json:"spam"`
 	Bucket struct {
 		MaxItems       int `json:"maxbucket"`
@@ -77,10 +89,12 @@ type BotConfig struct {
 		UseMemberNames bool `json:"usemembernames"`
 	} `json:"markov"`
 	Users struct {
-		TimezoneLocation string          `json:"timezonelocation"`
-		WelcomeChannel   uint64          `json:"welcomechannel"`
-		WelcomeMessage   string          `json:"welcomemessage"`
-		Roles            map[uint64]bool `json:"userroles"`
+		TimezoneLocation  string          `json:"timezonelocation"`
+		WelcomeChannel    uint64          `json:"welcomechannel"`
+		WelcomeMessage    string          `json:"welcomemessage"`
+		Roles             map[uint64]bool `json:"userroles"`
+		Appeals           bool            `json:"appeals"`
+		SilencedNameAlert bool            `json:"silencednamealert"`
 	} `json:"users"`
 	Bored struct {
 		Cooldown int64           `json:"maxbored"`
@@ -91,15 +105,26 @@ type BotConfig struct {
 		HideNegativeRules bool           `json:"hidenegativerules"`
 	} `json:"help"`
 	Log struct {
-		Cooldown int64  `json:"maxerror"`
-		Channel  uint64 `json:"logchannel"`
+		Cooldown          int64  `json:"maxerror"`
+		Channel           uint64 `json:"logchannel"`
+		ChatlogRetention  int    `json:"chatlogretention"`
+		EditlogRetention  int    `json:"editlogretention"`
+		DebuglogRetention int    `json:"debuglogretention"`
 	} `json:"log"`
 	Witty struct {
 		Responses map[string]string `json:"witty"`
 		Cooldown  int64             `json:"maxwit"`
 	} `json:"Wit"`
 	Schedule struct {
-		BirthdayRole uint64 `json:"birthdayrole"`
+		BirthdayRole      uint64 `json:"birthdayrole"`
+		StaleThreshold    int64  `json:"stalethreshold"`
+		AttendeeRole      uint64 `json:"attendeerole"`
+		EventDuration     int64  `json:"eventduration"`
+		RSVPReminder      int64  `json:"rsvpreminder"`
+		CalendarSecret    string `json:"calendarsecret" config:"hidden"`
+		BirthdayChannel   uint64 `json:"birthdaychannel"`
+		BirthdayMessage   string `json:"birthdaymessage"`
+		CalendarBirthdays bool   `json:"calendarbirthdays"`
 	} `json:"schedule"`
 	Search struct {
 		MaxResults int `json:"maxsearchresults"`
@@ -150,9 +175,20 @@ var ConfigHelp map[string]string = map[string]string{
 	"spam.silentrole":             "This should be a role with no permissions, so the bot can quarantine potential spammers without banning them.",
 	"spam.raidtime":               "In order to trigger a raid alarm, at least `spam.raidsize` people must join the chat within this many seconds of each other.",
 	"spam.raidsize":               "Specifies how many people must have joined the server within the `spam.raidtime` period to qualify as a raid.",
+	"spam.raidaccountage":         "Accounts younger than this many seconds when they join the server add up to 1 point to their raid score, depending on how new they are. Defaults to 604800 (1 week).",
+	"spam.raidbursttime":          "Users that join within this many seconds of other users add up to 1 point to their raid score, reaching 1 point if `spam.raidsize` users joined together. Defaults to 10.",
+	"spam.raidminscore":           "Only users with a raid score of at least this much count towards `spam.raidsize`. Users also get 1 point for a default avatar and 1 point for a username similar to someone else who joined. Use `!raidreport` to see everyone's scores. If set to 0, everyone counts. Defaults to 1.5.",
 	"spam.silencemessage":         "This message will be sent to users that have been silenced by the `!silence` command.",
 	"spam.autosilence":            "Gets the current autosilence state. Use the `!autosilence` command to set this.",
-	"spam.lockdownduration":       "Determines how long the server's verification mode will temporarily be increased to tableflip levels after a raid is detected. If set to 0, disables lockdown entirely.",
+	"spam.lockdownduration":       "Determines how long the lockdown engaged after a raid is detected will last, in seconds. If set to 0, disables lockdown entirely.",
+	"spam.raidlockdown":           "The lockdown level from `spam.lockdownlevels` that is engaged when a raid is detected. If empty, no lockdown is engaged.",
+	"spam.lockdownlevels":         "A map of lockdown levels that can be engaged via `!lockdown`, each set to a list of actions: `verification` raises the server verification level, `slowmode` sets slowmode on every channel in `spam.slowmodechannels`, `lock` stops @everyone from sending messages in every channel in `spam.lockdownchannels`, `invites` pauses all invite links, and `silence` silences everyone who joins. Example: `!setconfig spam.lockdownlevels full verification slowmode lock invites silence`",
+	"spam.lockdownchannels":       "A list of channels that @everyone is no longer allowed to send messages in during a lockdown with the `lock` action.",
+	"spam.slowmodechannels":       "A list of channels that get slowmode during a lockdown with the `slowmode` action.",
+	"spam.lockdownslowmode":       "How many seconds of slowmode to set on each channel in `spam.slowmodechannels` during a lockdown with the `slowmode` action.",
+	"spam.duplicatetime":          "If at least `spam.duplicateusers` different people post the same message within this many seconds of each other, they will all receive `spam.duplicatepressure` and the moderators will be alerted. Messages are compared ignoring case, punctuation, pings and spacing. Defaults to 10.",
+	"spam.duplicateusers":         "Specifies how many different people must post the same message within `spam.duplicatetime` seconds to be considered coordinated spam. If set to less than 2, disables duplicate detection entirely. Defaults to 3.",
+	"spam.duplicatepressure":      "Additional pressure generated by each message that is part of a group of identical messages posted by several different people. Defaults to (MaxPressure - BasePressure) / 3.",
 	"bucket.maxitems":             "Determines the maximum number of items sweetiebot can carry in her bucket. If set to 0, her bucket is disabled.",
 	"bucket.maxitemlength":        "Determines the maximum length of a string that can be added to her bucket.",
 	"bucket.maxfighthp":           "Maximum HP of the randomly generated enemy for the `!fight` command.",
@@ -165,15 +201,27 @@ var ConfigHelp map[string]string = map[string]string{
 	"users.welcomechannel":        "If set to a channel ID, the bot will treat this channel as a \"quarantine zone\" for silenced members. If autosilence is enabled, new users will be sent to this channel.",
 	"users.welcomemessage":        "If autosilence is enabled, this message will be sent to a new user upon joining.",
 	"users.roles":                 "A list of all user-assignable roles. Manage it via !addrole and !removerole",
+	"users.appeals":               "If true, users that are silenced or banned will be sent a PM with a case number. They can reply with a single message appealing their punishment, which will be forwarded to the mod channel. Use `!appeal` to accept or deny it.",
+	"users.silencednamealert":     "If true, the bot will post in the mod channel whenever a silenced user changes their username or nickname.",
 	"bored.cooldown":              "The bored cooldown timer, in seconds. This is the length of time a channel must be inactive for sweetiebot to post a bored message in it.",
 	"bored.commands":              "This determines what commands sweetie will run when she gets bored. She will choose one command from this list at random.\n\nExample: `!setconfig bored.commands !drop \"!pick bored\"`",
 	"help.rules":                  "Contains a list of numbered rules. The numbers do not need to be contiguous, and can be negative.",
 	"help.hidenegativerules":      "If true, `!rules -1` will display a rule at index -1, but `!rules` will not. This is useful for joke rules or additional rules that newcomers don't need to know about.",
 	"log.channel":                 "This is the channel where sweetiebot logs her output.",
 	"log.cooldown":                "The cooldown time for sweetiebot to display an error message, in seconds, intended to prevent the bot from spamming itself. Default: 4",
+	"log.chatlogretention":        "Number of days messages are kept in the chat log used by `!search`, `!history` and `!deleted`. If set to 0, messages are never deleted. Default: 7",
+	"log.editlogretention":        "Number of days previous versions of edited messages are kept. If set to 0, they are kept as long as the message itself. Default: 7",
+	"log.debuglogretention":       "Number of days sweetiebot's own log entries are kept. If set to 0, they are never deleted. Default: 8",
 	"witty.responses":             "Stores the replies used by the Witty module and must be configured using `!addwit` or `!removewit`",
 	"witty.cooldown":              "The cooldown time for the witty module. At least this many seconds must have passed before the bot will make another witty reply.",
 	"schedule.birthdayrole":       " This is the role given to members on their birthday.",
+	"schedule.stalethreshold":     "Number of seconds an event can be late before it is skipped instead, for example because the bot was offline. The moderators are notified about skipped events. Unbans, unsilences and reminders are never skipped. Set to 0 to never skip events.",
+	"schedule.attendeerole":       "If set, this role is given to everyone who RSVPed to an event when it starts, and removed again once it is over.",
+	"schedule.eventduration":      "Number of seconds after the start of an event that the attendee role is removed again. Default: 7200",
+	"schedule.rsvpreminder":       "Number of seconds before an event starts that everyone who RSVPed to it is sent a private reminder. Set to 0 to disable reminders. Default: 3600",
+	"schedule.birthdaychannel":    "If set, birthdays are announced in this channel instead of the default channel.",
+	"schedule.birthdaymessage":    "The message sent on a member's birthday. `{user}` is replaced with a ping to the member, and `{age}` with their new age. If the member didn't give their birth year or hid it, messages containing `{age}` are replaced by the default. Default: Happy Birthday {user}!",
+	"schedule.calendarbirthdays":  "If true, member birthdays are included in the calendar exported by `!calendar export` and served at the calendar URL. Anyone with the URL can see them, so this is off by default.",
 	"search.maxresults":           "Maximum number of search results that can be requested at once.",
 	"spoiler.channels":            "A list of channels that are exempt from the spoiler rules.",
 	"status.cooldown":             "Number of seconds sweetiebot waits before changing her status to a string picked randomly from the `status` collection.",
@@ -223,6 +271,8 @@ type SweetieBot struct {
 	MainGuildID        uint64
 	DBGuilds           map[uint64]bool   `json:"dbguilds"`
 	DebugChannels      map[string]string `json:"debugchannels"`
+	CalendarAddr       string            `json:"calendaraddr"`
+	CalendarURL        string            `json:"calendarurl"`
 	quit               AtomicBool
 	guilds             map[uint64]*GuildInfo
 	guildsLock         sync.RWMutex
@@ -349,7 +399,6 @@ func AttachToGuild(g *discordgo.Guild) {
 				commandlimit: &SaturationLimit{[]int64{}, 0, AtomicFlag{0}},
 				commands:     make(map[string]Command),
 				emotemodule:  nil,
-				lockdown:     -1,
 			}
 			sb.guildsLock.Lock()
 			sb.guilds[SBatoi(g.ID)] = guild
@@ -370,7 +419,6 @@ func AttachToGuild(g *discordgo.Guild) {
 		commandlimit: &SaturationLimit{[]int64{}, 0, AtomicFlag{0}},
 		commands:     make(map[string]Command),
 		emotemodule:  nil,
-		lockdown:     -1,
 		lastlogerr:   0,
 	}
 	config, err := ioutil.ReadFile(g.ID + ".json")
@@ -516,7 +564,7 @@ func AttachToGuild(g *discordgo.Guild) {
 	guild.modules = append(guild.modules, &BucketModule{})
 	guild.modules = append(guild.modules, &MiscModule{guild.emotemodule})
 	guild.modules = append(guild.modules, &ConfigModule{})
-	guild.modules = append(guild.modules, &SpamModule{tracker: make(map[uint64]*userPressure), lastraid: 0})
+	guild.modules = append(guild.modules, &SpamModule{tracker: make(map[uint64]*userPressure), duplicates: make(map[string]*duplicateGroup), lastraid: 0})
 	guild.modules = append(guild.modules, wittymodule)
 	guild.modules = append(guild.modules, &StatusModule{})
 	guild.modules = append(guild.modules, &BoredModule{lastmessage: 0})
@@ -772,12 +820,14 @@ func SBProcessCommand(s *discordgo.Session, m *discordgo.Message, info *GuildInf
 				info.Error(m.ChannelID, "Sorry, "+args[0]+" is not a valid command.\nFor a list of valid commands, type !help.")
 			}
 		}
-	} else if info != nil { // If info is nil this was sent through a private message so just ignore it completely
+	} else if info != nil {
 		for _, h := range info.hooks.OnMessageCreate {
 			if info.ProcessModule(m.ChannelID, h) {
 				h.OnMessageCreate(info, m)
 			}
 		}
+	} else if !m.Author.Bot { // If info is nil this was sent through a private message, which is only used to answer appeals
+		ReceiveAppeal(m)
 	}
 }
 
@@ -812,7 +862,7 @@ func sbMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
 		if info != nil && isdbguild && sb.db.CheckStatus() { // Log this message if it was sent to the main guild only.
 			cid := SBatoi(m.ChannelID)
 			if cid != info.config.Log.Channel {
-				sb.db.AddMessage(SBatoi(m.ID), SBatoi(m.Author.ID), SanitizeMentions(m.ContentWithMentionsReplaced()), cid, m.MentionEveryone, SBatoi(ch.GuildID))
+				sb.db.AddMessage(SBatoi(m.ID), SBatoi(m.Author.ID), SanitizeMentions(m.ContentWithMentionsReplaced()), cid, m.MentionEveryone, SBatoi(ch.GuildID), len(m.Attachments))
 			}
 		}
 		if info != nil {
@@ -864,7 +914,7 @@ func sbMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
 	}
 	cid := SBatoi(m.ChannelID)
 	if cid != info.config.Log.Channel && !private && sb.IsDBGuild(info) && sb.db.CheckStatus() { // Always ignore messages from the log channel
-		sb.db.AddMessage(SBatoi(m.ID), SBatoi(m.Author.ID), SanitizeMentions(m.ContentWithMentionsReplaced()), cid, m.MentionEveryone, SBatoi(ch.GuildID))
+		sb.db.AddMessage(SBatoi(m.ID), SBatoi(m.Author.ID), SanitizeMentions(m.ContentWithMentionsReplaced()), cid, m.MentionEveryone, SBatoi(ch.GuildID), len(m.Attachments))
 	}
 	if m.Author.ID == sb.SelfID {
 		return
@@ -883,12 +933,43 @@ func sbMessageDelete(s *discordgo.Session, m *discordgo.MessageDelete) {
 	if boolXOR(sb.Debug, info.IsDebug(m.ChannelID)) {
 		return
 	}
+	if sb.IsDBGuild(info) && sb.db.CheckStatus() {
+		sb.db.AddDeleted(SBatoi(m.ID))
+	}
 	for _, h := range info.hooks.OnMessageDelete {
 		if info.ProcessModule(m.ChannelID, h) {
 			h.OnMessageDelete(info, m.Message)
 		}
 	}
 }
+func sbMessageReactionAdd(s *discordgo.Session, m *discordgo.MessageReactionAdd) {
+	info := getChannelGuild(m.ChannelID)
+	if info == nil || m.UserID == sb.SelfID {
+		return
+	}
+	if boolXOR(sb.Debug, info.IsDebug(m.ChannelID)) {
+		return
+	}
+	for _, h := range info.hooks.OnReactionAdd {
+		if info.ProcessModule(m.ChannelID, h) {
+			h.OnMessageReactionAdd(info, m)
+		}
+	}
+}
+func sbMessageReactionRemove(s *discordgo.Session, m *discordgo.MessageReactionRemove) {
+	info := getChannelGuild(m.ChannelID)
+	if info == nil || m.UserID == sb.SelfID {
+		return
+	}
+	if boolXOR(sb.Debug, info.IsDebug(m.ChannelID)) {
+		return
+	}
+	for _, h := range info.hooks.OnReactionRemove {
+		if info.ProcessModule(m.ChannelID, h) {
+			h.OnMessageReactionRemove(info, m)
+		}
+	}
+}
 func sbUserUpdate(s *discordgo.Session, m *discordgo.UserUpdate) {
 	ProcessUser(m.User, nil)
 }
@@ -957,6 +1038,9 @@ func sbGuildMemberUpdate(s *discordgo.Session, m *discordgo.GuildMemberUpdate) {
 	if info == nil {
 		return
 	}
+	if info.config.Users.SilencedNameAlert && info.config.Spam.SilentRole != 0 {
+		notifySilencedRename(info, m.Member)
+	}
 	info.ProcessMember(m.Member)
 
 	for _, h := range info.hooks.OnGuildMemberUpdate {
@@ -1076,7 +1160,11 @@ func idleCheckLoop() {
 				}
 			}
 
-			if info.lockdown != -1 && time.Now().UTC().Sub(info.lastlockdown) > (time.Duration(info.config.Spam.LockdownDuration)*time.Second) {
+			if sb.IsDBGuild(info) && sb.db.CheckStatus() && RateLimit(&info.lastpurge, 3600) { // Purge logs here instead of in a module, so it still happens when modules are disabled
+				PurgeLogs(info)
+			}
+
+			if l := info.config.Spam.ActiveLockdown; l != nil && l.Duration > 0 && time.Now().UTC().Sub(l.Engaged) > l.Duration {
 				DisableLockdown(info)
 			}
 		}
@@ -1168,11 +1256,11 @@ func New(token string) *SweetieBot {
 
 	mainguildid := SBatoi(strings.TrimSpace(string(mainguild)))
 	sb = &SweetieBot{
-		version:            Version{0, 9, 8, 14},
+		version:            Version{0, 9, 8, 39},
 		Debug:              false,
 		Owners:             map[uint64]bool{95585199324143616: true},
-		RestrictedCommands: map[string]bool{"search": true, "lastping": true, "setstatus": true},
-		NonServerCommands:  map[string]bool{"about": true, "roll": true, "episodegen": true, "bestpony": true, "episodequote": true, "help": true, "listguilds": true, "update": true, "announce": true, "dumptables": true, "defaultserver": true},
+		RestrictedCommands: map[string]bool{"search": true, "lastping": true, "setstatus": true, "simulatespam": true, "history": true, "deleted": true, "purgelog": true, "stats": true},
+		NonServerCommands:  map[string]bool{"about": true, "roll": true, "episodegen": true, "bestpony": true, "episodequote": true, "help": true, "listguilds": true, "update": true, "announce": true, "dumptables": true, "defaultserver": true, "mydata": true, "forgetuser": true},
 		MainGuildID:        mainguildid,
 		DBGuilds:           make(map[uint64]bool),
 		DebugChannels:      make(map[string]string),
@@ -1184,6 +1272,31 @@ func New(token string) *SweetieBot {
 		heartbeat:          4294967290,
 		MessageCount:       0,
 		changelog: map[int]string{
+			AssembleVersion(0, 9, 8, 39): "- Added !pollsettings, which can close a poll at a given time, let members pick several options or rank them, restrict voting to a role, or make a poll anonymous\n- Ranked polls are counted by instant runoff, and !results shows every round\n- !vote accepts several options like `!vote poll 3 1 2`\n- Added !voters, which lists who voted for what unless the poll is anonymous. Existing databases must run `ALTER TABLE polls ADD COLUMN Choices TINYINT UNSIGNED NOT NULL DEFAULT 1, ADD COLUMN Ranked TINYINT UNSIGNED NOT NULL DEFAULT 0, ADD COLUMN Role BIGINT UNSIGNED NOT NULL DEFAULT 0, ADD COLUMN Anonymous TINYINT UNSIGNED NOT NULL DEFAULT 0, ADD COLUMN Closed TINYINT UNSIGNED NOT NULL DEFAULT 0;` and `ALTER TABLE votes ADD COLUMN `Rank` TINYINT UNSIGNED NOT NULL DEFAULT 1, DROP PRIMARY KEY, ADD PRIMARY KEY (Poll, User, `Option`);`",
+			AssembleVersion(0, 9, 8, 38): "- Added !postpoll, which posts a poll with a numbered reaction for each option. Reacting counts as a vote just like !vote, and the post always shows the current results. Existing databases must run `ALTER TABLE polls ADD COLUMN Channel BIGINT UNSIGNED NOT NULL DEFAULT 0, ADD COLUMN Message BIGINT UNSIGNED NOT NULL DEFAULT 0, ADD INDEX INDEX_MESSAGE (Message);`",
+			AssembleVersion(0, 9, 8, 37): "- Added !mybirthday, so members can set their own birthday, optionally with a birth year that can be hidden\n- Added !birthdays, which lists upcoming birthdays or the birthdays in a month\n- Birthdays can be announced in their own channel with a custom message\n- Birthdays are removed when the member leaves the server, and !addbirthday now replaces a member's old birthday\n- Birthdays on February 29 are celebrated on February 28 in years without one",
+			AssembleVersion(0, 9, 8, 36): "- Added !calendar, which exports upcoming episodes and events as an .ics file, or imports events from one. Birthdays are only exported if Schedule.CalendarBirthdays is enabled\n- The bot can serve each server's calendar at a secret URL that calendar apps can subscribe to, see INSTALLATION.md",
+			AssembleVersion(0, 9, 8, 35): "- Added !reminders, !snooze and !editreminder to manage your reminders\n- !remindme here ... posts the reminder in the current channel with a link back to your message instead of sending it privately",
+			AssembleVersion(0, 9, 8, 34): "- Times can now be relative, like \"in 2 hours\", \"3d4h\", \"tomorrow at 5pm\", \"next tuesday\" or \"2 days ago\", and can end with a timezone like \"18:00 Europe/Berlin\". This works in !remindme, !addevent, !search, !transcript, and the durations of !ban and !silence",
+			AssembleVersion(0, 9, 8, 33): "- Members can now RSVP to events by reacting to their announcement or using !rsvp, and get a private reminder before they start (configure with Schedule.RSVPReminder)\n- !schedule shows how many members are attending an event\n- Added Schedule.AttendeeRole and Schedule.EventDuration, which give attendees a role while an event is running. Existing databases need the new rsvps table from sweetiebot.sql",
+			AssembleVersion(0, 9, 8, 32): "- Scheduled events that fail are now retried with increasing delays, and moderators are notified when the bot gives up. Use !failedevents to list and retry them\n- Added Schedule.StaleThreshold, which skips events that are too late, like announcements that were due while the bot was offline. Existing databases need the new Status, Attempts, Retry and LastError columns in the schedule table from sweetiebot.sql",
+			AssembleVersion(0, 9, 8, 31): "- Scheduled events now store their data as JSON, and existing events are converted automatically\n- Modules can now define their own kinds of scheduled events",
+			AssembleVersion(0, 9, 8, 30): "- !addevent now accepts cron expressions and recurrences like \"every Friday 20:00\" or \"first Monday of the month\", which are evaluated in the server's timezone and follow daylight savings changes\n- !schedule shows the next few occurrences of recurring events. Existing databases need the new Cron column in the schedule table from sweetiebot.sql",
+			AssembleVersion(0, 9, 8, 29): "- Username, nickname and avatar changes are now recorded with timestamps, and !aka shows the most recent ones. Existing databases need the new namelog table, the new members_before_update trigger and the updated users_before_update trigger from sweetiebot.sql\n- Added Users.SilencedNameAlert, which notifies the mod channel when a silenced user changes their name",
+			AssembleVersion(0, 9, 8, 28): "- !userinfo now shows moderators an embed with account age, recent activity, spam pressure, silence status, pending bans and recent audit log entries",
+			AssembleVersion(0, 9, 8, 27): "- Added !joinstats, which reports joins, leaves, net growth and how quickly newcomers start talking. Existing databases need the new departures table and members_before_delete trigger from sweetiebot.sql",
+			AssembleVersion(0, 9, 8, 26): "- Added !stats, which shows message activity, busiest hours, top posters and top channels for the server, a channel or a user",
+			AssembleVersion(0, 9, 8, 25): "- Added !transcript, which uploads an HTML or plain text transcript of part of a channel to the mod channel",
+			AssembleVersion(0, 9, 8, 24): "- Added !mydata, which sends you a ZIP file with everything the bot has stored about you\n- Added !forgetuser, which lets the bot owner delete everything stored about a user on every server",
+			AssembleVersion(0, 9, 8, 23): "- Log retention is now configured per server via Log.ChatlogRetention, Log.EditlogRetention and Log.DebuglogRetention, and old entries are purged by the scheduler every hour. Existing databases should drop the CleanChatlog and CleanDebugLog events\n- Added !purgelog, which deletes all logged data of a user",
+			AssembleVersion(0, 9, 8, 22): "- Added !history and !deleted, which show previous versions of a message and messages deleted from a channel\n- The editlog now keeps every version of a message instead of just the original, and deleted messages are recorded in the new deletelog table. Existing databases must create the deletelog table, change the editlog primary key to (ID, Timestamp), and update the chatlog triggers from sweetiebot.sql",
+			AssembleVersion(0, 9, 8, 21): "- !search now supports before:, after:, during:, has:link, has:attachment, mentions:, edited:, regex: and -excluded words\n- The chatlog now records how many attachments a message had. Existing databases must run `ALTER TABLE chatlog ADD COLUMN Attachments TINYINT UNSIGNED NOT NULL DEFAULT 0;` and update the AddChat procedure from sweetiebot.sql",
+			AssembleVersion(0, 9, 8, 20): "- !search now uses a fulltext index, supports phrases, OR and NOT, and returns the most relevant results first. Existing databases must run `ALTER TABLE chatlog ADD FULLTEXT INDEX INDEX_MESSAGE (Message);`\n- Several searches can now run at the same time",
+			AssembleVersion(0, 9, 8, 19): "- Added configurable lockdown levels (Spam.LockdownLevels) that can also set slowmode, lock channels, pause invites, and silence everyone who joins\n- Added !lockdown and !unlock\n- Raid lockdowns now use the level in Spam.RaidLockdown",
+			AssembleVersion(0, 9, 8, 18): "- Raid detection now scores users on account age, default avatars, similar usernames and how many people joined together, so normal join waves don't trigger it\n- Added !raidreport",
+			AssembleVersion(0, 9, 8, 17): "- Sweetie now detects several users posting the same message at once, adds pressure to all of them and alerts the moderators",
+			AssembleVersion(0, 9, 8, 16): "- !getpressure now shows a timeline of where a user's pressure came from\n- Added !simulatespam to test alternative spam settings against the chat log",
+			AssembleVersion(0, 9, 8, 15): "- Silenced and banned users are now sent a case number they can appeal over PM\n- Added !appeal to accept or deny appeals",
 			AssembleVersion(0, 9, 8, 14): "- Reduce database pressure on startup",
 			AssembleVersion(0, 9, 8, 13): "- Fix crash on startup.\n- Did more code refactoring, fixed several spelling errors.",
 			AssembleVersion(0, 9, 8, 12): "- Do bulk member insertions in single batch to reduce database pressure.\n- Removed bestpony command\n- Did large internal code refactor",
@@ -1305,6 +1418,10 @@ func New(token string) *SweetieBot {
 	if err == nil && len(dbguilds) > 0 {
 		json.Unmarshal(dbguilds, sb)
 	}
+	calendar, err := ioutil.ReadFile("calendar")
+	if err == nil && len(calendar) > 0 {
+		json.Unmarshal(calendar, sb)
+	}
 	sb.DBGuilds[sb.MainGuildID] = true
 
 	rand.Intn(10)
@@ -1344,6 +1461,8 @@ func New(token string) *SweetieBot {
 	sb.dg.AddHandler(sbMessageCreate)
 	sb.dg.AddHandler(sbMessageUpdate)
 	sb.dg.AddHandler(sbMessageDelete)
+	sb.dg.AddHandler(sbMessageReactionAdd)
+	sb.dg.AddHandler(sbMessageReactionRemove)
 	sb.dg.AddHandler(sbUserUpdate)
 	sb.dg.AddHandler(sbPresenceUpdate)
 	sb.dg.AddHandler(sbGuildUpdate)
@@ -1367,6 +1486,9 @@ func New(token string) *SweetieBot {
 
 	go idleCheckLoop()
 	go deadlockDetector()
+	if len(sb.CalendarAddr) > 0 {
+		go startCalendarServer(sb.CalendarAddr)
+	}
 
 	//BuildMarkov(1, 1)
 	return sb
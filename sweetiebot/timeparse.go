@@ -0,0 +1,167 @@
+package sweetiebot
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationRegex = regexp.MustCompile(`^(\d+|an?) ?([a-z]+)[ ,]*(?:and )?`)
+
+// parseDurationUnit accepts abbreviations like "h" or "mins" on top of everything parseRepeatInterval accepts, and returns the same interval
+func parseDurationUnit(s string) uint8 {
+	switch s {
+	case "s", "sec", "secs":
+		return 1
+	case "m", "min", "mins":
+		return 2
+	case "h", "hr", "hrs":
+		return 3
+	case "d":
+		return 4
+	case "w", "wk", "wks":
+		return 5
+	case "mo", "mos":
+		return 6
+	case "y", "yr", "yrs":
+		return 8
+	}
+	return parseRepeatInterval(s)
+}
+
+// addInterval adds n units of an interval returned by parseRepeatInterval to a time. Months, quarters and years follow the calendar.
+func addInterval(t time.Time, n int, interval uint8) (time.Time, bool) {
+	switch interval {
+	case 1:
+		return t.Add(time.Duration(n) * time.Second), true
+	case 2:
+		return t.Add(time.Duration(n) * time.Minute), true
+	case 3:
+		return t.Add(time.Duration(n) * time.Hour), true
+	case 4:
+		return t.AddDate(0, 0, n), true
+	case 5:
+		return t.AddDate(0, 0, n*7), true
+	case 6:
+		return t.AddDate(0, n, 0), true
+	case 7:
+		return t.AddDate(0, n*3, 0), true
+	case 8:
+		return t.AddDate(n, 0, 0), true
+	}
+	return t, false
+}
+
+// addDuration adds a duration like "2 hours", "3d4h", "an hour" or "1 week and 2 days" to a time, or subtracts it if sign is negative
+func addDuration(t time.Time, s string, sign int) (time.Time, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if len(s) == 0 {
+		return t, errors.New("no duration was given")
+	}
+	for len(s) > 0 {
+		m := durationRegex.FindStringSubmatch(s)
+		if m == nil {
+			return t, errors.New(s + " is not a valid duration")
+		}
+		n := 1
+		if m[1] != "a" && m[1] != "an" {
+			var err error
+			if n, err = strconv.Atoi(m[1]); err != nil {
+				return t, errors.New(m[1] + " is too large")
+			}
+		}
+		var ok bool
+		if t, ok = addInterval(t, n*sign, parseDurationUnit(m[2])); !ok {
+			return t, errors.New(m[2] + " is not a unit of time")
+		}
+		s = s[len(m[0]):]
+	}
+	return t, nil
+}
+
+// parseTimeLocation returns the timezone named by s, like "Europe/Berlin" or "UTC", or nil if s isn't the name of a timezone
+func parseTimeLocation(s string) *time.Location {
+	if !strings.Contains(s, "/") && !strings.EqualFold(s, "utc") {
+		return nil
+	}
+	if loc, err := time.LoadLocation(s); err == nil {
+		return loc
+	}
+	if loc, err := time.LoadLocation(strings.Title(strings.ToLower(s))); err == nil { // Timezone names are case sensitive, so "europe/berlin" has to become "Europe/Berlin"
+		return loc
+	}
+	if strings.EqualFold(s, "utc") {
+		return time.UTC
+	}
+	return nil
+}
+
+// parseRelativeTime understands times relative to now, like "in 2 hours", "3d4h", "2 days ago", "tomorrow at 5pm", "next tuesday", "next week" or "at 18:00", and interprets them in the given timezone. A time of day on its own refers to the next time it happens, and days without a time refer to midnight.
+func parseRelativeTime(s string, now time.Time, tz *time.Location) (time.Time, error) {
+	s = strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	switch {
+	case len(s) == 0:
+		return now, errors.New("no time was given")
+	case s == "now":
+		return now, nil
+	case strings.HasPrefix(s, "in "):
+		return addDuration(now, s[3:], 1)
+	case strings.HasSuffix(s, " ago"):
+		return addDuration(now, s[:len(s)-4], -1)
+	}
+	if t, err := addDuration(now, s, 1); err == nil {
+		return t, nil
+	}
+
+	local := now.In(tz)
+	words := strings.Split(s, " ")
+	if len(words) == 2 && words[0] == "next" {
+		if t, ok := addInterval(now, 1, parseDurationUnit(words[1])); ok {
+			return t, nil
+		}
+	}
+	offset := 0
+	anchored := true
+	switch words[0] {
+	case "today":
+		words = words[1:]
+	case "tomorrow":
+		offset = 1
+		words = words[1:]
+	case "yesterday":
+		offset = -1
+		words = words[1:]
+	default:
+		if len(words) > 1 && (words[0] == "next" || words[0] == "this" || words[0] == "on") {
+			words = words[1:]
+		}
+		if day := parseRecurrenceDay(words[0]); day >= 0 {
+			offset = (day - int(local.Weekday()) + 7) % 7
+			if offset == 0 {
+				offset = 7
+			}
+			words = words[1:]
+		} else {
+			anchored = false
+		}
+	}
+
+	hour, minute := 0, 0
+	clock := strings.Join(words, " ")
+	if !anchored && strings.Trim(clock, "0123456789") == "" {
+		return now, errors.New(s + " is not a valid time")
+	}
+	if len(clock) > 0 {
+		var err error
+		if hour, minute, err = parseTimeOfDay(clock); err != nil {
+			return now, err
+		}
+	}
+	t := time.Date(local.Year(), local.Month(), local.Day()+offset, hour, minute, 0, 0, tz)
+	if !anchored && !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,64 @@
+package sweetiebot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRelativeTime(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	now := time.Date(2024, 9, 4, 15, 0, 0, 0, time.UTC) // A wednesday
+	at := func(month time.Month, day int, hour int, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		in   string
+		tz   *time.Location
+		want time.Time
+	}{
+		{"now", time.UTC, now},
+		{"in 2 hours", time.UTC, at(9, 4, 17, 0)},
+		{"In  2   Hours", time.UTC, at(9, 4, 17, 0)},
+		{"3d4h", time.UTC, at(9, 7, 19, 0)},
+		{"2 days ago", time.UTC, at(9, 2, 15, 0)},
+		{"an hour", time.UTC, at(9, 4, 16, 0)},
+		{"in a minute", time.UTC, at(9, 4, 15, 1)},
+		{"1 week and 2 days", time.UTC, at(9, 13, 15, 0)},
+		{"in 1 month", time.UTC, at(10, 4, 15, 0)},
+		{"30 secs", time.UTC, now.Add(30 * time.Second)},
+		{"next week", time.UTC, at(9, 11, 15, 0)},
+		{"next month", time.UTC, at(10, 4, 15, 0)},
+		{"tomorrow", time.UTC, at(9, 5, 0, 0)},
+		{"tomorrow at 5pm", time.UTC, at(9, 5, 17, 0)},
+		{"yesterday noon", time.UTC, at(9, 3, 12, 0)},
+		{"today 3pm", time.UTC, at(9, 4, 15, 0)},
+		{"at 18:00", time.UTC, at(9, 4, 18, 0)},
+		{"9am", time.UTC, at(9, 5, 9, 0)},
+		{"midnight", time.UTC, at(9, 5, 0, 0)},
+		{"next tuesday", time.UTC, at(9, 10, 0, 0)},
+		{"wednesday 8pm", time.UTC, at(9, 11, 20, 0)},
+		{"on friday at 10:30", time.UTC, at(9, 6, 10, 30)},
+		{"fri", time.UTC, at(9, 6, 0, 0)},
+		{"tomorrow at 5pm", newYork, at(9, 5, 21, 0)},
+		{"at 1pm", newYork, at(9, 4, 17, 0)},
+		{"9am", newYork, at(9, 5, 13, 0)},
+		{"in 2 hours", newYork, at(9, 4, 17, 0)},
+	}
+	for _, c := range cases {
+		got, err := parseRelativeTime(c.in, now, c.tz)
+		if err != nil {
+			t.Errorf("parseRelativeTime(%q) in %s failed: %s", c.in, c.tz, err.Error())
+		} else if !got.Equal(c.want) {
+			t.Errorf("parseRelativeTime(%q) in %s = %s, want %s", c.in, c.tz, got.UTC(), c.want)
+		}
+	}
+}
+
+func TestParseRelativeTimeErrors(t *testing.T) {
+	now := time.Date(2024, 9, 4, 15, 0, 0, 0, time.UTC)
+	for _, s := range []string{"", "   ", "blah", "in 5 parsecs", "3 fortnights ago", "42", "25:00", "13pm", "next blah", "tomorrow at teatime", "in 99999999999999999999 hours"} {
+		if got, err := parseRelativeTime(s, now, time.UTC); err == nil {
+			t.Errorf("parseRelativeTime(%q) = %s, expected an error", s, got)
+		}
+	}
+}
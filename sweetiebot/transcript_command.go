@@ -0,0 +1,282 @@
+package sweetiebot
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blackhole12/discordgo"
+)
+
+const transcriptMaxMessages = 5000
+const transcriptMaxLookups = 20 // Maximum number of requests made to retrieve the attachments of logged messages
+const transcriptTimeFormat = "2006-01-02 15:04:05 MST"
+
+type transcriptMessage struct {
+	ID                 uint64
+	Author             string
+	Timestamp          time.Time
+	Message            string
+	Attachments        []string
+	MissingAttachments int
+	Deleted            bool
+}
+
+// parseTranscriptBound accepts a message ID, a message link or a timestamp and returns the corresponding snowflake ID. If end is true, a timestamp includes every message sent during that minute.
+func parseTranscriptBound(s string, end bool, info *GuildInfo, user *discordgo.User) (uint64, error) {
+	if strings.Contains(s, "/channels/") {
+		if id := parseMessageLink(s); id != 0 {
+			return id, nil
+		}
+		return 0, fmt.Errorf("%s is not a valid message link", s)
+	}
+	if id, err := strconv.ParseUint(s, 10, 64); err == nil && id > (1<<22) {
+		return id, nil
+	}
+	t, err := parseCommonTime(s, info, user)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a message ID, message link or timestamp", s)
+	}
+	if end {
+		return timeSnowflake(t.Add(time.Minute)) - 1, nil
+	}
+	return timeSnowflake(t), nil
+}
+
+// getTranscriptAttachments asks discord for the attachment URLs of the given messages, which must be sorted oldest first. Messages are retrieved 100 at a time, skipping ahead over messages without attachments, and no more than transcriptMaxLookups requests are made, so a transcript can't hold up the bot for long. Messages that couldn't be retrieved are missing from the result.
+func getTranscriptAttachments(channel uint64, IDs []uint64) map[uint64][]string {
+	r := make(map[uint64][]string)
+	next := 0
+	for i := 0; i < transcriptMaxLookups && next < len(IDs); i++ {
+		after := IDs[next] - 1
+		messages, err := sb.dg.ChannelMessages(SBitoa(channel), 100, "", SBitoa(after), "")
+		if err != nil || len(messages) == 0 {
+			break
+		}
+		for _, v := range messages {
+			id := SBatoi(v.ID)
+			if id > after {
+				after = id
+			}
+			for _, a := range v.Attachments {
+				r[id] = append(r[id], a.URL)
+			}
+		}
+		for next < len(IDs) && IDs[next] <= after {
+			next++
+		}
+	}
+	return r
+}
+
+func getTranscriptFromLog(info *GuildInfo, channel uint64, from uint64, to uint64) []transcriptMessage {
+	messages := sb.db.GetChatlogRange(SBatoi(info.ID), channel, from, to, transcriptMaxMessages)
+	IDs := []uint64{}
+	for _, v := range messages {
+		if v.Attachments > 0 { // The chatlog only knows how many attachments there were, so we have to ask discord for the URLs
+			IDs = append(IDs, v.ID)
+		}
+	}
+	attachments := getTranscriptAttachments(channel, IDs)
+	r := make([]transcriptMessage, 0, len(messages))
+	for _, v := range messages {
+		m := transcriptMessage{ID: v.ID, Author: getUserName(v.Author, info), Timestamp: v.Timestamp, Message: v.Message, Deleted: v.Deleted != nil}
+		if v.Attachments > 0 {
+			if urls, ok := attachments[v.ID]; ok {
+				m.Attachments = urls
+			} else {
+				m.MissingAttachments = v.Attachments
+			}
+		}
+		r = append(r, m)
+	}
+	return r
+}
+
+func getTranscriptFromDiscord(channel uint64, from uint64, to uint64) ([]transcriptMessage, error) {
+	r := []transcriptMessage{}
+	after := from
+	if after > 0 {
+		after--
+	}
+	for len(r) < transcriptMaxMessages {
+		messages, err := sb.dg.ChannelMessages(SBitoa(channel), 100, "", SBitoa(after), "")
+		if err != nil {
+			return r, err
+		}
+		if len(messages) == 0 {
+			break
+		}
+		for i := len(messages) - 1; i >= 0; i-- { // discord returns the newest messages first
+			v := messages[i]
+			id := SBatoi(v.ID)
+			if id > after {
+				after = id
+			}
+			if id > to || len(r) >= transcriptMaxMessages {
+				return r, nil
+			}
+			m := transcriptMessage{ID: id, Author: v.Author.Username, Message: v.ContentWithMentionsReplaced()}
+			m.Timestamp, err = v.Timestamp.Parse()
+			if err != nil {
+				m.Timestamp = snowflakeTime(id)
+			}
+			for _, a := range v.Attachments {
+				m.Attachments = append(m.Attachments, a.URL)
+			}
+			r = append(r, m)
+		}
+	}
+	return r, nil
+}
+
+func renderTranscriptText(messages []transcriptMessage, title string, info *GuildInfo, user *discordgo.User) string {
+	lines := []string{title, ""}
+	for _, v := range messages {
+		line := fmt.Sprintf("[%s] %s: %s", ApplyTimezone(v.Timestamp, info, user).Format(transcriptTimeFormat), v.Author, v.Message)
+		if v.Deleted {
+			line += " (deleted)"
+		}
+		lines = append(lines, line)
+		for _, a := range v.Attachments {
+			lines = append(lines, "    Attachment: "+a)
+		}
+		if v.MissingAttachments > 0 {
+			lines = append(lines, "    "+Pluralize(int64(v.MissingAttachments), " attachment")+" could not be retrieved")
+		}
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func renderTranscriptHTML(messages []transcriptMessage, title string, info *GuildInfo, user *discordgo.User) string {
+	s := []string{
+		"<!DOCTYPE html>",
+		"<html><head><meta charset=\"utf-8\"><title>" + html.EscapeString(title) + "</title>",
+		"<style>body{font-family:sans-serif;font-size:14px;background:#36393e;color:#dcddde}table{border-collapse:collapse}td{padding:2px 8px;vertical-align:top}.time{color:#72767d;white-space:nowrap}.author{font-weight:bold;white-space:nowrap}.deleted{color:#f04747}a{color:#00b0f4}</style>",
+		"</head><body><h3>" + html.EscapeString(title) + "</h3><table>",
+	}
+	for _, v := range messages {
+		class := ""
+		if v.Deleted {
+			class = " class=\"deleted\""
+		}
+		content := strings.Replace(html.EscapeString(v.Message), "\n", "<br>", -1)
+		for _, a := range v.Attachments {
+			content += "<br><a href=\"" + html.EscapeString(a) + "\">" + html.EscapeString(a) + "</a>"
+		}
+		if v.MissingAttachments > 0 {
+			content += "<br><i>" + Pluralize(int64(v.MissingAttachments), " attachment") + " could not be retrieved</i>"
+		}
+		if v.Deleted {
+			content += " <i>(deleted)</i>"
+		}
+		s = append(s, fmt.Sprintf("<tr id=\"%v\"%s><td class=\"time\">%s</td><td class=\"author\">%s</td><td>%s</td></tr>", v.ID, class, ApplyTimezone(v.Timestamp, info, user).Format(transcriptTimeFormat), html.EscapeString(v.Author), content))
+	}
+	s = append(s, "</table></body></html>")
+	return strings.Join(s, "\n")
+}
+
+type transcriptCommand struct {
+}
+
+func (c *transcriptCommand) Name() string {
+	return "Transcript"
+}
+func (c *transcriptCommand) Process(args []string, msg *discordgo.Message, indices []int, info *GuildInfo) (string, bool, *discordgo.MessageEmbed) {
+	if len(args) < 3 {
+		return "```You must specify a channel, where the transcript starts, and where it ends.```", false, nil
+	}
+	if !channelregex.MatchString(args[0]) {
+		return "```You must specify a channel, which should be an actual ping in the format #channel.```", false, nil
+	}
+	if info.config.Basic.ModChannel == 0 {
+		return "```Error: No mod channel has been set, so there's nowhere to upload the transcript to.```", false, nil
+	}
+	channel := SBatoi(args[0][2 : len(args[0])-1])
+	ch, err := sb.dg.State.Channel(SBitoa(channel))
+	if err != nil || ch.GuildID != info.ID {
+		return "```Error: That channel isn't on this server.```", false, nil
+	}
+	if !canViewLoggedChannel(info, channel, msg) {
+		return "```Error: You can only make a transcript of that channel inside it.```", false, nil
+	}
+	from, err := parseTranscriptBound(args[1], false, info, msg.Author)
+	if err != nil {
+		return "```Error: " + err.Error() + ". Remember to put timestamps in quotes if they have spaces in them.```", false, nil
+	}
+	to, err := parseTranscriptBound(args[2], true, info, msg.Author)
+	if err != nil {
+		return "```Error: " + err.Error() + ". Remember to put timestamps in quotes if they have spaces in them.```", false, nil
+	}
+	if from > to {
+		from, to = to, from
+	}
+	text := len(args) > 3 && (strings.ToLower(args[3]) == "text" || strings.ToLower(args[3]) == "txt")
+
+	var messages []transcriptMessage
+	var missing time.Time
+	if sb.IsDBGuild(info) && sb.db.CheckStatus() {
+		messages = getTranscriptFromLog(info, channel, from, to)
+		// The chat log doesn't reach back past the log retention, or past when the bot started logging this channel, so anything older than that has to come from discord
+		if oldest := sb.db.GetOldestChatlog(SBatoi(info.ID), channel); len(messages) > 0 && oldest > from {
+			older, err := getTranscriptFromDiscord(channel, from, oldest-1)
+			if err != nil {
+				info.LogError("Error retrieving messages older than the chat log: ", err)
+				missing = snowflakeTime(oldest)
+			}
+			messages = append(older, messages...)
+			if len(messages) > transcriptMaxMessages {
+				messages = messages[:transcriptMaxMessages]
+			}
+		}
+	}
+	if len(messages) == 0 {
+		messages, err = getTranscriptFromDiscord(channel, from, to)
+		if err != nil && len(messages) == 0 {
+			return "```Error retrieving messages: " + err.Error() + "```", false, nil
+		}
+	}
+	if len(messages) == 0 {
+		return "```No messages were found in that range.```", false, nil
+	}
+
+	chname := ch.Name
+	title := fmt.Sprintf("Transcript of #%s from %s to %s", chname, ApplyTimezone(messages[0].Timestamp, info, msg.Author).Format(transcriptTimeFormat), ApplyTimezone(messages[len(messages)-1].Timestamp, info, msg.Author).Format(transcriptTimeFormat))
+	name := fmt.Sprintf("transcript-%s-%s", chname, messages[0].Timestamp.Format("2006-01-02"))
+	var content string
+	if text {
+		content = renderTranscriptText(messages, title, info, msg.Author)
+		name += ".txt"
+	} else {
+		content = renderTranscriptHTML(messages, title, info, msg.Author)
+		name += ".html"
+	}
+
+	modchannel := SBitoa(info.config.Basic.ModChannel)
+	info.SendMessage(modchannel, "```"+title+", requested by "+msg.Author.Username+".```")
+	if _, err = sb.dg.ChannelFileSend(modchannel, name, strings.NewReader(content)); err != nil {
+		return "```Error uploading transcript: " + err.Error() + "```", false, nil
+	}
+	result := "Uploaded a transcript of " + Pluralize(int64(len(messages)), " message") + " to the mod channel."
+	if len(messages) >= transcriptMaxMessages {
+		result += " The transcript was cut off after " + strconv.Itoa(transcriptMaxMessages) + " messages."
+	}
+	if !missing.IsZero() {
+		result += " The transcript is incomplete, because messages older than " + ApplyTimezone(missing, info, msg.Author).Format(transcriptTimeFormat) + " are no longer in the chat log and couldn't be retrieved from discord."
+	}
+	return "```" + result + "```", false, nil
+}
+func (c *transcriptCommand) Usage(info *GuildInfo) *CommandUsage {
+	return &CommandUsage{
+		Desc: "Saves every message in a channel between two points as an HTML or plain text file and uploads it to the mod channel, for example to preserve a conversation as evidence. Messages are taken from the chat log if possible. Messages that aren't in the chat log, for example because they are older than the log retention, are retrieved from discord instead, in which case deleted messages can't be included. Timestamps are shown in your timezone, and attachments are listed as links.",
+		Params: []CommandUsageParam{
+			{Name: "#channel", Desc: "The channel to make a transcript of. Must be an actual channel ping.", Optional: false},
+			{Name: "from", Desc: "The first message to include, given as a message ID, a link to a message, or a timestamp. Put timestamps in quotes if they have spaces in them.", Optional: false},
+			{Name: "to", Desc: "The last message to include, in the same format. A timestamp includes every message sent during that minute.", Optional: false},
+			{Name: "html|text", Desc: "The format of the transcript. Defaults to HTML.", Optional: true},
+		},
+	}
+}
+func (c *transcriptCommand) UsageShort() string { return "Uploads a transcript of a channel." }
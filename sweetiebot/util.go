@@ -925,8 +925,89 @@ func MigrateSettings(config []byte, guild *GuildInfo) error {
 		guild.config.Spam.LinePressure = (guild.config.Spam.MaxPressure - guild.config.Spam.BasePressure) / 70.0
 	}
 
-	if guild.config.Version != 19 {
-		guild.config.Version = 19 // set version to most recent config version
+	if guild.config.Version <= 19 {
+		restrictCommand("appeal", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		guild.config.Users.Appeals = true
+	}
+
+	if guild.config.Version <= 20 {
+		restrictCommand("simulatespam", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 21 {
+		guild.config.Spam.DuplicateTime = 10
+		guild.config.Spam.DuplicateUsers = 3
+		guild.config.Spam.DuplicatePressure = (guild.config.Spam.MaxPressure - guild.config.Spam.BasePressure) / 3.0
+	}
+
+	if guild.config.Version <= 22 {
+		restrictCommand("raidreport", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		guild.config.Spam.RaidAccountAge = 604800
+		guild.config.Spam.RaidBurstTime = 10
+		guild.config.Spam.RaidMinScore = 1.5
+	}
+
+	if guild.config.Version <= 23 {
+		restrictCommand("lockdown", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("unlock", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		guild.config.Spam.RaidLockdown = "raid"
+		guild.config.Spam.LockdownSlowmode = 10
+		guild.config.Spam.LockdownLevels = map[string]map[string]bool{
+			"raid":   {"verification": true},
+			"strict": {"verification": true, "slowmode": true, "invites": true, "silence": true},
+			"full":   {"verification": true, "slowmode": true, "lock": true, "invites": true, "silence": true},
+		}
+	}
+
+	if guild.config.Version <= 24 {
+		restrictCommand("history", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("deleted", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 25 {
+		restrictCommand("purgelog", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		guild.config.Log.ChatlogRetention = 7
+		guild.config.Log.EditlogRetention = 7
+		guild.config.Log.DebuglogRetention = 8
+	}
+
+	if guild.config.Version <= 26 {
+		restrictCommand("transcript", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 27 {
+		restrictCommand("joinstats", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 28 && sb.db.status.get() {
+		migrateScheduleData(SBatoi(guild.ID)) // Scheduled events now store their data as JSON
+	}
+
+	if guild.config.Version <= 29 {
+		restrictCommand("failedevents", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		guild.config.Schedule.StaleThreshold = 86400
+	}
+
+	if guild.config.Version <= 30 {
+		guild.config.Schedule.EventDuration = 7200
+		guild.config.Schedule.RSVPReminder = 3600
+	}
+
+	if guild.config.Version <= 31 {
+		guild.config.Schedule.BirthdayMessage = "Happy Birthday {user}!"
+	}
+
+	if guild.config.Version <= 32 {
+		restrictCommand("postpoll", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version <= 33 {
+		restrictCommand("pollsettings", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+		restrictCommand("voters", guild.config.Modules.CommandRoles, guild.config.Basic.AlertRole)
+	}
+
+	if guild.config.Version != 34 {
+		guild.config.Version = 34 // set version to most recent config version
 		guild.SaveConfig()
 	}
 	return nil
@@ -976,10 +1057,11 @@ func getTimeFormat(monthfirst bool, fullmonth bool, year int, hours int, timezon
 	}
 	return date
 }
-func parseCommonTime(s string, info *GuildInfo, user *discordgo.User) (time.Time, error) {
+
+// parseTimeLayouts tries to parse an absolute date in every format returned by getTimeFormat. Dates without a timezone are in the given timezone, and dates without a year are in the current year.
+func parseTimeLayouts(s string, tz *time.Location) (time.Time, error) {
 	var t time.Time
 	var err error
-	tz := getTimezone(info, user)
 
 	// Iterate through every single imaginable time format that we could possibly parse
 	for year := 0; year < 3; year++ {
@@ -996,7 +1078,7 @@ func parseCommonTime(s string, info *GuildInfo, user *discordgo.User) (time.Time
 						}
 						if err == nil {
 							if year == FORMAT_NOYEAR {
-								t = t.AddDate(ApplyTimezone(time.Now().UTC(), info, user).Year(), 0, 0)
+								t = t.AddDate(time.Now().In(tz).Year(), 0, 0)
 							}
 							return t, err
 						}
@@ -1008,6 +1090,30 @@ func parseCommonTime(s string, info *GuildInfo, user *discordgo.User) (time.Time
 	return t, err
 }
 
+// parseCommonTime accepts an absolute date like "2 January 2006 3:04pm -0700", or a time relative to now like "in 2 hours", "3d4h", "tomorrow at 5pm" or "next tuesday". Either can end with a timezone like "Europe/Berlin", otherwise the user's timezone is used.
+func parseCommonTime(s string, info *GuildInfo, user *discordgo.User) (time.Time, error) {
+	tz := getTimezone(info, user)
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, " "); i > 0 {
+		if loc := parseTimeLocation(s[i+1:]); loc != nil {
+			tz = loc
+			s = strings.TrimSpace(s[:i])
+		}
+	}
+
+	if t, err := parseTimeLayouts(s, tz); err == nil {
+		return t, nil
+	}
+	if t, err := parseTimeLayouts(strings.ToLower(s), tz); err == nil { // am and pm are case sensitive
+		return t, nil
+	}
+	t, err := parseRelativeTime(s, time.Now().UTC(), tz)
+	if err != nil {
+		return t, fmt.Errorf("\"%s\" is not a date, time or duration I understand", s)
+	}
+	return t, nil
+}
+
 func getAllPerms(info *GuildInfo, user string) (int64, error) {
 	m, err := sb.dg.State.Member(info.ID, user)
 	if err != nil {
@@ -1065,6 +1171,36 @@ func snowflakeTime(id uint64) time.Time {
 	return time.Unix(int64(((id>>22)+DiscordEpoch)/1000), 0)
 }
 
+// barGraph renders count as a 10 character text bar, scaled so that max fills the entire bar
+func barGraph(count uint64, max uint64) string {
+	normalized := count
+	if max > 10 {
+		normalized = uint64(float32(count) * (10.0 / float32(max)))
+	}
+	if count > 0 && normalized < 1 {
+		normalized = 1
+	}
+
+	graph := ""
+	for i := 0; i < 10; i++ {
+		if uint64(i) < normalized {
+			graph += "\u2588" // this isn't very efficient but the maximum is 10 so it doesn't matter
+		} else {
+			graph += "\u2591"
+		}
+	}
+	return graph
+}
+
+// timeSnowflake returns the lowest snowflake ID that could have been created at the given time
+func timeSnowflake(t time.Time) uint64 {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	if ms < DiscordEpoch {
+		return 0
+	}
+	return (ms - DiscordEpoch) << 22
+}
+
 func setupSilenceRole(info *GuildInfo) {
 	if info.config.Spam.SilentRole > 0 {
 		guild, err := sb.dg.State.Guild(info.ID)
@@ -1102,3 +1238,12 @@ func UnsilenceMember(user uint64, info *GuildInfo) error {
 
 	return sb.dg.GuildMemberRoleRemove(info.ID, SBitoa(user), SBitoa(info.config.Spam.SilentRole))
 }
+
+// truncateString shortens a string to at most the given number of characters without splitting any of them
+func truncateString(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}